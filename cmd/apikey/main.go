@@ -0,0 +1,45 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/weiyuan-lane/google-translate-api/internal/services/auth"
+)
+
+// Prints a new API key, to hand to the client, and its entry for the keys
+// file, which only holds the hash
+func main() {
+	id := flag.String("id", "", "id of the client the key is for")
+	scopes := flag.String("scopes", auth.ScopeTranslate+","+auth.ScopeDetect, "comma separated scopes of the key")
+	tenant := flag.String("tenant", "", "tenant the key acts for, if any")
+	flag.Parse()
+
+	if *id == "" {
+		fmt.Fprintln(os.Stderr, "no client id given, use --id")
+		os.Exit(2)
+	}
+
+	key, err := auth.GenerateKey()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err.Error())
+		os.Exit(1)
+	}
+
+	entry, err := json.Marshal(auth.APIKey{
+		ID:     *id,
+		SHA256: auth.HashKey(key),
+		Scopes: strings.Split(*scopes, ","),
+		Tenant: *tenant,
+	})
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err.Error())
+		os.Exit(1)
+	}
+
+	fmt.Printf("Key:   %s\n", key)
+	fmt.Printf("Entry: %s\n", entry)
+}
@@ -0,0 +1,99 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"text/tabwriter"
+
+	"github.com/weiyuan-lane/google-translate-api/internal/services/glossarysync"
+	"github.com/weiyuan-lane/google-translate-api/internal/services/googletranslatewrapper"
+	"github.com/weiyuan-lane/google-translate-api/internal/utils/config"
+	"github.com/weiyuan-lane/google-translate-api/internal/utils/googletranslate"
+)
+
+func main() {
+	configFile := flag.String("config", os.Getenv("CONFIG_FILE"), "YAML or JSON config file, env vars take precedence over it")
+	manifestPath := flag.String("manifest", "", "path to the glossary manifest, GLOSSARY_SYNC_MANIFEST by default")
+	dryRun := flag.Bool("dry-run", false, "print the plan without changing any glossary")
+	flag.Parse()
+
+	appConfig, err := config.Load(*configFile)
+	exitOnError(err)
+
+	if *manifestPath == "" {
+		*manifestPath = appConfig.GlossarySyncManifest
+	}
+	if *manifestPath == "" {
+		fmt.Fprintln(os.Stderr, "no manifest given, use --manifest or GLOSSARY_SYNC_MANIFEST")
+		os.Exit(2)
+	}
+
+	manifest, err := glossarysync.LoadManifest(*manifestPath)
+	exitOnError(err)
+
+	googleTranslateV3Client, err := googletranslate.InitTranslateV3Client(appConfig.GoogleTranslateV3ClientConfig())
+	exitOnError(err)
+	defer googleTranslateV3Client.Close()
+
+	googleTranslateV3RegionalClient, err := googletranslate.InitTranslateV3RegionalClient(
+		appConfig.GoogleTranslateV3ClientConfig(),
+		appConfig.GoogleTranslateV3RegionalLocation,
+		appConfig.GoogleTranslateV3RegionalEndpoint,
+	)
+	exitOnError(err)
+	if googleTranslateV3RegionalClient != nil {
+		defer googleTranslateV3RegionalClient.Close()
+	}
+
+	storageClient, err := googletranslate.InitStorageClient(appConfig.GoogleTranslateV3ClientConfig())
+	exitOnError(err)
+	defer storageClient.Close()
+
+	syncer := glossarysync.NewSyncer(
+		googletranslatewrapper.NewTranslateV3Wrapper(
+			googleTranslateV3Client,
+			googleTranslateV3RegionalClient,
+			appConfig.GoogleTranslateV3ProjectID,
+			appConfig.GoogleTranslateV3RegionalLocation,
+		),
+		glossarysync.NewGCSUploader(storageClient),
+	)
+
+	plan, err := syncer.Sync(context.Background(), manifest, *dryRun)
+	if err != nil {
+		fmt.Println("Sync failed, applied so far:")
+		printPlan(plan)
+		fmt.Fprintln(os.Stderr, err.Error())
+		os.Exit(1)
+	}
+
+	if *dryRun {
+		fmt.Println("Dry run, no changes applied")
+	}
+	if !plan.HasChanges() {
+		fmt.Println("Glossaries are in sync")
+	}
+	printPlan(plan)
+}
+
+func printPlan(plan glossarysync.Plan) {
+	writer := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	for _, item := range plan.Items {
+		target := item.GCSSource
+		if item.Action == glossarysync.ActionDelete {
+			target = item.CurrentGCSSource
+		}
+
+		fmt.Fprintf(writer, "%s\t%s\t%s\n", item.Action, item.Name, target)
+	}
+	writer.Flush()
+}
+
+func exitOnError(err error) {
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err.Error())
+		os.Exit(1)
+	}
+}
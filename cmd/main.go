@@ -1,9 +1,32 @@
 package main
 
 import (
+	"flag"
+	"fmt"
+	"os"
+
 	"github.com/weiyuan-lane/google-translate-api/internal/server"
+	"github.com/weiyuan-lane/google-translate-api/internal/utils/config"
 )
 
 func main() {
-	server.Init()
+	configFile := flag.String("config", os.Getenv("CONFIG_FILE"), "YAML or JSON config file, env vars take precedence over it")
+	printConfig := flag.Bool("print-config", false, "print the effective config, with secrets masked, and exit")
+	flag.Parse()
+
+	appConfig, err := config.Load(*configFile)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "Failed to start server: "+err.Error())
+		os.Exit(1)
+	}
+
+	if *printConfig {
+		appConfig.Print(os.Stdout)
+		return
+	}
+
+	if err := server.Init(appConfig); err != nil {
+		fmt.Fprintln(os.Stderr, "Failed to start server: "+err.Error())
+		os.Exit(1)
+	}
 }
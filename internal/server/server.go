@@ -1,49 +1,610 @@
 package server
 
 import (
+	"context"
+	"fmt"
 	"strconv"
+	"strings"
+	"time"
 
+	"cloud.google.com/go/translate"
+	translatev3 "cloud.google.com/go/translate/apiv3"
+
+	"github.com/weiyuan-lane/google-translate-api/internal/services/auth"
+	"github.com/weiyuan-lane/google-translate-api/internal/services/configreload"
+	"github.com/weiyuan-lane/google-translate-api/internal/services/glossaryexplain"
+	"github.com/weiyuan-lane/google-translate-api/internal/services/glossarysync"
 	"github.com/weiyuan-lane/google-translate-api/internal/services/googletranslatewrapper"
+	"github.com/weiyuan-lane/google-translate-api/internal/services/health"
+	"github.com/weiyuan-lane/google-translate-api/internal/services/localglossary"
+	"github.com/weiyuan-lane/google-translate-api/internal/services/routing"
+	"github.com/weiyuan-lane/google-translate-api/internal/services/tenancy"
+	"github.com/weiyuan-lane/google-translate-api/internal/services/translationproviders"
+	"github.com/weiyuan-lane/google-translate-api/internal/services/usage"
 	httptransport "github.com/weiyuan-lane/google-translate-api/internal/transports/http"
 	"github.com/weiyuan-lane/google-translate-api/internal/utils/config"
+	"github.com/weiyuan-lane/google-translate-api/internal/utils/cors"
+	"github.com/weiyuan-lane/google-translate-api/internal/utils/errorhandlers"
 	"github.com/weiyuan-lane/google-translate-api/internal/utils/googletranslate"
 	loggerutils "github.com/weiyuan-lane/google-translate-api/internal/utils/logger"
+	"github.com/weiyuan-lane/google-translate-api/internal/utils/metrics"
+	"github.com/weiyuan-lane/google-translate-api/internal/utils/ratelimit"
+	"github.com/weiyuan-lane/google-translate-api/internal/utils/tlsconfig"
+	"github.com/weiyuan-lane/google-translate-api/internal/utils/tracing"
 )
 
-func Init() {
-	appConfig := config.ApplicationConfig()
+func Init(appConfig config.AppConfig) error {
+	errorhandlers.SetAppName(appConfig.AppName)
 
 	logger := loggerutils.New(
 		appConfig.AppName,
 		appConfig.IsDevEnv,
 	)
+	if appConfig.LogRedaction != "" {
+		redactionPolicy, err := loggerutils.ParseRedactionPolicy(appConfig.LogRedaction)
+		if err != nil {
+			return fmt.Errorf("invalid config LOG_REDACTION: %w", err)
+		}
+		logger = logger.WithRedaction(redactionPolicy)
+		logger.Info(fmt.Sprintf("Redacting user content in logs with %s", redactionPolicy))
+	}
 
-	googleTranslateV2Client := googletranslate.InitTranslateV2Client(
-		appConfig.GoogleTranslateV2APIKey,
-	)
-	defer googleTranslateV2Client.Close()
+	var googleTranslateV2Client *translate.Client
+	if appConfig.GoogleTranslateV2Enabled {
+		client, err := googletranslate.InitTranslateV2Client(
+			appConfig.GoogleTranslateV2ClientConfig(),
+		)
+		if err != nil {
+			return err
+		}
+		defer client.Close()
+
+		googleTranslateV2Client = client
+	}
+
+	var googleTranslateV3Client, googleTranslateV3RegionalClient *translatev3.TranslationClient
+	if appConfig.GoogleTranslateV3Enabled {
+		client, err := googletranslate.InitTranslateV3Client(
+			appConfig.GoogleTranslateV3ClientConfig(),
+		)
+		if err != nil {
+			return err
+		}
+		defer client.Close()
+
+		regionalClient, err := googletranslate.InitTranslateV3RegionalClient(
+			appConfig.GoogleTranslateV3ClientConfig(),
+			appConfig.GoogleTranslateV3RegionalLocation,
+			appConfig.GoogleTranslateV3RegionalEndpoint,
+		)
+		if err != nil {
+			return err
+		}
+		if regionalClient != nil {
+			defer regionalClient.Close()
+		}
+
+		googleTranslateV3Client = client
+		googleTranslateV3RegionalClient = regionalClient
+	}
+
+	if googleTranslateV2Client == nil && googleTranslateV3Client == nil {
+		return fmt.Errorf("invalid config GOOGLE_TRANSLATE_V2_ENABLED: at least one of GOOGLE_TRANSLATE_V2_ENABLED and GOOGLE_TRANSLATE_V3_ENABLED must be true")
+	}
+
+	shutdownTracing, err := tracing.Init(context.Background(), tracing.Config{
+		ServiceName:  appConfig.AppName,
+		OTLPEndpoint: appConfig.TracingOTLPEndpoint,
+		OTLPInsecure: appConfig.TracingOTLPInsecure,
+		SampleRatio:  appConfig.TracingSampleRatio,
+	})
+	if err != nil {
+		return fmt.Errorf("invalid config TRACING_OTLP_ENDPOINT: %w", err)
+	}
+	defer shutdownTracing(context.Background())
+	if appConfig.TracingOTLPEndpoint != "" {
+		logger.Info(fmt.Sprintf("Exporting traces to %s", appConfig.TracingOTLPEndpoint))
+	}
 
-	googleTranslateV3Client := googletranslate.InitTranslateV3Client()
-	defer googleTranslateV2Client.Close()
+	metricsBuckets := metrics.Buckets{
+		HTTPRequest:  appConfig.MetricsHTTPBuckets,
+		UpstreamCall: appConfig.MetricsUpstreamBuckets,
+	}
+	if err := metricsBuckets.Validate(); err != nil {
+		return fmt.Errorf("invalid config METRICS_*_BUCKETS: %w", err)
+	}
+	appMetrics := metrics.New(metricsBuckets)
+	errorhandlers.ObserveErrors(appMetrics.CountError)
+
+	var usageStore usage.Store = usage.NewMemoryStore()
+	if appConfig.UsageFile != "" {
+		fileStore, err := usage.NewFileStore(appConfig.UsageFile, logger)
+		if err != nil {
+			return fmt.Errorf("invalid config USAGE_FILE: %w", err)
+		}
+		defer fileStore.Close()
+
+		usageStore = fileStore
+		logger.Info(fmt.Sprintf("Recording usage to %s", appConfig.UsageFile))
+	}
+	usageMeter := usage.NewMeter(usageStore, logger)
+
+	priceTable := usage.PriceTable{}
+	if appConfig.PriceTableFile != "" {
+		loadedPriceTable, err := usage.LoadPriceTable(appConfig.PriceTableFile)
+		if err != nil {
+			return fmt.Errorf("invalid config PRICE_TABLE_FILE: %w", err)
+		}
+		priceTable = loadedPriceTable
+	}
+	deploymentMeter := usageMeter.ForTenant("", appConfig.UsageBudget())
 
 	translateV3Wrapper := googletranslatewrapper.NewTranslateV3Wrapper(
 		googleTranslateV3Client,
-		appConfig.GoogleTranslateV3ProjectKey,
-	)
+		googleTranslateV3RegionalClient,
+		appConfig.GoogleTranslateV3ProjectID,
+		appConfig.GoogleTranslateV3RegionalLocation,
+	).WithMeter(deploymentMeter).WithMetrics(appMetrics)
+	// Limiters are set up even without limits, so a config reload can add them
+	v3Limiter := ratelimit.New("Google translate V3", appConfig.GoogleTranslateV3RateLimits.Limits())
+	translateV3Wrapper = translateV3Wrapper.WithLimiter(v3Limiter)
+	if limits := v3Limiter.Limits(); limits.IsLimited() {
+		logger.Info(fmt.Sprintf("Google translate V3 limited to %s", limits))
+	}
+
 	translateV2Wrapper := googletranslatewrapper.NewTranslateV2WrapperWithV3Wrapper(
 		googleTranslateV2Client,
 		translateV3Wrapper,
+	).WithMeter(deploymentMeter).WithMetrics(appMetrics)
+	v2Limiter := ratelimit.New("Google translate V2", appConfig.GoogleTranslateV2RateLimits.Limits())
+	translateV2Wrapper = translateV2Wrapper.WithLimiter(v2Limiter)
+	if limits := v2Limiter.Limits(); limits.IsLimited() {
+		logger.Info(fmt.Sprintf("Google translate V2 limited to %s", limits))
+	}
+
+	failoverPolicy := googletranslatewrapper.FailoverPolicy{
+		Enabled:   appConfig.FailoverEnabled,
+		Primary:   appConfig.FailoverPrimary,
+		Secondary: appConfig.FailoverSecondary,
+		OnClasses: appConfig.FailoverOn,
+	}
+	if err := failoverPolicy.Validate(); err != nil {
+		return fmt.Errorf("invalid config FAILOVER_*: %w", err)
+	}
+	translateV2Wrapper = translateV2Wrapper.WithFailover(failoverPolicy, logger)
+
+	var tenantRegistry *tenancy.Registry
+	var tenantLimiters *tenantLimiters
+	if appConfig.TenantsFile != "" {
+		tenants, err := tenancy.LoadTenants(appConfig.TenantsFile)
+		if err != nil {
+			return fmt.Errorf("invalid config TENANTS_FILE: %w", err)
+		}
+		tenantLimiters = newTenantLimiters(tenants)
+
+		backends := tenantBackends{
+			appConfig:        appConfig,
+			v2Client:         googleTranslateV2Client,
+			v3Client:         googleTranslateV3Client,
+			v3RegionalClient: googleTranslateV3RegionalClient,
+			v2Limiter:        v2Limiter,
+			v3Limiter:        v3Limiter,
+			tenantLimiters:   tenantLimiters,
+			failoverPolicy:   failoverPolicy,
+			meter:            usageMeter,
+			metrics:          appMetrics,
+			logger:           logger,
+		}
+		tenantRegistry = tenancy.NewRegistry(tenants, backends.create)
+
+		logger.Info(fmt.Sprintf("Loaded %d tenants from %s", tenantRegistry.Len(), appConfig.TenantsFile))
+	}
+
+	providers := []translationproviders.Provider{}
+	if appConfig.LibreTranslateURL != "" {
+		providers = append(providers, translationproviders.NewLibreTranslate(
+			appConfig.LibreTranslateURL,
+			appConfig.LibreTranslateAPIKey,
+			nil,
+		))
+	}
+	if appConfig.DeepLAPIKey != "" {
+		providers = append(providers, translationproviders.NewDeepL(
+			appConfig.DeepLURL,
+			appConfig.DeepLAPIKey,
+			nil,
+		))
+	}
+	providerRegistry := translationproviders.NewRegistry(providers...)
+
+	var router *routing.Router
+	if appConfig.RoutingRulesFile != "" {
+		knownProviders := append([]string{translationproviders.ProviderGoogle}, providerRegistry.Names()...)
+		loadedRouter, err := routing.NewRouter(appConfig.RoutingRulesFile, knownProviders)
+		if err != nil {
+			return fmt.Errorf("invalid config ROUTING_RULES_FILE: %w", err)
+		}
+		router = loadedRouter
+
+		logger.Info(fmt.Sprintf("Loaded %d routing rules from %s", router.Len(), appConfig.RoutingRulesFile))
+		if appConfig.RoutingRulesReloadSeconds > 0 {
+			go router.Watch(
+				context.Background(),
+				time.Duration(appConfig.RoutingRulesReloadSeconds)*time.Second,
+				logger,
+			)
+		}
+	}
+
+	var localGlossaryEngine *localglossary.Engine
+	if appConfig.LocalGlossaryDir != "" {
+		engine, err := localglossary.NewFromDir(
+			appConfig.LocalGlossaryDir,
+			localglossary.Options{
+				CaseSensitive:     appConfig.LocalGlossaryCaseSensitive,
+				WholeWord:         appConfig.LocalGlossaryWholeWord,
+				LongestMatchFirst: appConfig.LocalGlossaryLongestMatch,
+			},
+		)
+		if err != nil {
+			return fmt.Errorf("invalid config LOCAL_GLOSSARY_DIR: %w", err)
+		}
+
+		logger.Info(fmt.Sprintf("Loaded %d local glossary entries from %s", engine.TermSetCount(), appConfig.LocalGlossaryDir))
+		localGlossaryEngine = engine
+	}
+
+	var glossarySyncer *glossarysync.Syncer
+	glossaryCatalog := glossaryexplain.NewCatalog(map[string]glossaryexplain.Glossary{})
+	if appConfig.GlossarySyncManifest != "" {
+		// Glossaries can only be synced through the V3 backend, while their
+		// entries are still useful to explain V3 responses
+		if translateV3Wrapper.IsEnabled() {
+			storageClient, err := googletranslate.InitStorageClient(appConfig.GoogleTranslateV3ClientConfig())
+			if err != nil {
+				return err
+			}
+			defer storageClient.Close()
+
+			syncer := glossarysync.NewSyncer(translateV3Wrapper, glossarysync.NewGCSUploader(storageClient))
+			glossarySyncer = &syncer
+		}
+
+		manifest, err := glossarysync.LoadManifest(appConfig.GlossarySyncManifest)
+		if err != nil {
+			return fmt.Errorf("invalid config GLOSSARY_SYNC_MANIFEST: %w", err)
+		}
+
+		glossaryCatalog, err = glossaryexplain.NewCatalogFromManifest(manifest)
+		if err != nil {
+			return fmt.Errorf("invalid config GLOSSARY_SYNC_MANIFEST: %w", err)
+		}
+
+		logger.Info(fmt.Sprintf("Loaded entries of %d glossaries from %s", glossaryCatalog.Len(), appConfig.GlossarySyncManifest))
+	}
+
+	authenticator := auth.Authenticator{}
+	if appConfig.APIKeysFile != "" || appConfig.APIKeys != "" {
+		keyStore, err := auth.LoadKeyStore(appConfig.APIKeysFile, appConfig.APIKeys)
+		if err != nil {
+			return fmt.Errorf("invalid config API_KEYS_FILE: %w", err)
+		}
+
+		logger.Info(fmt.Sprintf("Loaded %d API keys", keyStore.Len()))
+		authenticator.APIKeys = &keyStore
+	}
+
+	if appConfig.JWTJWKSURL != "" || appConfig.JWTJWKSFile != "" {
+		jwtVerifier, err := makeJWTVerifier(appConfig, logger)
+		if err != nil {
+			return err
+		}
+		authenticator.JWT = jwtVerifier
+	}
+
+	if appConfig.TLSClientPrincipalsFile != "" {
+		clientCertStore, err := auth.LoadClientCertStore(appConfig.TLSClientPrincipalsFile)
+		if err != nil {
+			return fmt.Errorf("invalid config TLS_CLIENT_PRINCIPALS_FILE: %w", err)
+		}
+
+		logger.Info(fmt.Sprintf("Loaded %d client certificate subjects", clientCertStore.Len()))
+		authenticator.ClientCerts = &clientCertStore
+	}
+
+	if !authenticator.IsEnabled() {
+		logger.Info("No API keys, JWKS or client certificates configured, requests are not authenticated")
+	}
+
+	var tlsReloader *tlsconfig.Reloader
+	if appConfig.TLSCertFile != "" {
+		tlsReloader, err = makeTLSReloader(appConfig)
+		if err != nil {
+			return err
+		}
+
+		if appConfig.TLSReloadSeconds > 0 {
+			tlsCtx, stopTLSReloads := context.WithCancel(context.Background())
+			defer stopTLSReloads()
+			go tlsReloader.Watch(tlsCtx, time.Duration(appConfig.TLSReloadSeconds)*time.Second, logger)
+		}
+	}
+
+	// Validated even when the checks are off, so typos do not go unnoticed
+	readinessChecks, err := makeReadinessChecks(appConfig.ReadinessCriticalChecks, translateV2Wrapper, translateV3Wrapper)
+	if err != nil {
+		return fmt.Errorf("invalid config READINESS_CRITICAL_CHECKS: %w", err)
+	}
+
+	var readinessChecker *health.Checker
+	if appConfig.ReadinessCheckIntervalSeconds > 0 {
+		readinessChecker = health.NewChecker(
+			readinessChecks,
+			time.Duration(appConfig.ReadinessCheckIntervalSeconds)*time.Second,
+			time.Duration(appConfig.ReadinessCheckTimeoutSeconds)*time.Second,
+			logger,
+		)
+		checkCtx, stopChecks := context.WithCancel(context.Background())
+		defer stopChecks()
+		readinessChecker.Start(checkCtx)
+	}
+
+	corsPolicies, err := makeCORSPolicies(appConfig.Reloadable())
+	if err != nil {
+		return fmt.Errorf("invalid config CORS_*: %w", err)
+	}
+
+	configReloader := configreload.New(
+		appConfig.File(),
+		appConfig,
+		makeConfigApplier(authenticator, v2Limiter, v3Limiter, appConfig.TenantsFile, tenantLimiters, corsPolicies),
+		logger,
 	)
+	reloadCtx, stopReloads := context.WithCancel(context.Background())
+	defer stopReloads()
+	go configReloader.Watch(reloadCtx, time.Duration(appConfig.ConfigReloadSeconds)*time.Second)
+
+	logCapabilities(logger, translateV2Wrapper, translateV3Wrapper, providerRegistry, localGlossaryEngine, glossarySyncer, router)
 
 	httpServer := httptransport.HttpServer{
 		LivelinessProbePort:      strconv.Itoa(appConfig.LivenessPort),
 		Port:                     strconv.Itoa(appConfig.Port),
 		Logger:                   logger,
 		GracefulShutdownSeconds:  appConfig.GracefulShutdownSeconds,
+		ShutdownDelaySeconds:     appConfig.ShutdownDelaySeconds,
 		EnableHTTP2:              appConfig.EnableHTTP2,
 		GoogleTranslateV2Wrapper: translateV2Wrapper,
 		GoogleTranslateV3Wrapper: translateV3Wrapper,
+		LocalGlossary:            localGlossaryEngine,
+		GlossarySyncer:           glossarySyncer,
+		GlossaryManifest:         appConfig.GlossarySyncManifest,
+		GlossaryCatalog:          glossaryCatalog,
+		Providers:                providerRegistry,
+		Router:                   router,
+		Authenticator:            authenticator,
+		Tenants:                  tenantRegistry,
+		UsageStore:               usageStore,
+		PriceTable:               priceTable,
+		Metrics:                  appMetrics,
+		Readiness:                readinessChecker,
+		ConfigReloader:           configReloader,
+		CORSPolicies:             corsPolicies,
+		TLS:                      tlsReloader,
 	}
 
 	httpServer.ListenAndServe()
+
+	return nil
+}
+
+// makeConfigApplier puts reloaded rate limits and API keys in place, along
+// with the rate limits in the tenants file. API keys can be changed but not
+// turned on or off, as that decides whether requests are authenticated at
+// all.
+func makeConfigApplier(
+	authenticator auth.Authenticator,
+	v2Limiter, v3Limiter *ratelimit.Limiter,
+	tenantsFile string,
+	tenantLimiters *tenantLimiters,
+	corsPolicies *cors.Policies,
+) func(config.Reloadable) error {
+	return func(next config.Reloadable) error {
+		nextCORSPolicies := next.CORSPolicies()
+		if err := validateCORSPolicies(nextCORSPolicies); err != nil {
+			return fmt.Errorf("invalid CORS_*: %w", err)
+		}
+
+		tenants := tenancy.Tenants{}
+		if tenantsFile != "" {
+			loaded, err := tenancy.LoadTenants(tenantsFile)
+			if err != nil {
+				return fmt.Errorf("invalid TENANTS_FILE: %w", err)
+			}
+			tenants = loaded
+		}
+
+		hasAPIKeys := next.APIKeysFile != "" || next.APIKeys != ""
+		if hasAPIKeys != (authenticator.APIKeys != nil) {
+			return fmt.Errorf("API keys can only be turned on or off with a restart")
+		}
+
+		if hasAPIKeys {
+			keyStore, err := auth.LoadKeyStore(next.APIKeysFile, next.APIKeys)
+			if err != nil {
+				return err
+			}
+			authenticator.APIKeys.Replace(keyStore)
+		}
+
+		v2Limiter.SetLimits(next.GoogleTranslateV2RateLimits.Limits())
+		v3Limiter.SetLimits(next.GoogleTranslateV3RateLimits.Limits())
+		if tenantLimiters != nil {
+			tenantLimiters.setLimits(tenants)
+		}
+		corsPolicies.Set(nextCORSPolicies)
+
+		return nil
+	}
+}
+
+func makeCORSPolicies(reloadable config.Reloadable) (*cors.Policies, error) {
+	policies := reloadable.CORSPolicies()
+	if err := validateCORSPolicies(policies); err != nil {
+		return nil, err
+	}
+
+	return cors.NewPolicies(policies), nil
+}
+
+func validateCORSPolicies(policies map[string]cors.Policy) error {
+	for group, policy := range policies {
+		if err := policy.Validate(); err != nil {
+			return fmt.Errorf("policy of %s routes: %w", group, err)
+		}
+	}
+
+	return nil
+}
+
+// makeReadinessChecks pings the enabled Google backends. Every check is
+// critical unless critical names a subset of them, or "none".
+func makeReadinessChecks(
+	critical []string,
+	translateV2Wrapper googletranslatewrapper.TranslateV2Wrapper,
+	translateV3Wrapper googletranslatewrapper.TranslateV3Wrapper,
+) ([]health.Check, error) {
+	checks := []health.Check{}
+	if translateV2Wrapper.IsEnabled() {
+		checks = append(checks, health.Check{Name: "google_translate_v2", Run: translateV2Wrapper.Ping})
+	}
+	if translateV3Wrapper.IsEnabled() {
+		checks = append(checks, health.Check{Name: "google_translate_v3", Run: translateV3Wrapper.Ping})
+	}
+
+	known := map[string]bool{}
+	names := []string{}
+	for _, check := range checks {
+		known[check.Name] = true
+		names = append(names, check.Name)
+	}
+
+	criticalNames := map[string]bool{}
+	for _, name := range critical {
+		if name == "none" && len(critical) > 1 {
+			return nil, fmt.Errorf("\"none\" cannot be listed with other checks")
+		}
+		if !known[name] && name != "none" {
+			return nil, fmt.Errorf("unknown check %q, the checks of the enabled backends are %q", name, names)
+		}
+		criticalNames[name] = true
+	}
+
+	for i := range checks {
+		checks[i].Critical = critical == nil || criticalNames[checks[i].Name]
+	}
+
+	return checks, nil
+}
+
+// makeJWTVerifier loads the JWKS once up front. A JWKS URL that cannot be
+// reached yet is retried on the first requests, while a bad JWKS file fails
+// startup.
+func makeJWTVerifier(appConfig config.AppConfig, logger *loggerutils.Logger) (*auth.JWTVerifier, error) {
+	if appConfig.JWTIssuer == "" || appConfig.JWTAudience == "" {
+		return nil, fmt.Errorf("invalid config: JWT_ISSUER and JWT_AUDIENCE must be set with a JWKS")
+	}
+
+	scopeMapping, err := auth.ParseScopeMapping(appConfig.JWTScopeMapping)
+	if err != nil {
+		return nil, fmt.Errorf("invalid config JWT_SCOPE_MAPPING: %w", err)
+	}
+
+	keySet := auth.NewKeySet(
+		appConfig.JWTJWKSURL,
+		appConfig.JWTJWKSFile,
+		time.Duration(appConfig.JWTJWKSRefreshSeconds)*time.Second,
+		nil,
+		logger,
+	)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	if err := keySet.Refresh(ctx); err != nil {
+		if appConfig.JWTJWKSURL == "" {
+			return nil, fmt.Errorf("invalid config JWT_JWKS_FILE: %w", err)
+		}
+		logger.Error("Failed to fetch JWKS, retrying on the next request", map[string]string{
+			"url":   appConfig.JWTJWKSURL,
+			"error": err.Error(),
+		})
+	} else {
+		logger.Info(fmt.Sprintf("Loaded %d JWKS keys", keySet.Len()))
+	}
+
+	return auth.NewJWTVerifier(auth.JWTConfig{
+		Issuer:       appConfig.JWTIssuer,
+		Audience:     appConfig.JWTAudience,
+		ScopeClaim:   appConfig.JWTScopeClaim,
+		TenantClaim:  appConfig.JWTTenantClaim,
+		ScopeMapping: scopeMapping,
+	}, keySet), nil
+}
+
+// makeTLSReloader loads the certificate, key and client CAs, which are
+// then reloaded when their files change
+func makeTLSReloader(appConfig config.AppConfig) (*tlsconfig.Reloader, error) {
+	minVersion, err := tlsconfig.ParseMinVersion(appConfig.TLSMinVersion)
+	if err != nil {
+		return nil, fmt.Errorf("invalid config TLS_MIN_VERSION: %w", err)
+	}
+
+	reloader, err := tlsconfig.NewReloader(tlsconfig.Config{
+		CertFile:           appConfig.TLSCertFile,
+		KeyFile:            appConfig.TLSKeyFile,
+		ClientCAFile:       appConfig.TLSClientCAFile,
+		ClientCertRequired: appConfig.TLSClientCertRequired,
+		MinVersion:         minVersion,
+		HTTP2:              appConfig.EnableHTTP2,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("invalid config TLS_CERT_FILE: %w", err)
+	}
+
+	return reloader, nil
+}
+
+func logCapabilities(
+	logger *loggerutils.Logger,
+	translateV2Wrapper googletranslatewrapper.TranslateV2Wrapper,
+	translateV3Wrapper googletranslatewrapper.TranslateV3Wrapper,
+	providerRegistry translationproviders.Registry,
+	localGlossaryEngine *localglossary.Engine,
+	glossarySyncer *glossarysync.Syncer,
+	router *routing.Router,
+) {
+	capabilities := []string{}
+	if translateV2Wrapper.IsEnabled() {
+		capabilities = append(capabilities, "translate-v2")
+	}
+	if translateV3Wrapper.IsEnabled() {
+		capabilities = append(capabilities, "translate-v3", "glossaries-v3")
+	}
+	for _, name := range providerRegistry.Names() {
+		capabilities = append(capabilities, "provider-"+name)
+	}
+	if localGlossaryEngine != nil {
+		capabilities = append(capabilities, "local-glossary")
+	}
+	if glossarySyncer != nil {
+		capabilities = append(capabilities, "glossary-sync")
+	}
+	if router != nil {
+		capabilities = append(capabilities, "routing-rules")
+	}
+	if translateV2Wrapper.FailoverEnabled() {
+		capabilities = append(capabilities, "failover")
+	}
+
+	logger.Info(fmt.Sprintf("Active capabilities: %s", strings.Join(capabilities, ", ")))
 }
@@ -0,0 +1,161 @@
+package server
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"cloud.google.com/go/translate"
+	translatev3 "cloud.google.com/go/translate/apiv3"
+	"google.golang.org/api/option"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+
+	"github.com/weiyuan-lane/google-translate-api/internal/services/auth"
+	"github.com/weiyuan-lane/google-translate-api/internal/services/googletranslatewrapper"
+	"github.com/weiyuan-lane/google-translate-api/internal/services/tenancy"
+	"github.com/weiyuan-lane/google-translate-api/internal/utils/config"
+	"github.com/weiyuan-lane/google-translate-api/internal/utils/cors"
+	"github.com/weiyuan-lane/google-translate-api/internal/utils/ratelimit"
+)
+
+// enabledWrappers are backed by clients that are never called
+func enabledWrappers(t *testing.T) (googletranslatewrapper.TranslateV2Wrapper, googletranslatewrapper.TranslateV3Wrapper) {
+	t.Helper()
+
+	v2Client, err := translate.NewClient(context.Background(), option.WithAPIKey("key"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { v2Client.Close() })
+
+	v3Client, err := translatev3.NewTranslationClient(context.Background(),
+		option.WithEndpoint("127.0.0.1:1"),
+		option.WithoutAuthentication(),
+		option.WithGRPCDialOption(grpc.WithTransportCredentials(insecure.NewCredentials())),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { v3Client.Close() })
+
+	return googletranslatewrapper.NewTranslateV2Wrapper(v2Client),
+		googletranslatewrapper.NewTranslateV3Wrapper(v3Client, nil, "project", "us-central1")
+}
+
+func TestMakeReadinessChecks(t *testing.T) {
+	v2Wrapper, v3Wrapper := enabledWrappers(t)
+
+	tests := []struct {
+		name         string
+		critical     []string
+		v3Disabled   bool
+		wantCritical map[string]bool
+		wantErr      bool
+	}{
+		{
+			name:         "every check by default",
+			wantCritical: map[string]bool{"google_translate_v2": true, "google_translate_v3": true},
+		},
+		{
+			name:         "listed checks",
+			critical:     []string{"google_translate_v3"},
+			wantCritical: map[string]bool{"google_translate_v2": false, "google_translate_v3": true},
+		},
+		{
+			name:         "none",
+			critical:     []string{"none"},
+			wantCritical: map[string]bool{"google_translate_v2": false, "google_translate_v3": false},
+		},
+		{
+			name:     "unregistered check",
+			critical: []string{"redis"},
+			wantErr:  true,
+		},
+		{
+			name:     "misspelt check",
+			critical: []string{"google_translate_v2", "google_translate_v4"},
+			wantErr:  true,
+		},
+		{
+			name:       "check of a disabled backend",
+			critical:   []string{"google_translate_v3"},
+			v3Disabled: true,
+			wantErr:    true,
+		},
+		{
+			name:     "none with other checks",
+			critical: []string{"none", "google_translate_v2"},
+			wantErr:  true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			v3 := v3Wrapper
+			if tt.v3Disabled {
+				v3 = googletranslatewrapper.TranslateV3Wrapper{}
+			}
+
+			checks, err := makeReadinessChecks(tt.critical, v2Wrapper, v3)
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("makeReadinessChecks(%q) error = nil, want an error", tt.critical)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("makeReadinessChecks(%q) error = %v", tt.critical, err)
+			}
+
+			critical := map[string]bool{}
+			for _, check := range checks {
+				critical[check.Name] = check.Critical
+			}
+			if !reflect.DeepEqual(critical, tt.wantCritical) {
+				t.Errorf("critical checks = %v, want %v", critical, tt.wantCritical)
+			}
+		})
+	}
+}
+
+func TestConfigApplierReloadsTenantLimits(t *testing.T) {
+	tenantsFile := filepath.Join(t.TempDir(), "tenants.json")
+	writeTenants := func(requestsPerMinute string) {
+		t.Helper()
+		content := `{"tenants": [{"id": "acme", "rate_limits": {"requests_per_minute": ` + requestsPerMinute + `}}]}`
+		if err := os.WriteFile(tenantsFile, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	writeTenants("60")
+	tenants, err := tenancy.LoadTenants(tenantsFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	limiters := newTenantLimiters(tenants)
+	deploymentLimiter := ratelimit.New("deployment", ratelimit.Limits{})
+	limiter := limiters.create("acme", "acme", deploymentLimiter)
+
+	apply := makeConfigApplier(auth.Authenticator{}, deploymentLimiter, deploymentLimiter, tenantsFile, limiters, cors.NewPolicies(nil))
+
+	writeTenants("120")
+	if err := apply(config.Reloadable{}); err != nil {
+		t.Fatalf("apply() error = %v", err)
+	}
+	if limits := limiter.Limits(); limits.RequestsPerMinute != 120 {
+		t.Errorf("tenant limits after a reload = %s, want 120 requests/min", limits)
+	}
+
+	// An invalid tenants file keeps the limits in place
+	writeTenants("-1")
+	if err := apply(config.Reloadable{}); err == nil {
+		t.Error("apply() with invalid tenants error = nil, want an error")
+	}
+	if limits := limiter.Limits(); limits.RequestsPerMinute != 120 {
+		t.Errorf("tenant limits after a failed reload = %s, want 120 requests/min", limits)
+	}
+}
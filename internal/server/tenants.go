@@ -0,0 +1,165 @@
+package server
+
+import (
+	"fmt"
+	"sync"
+
+	"cloud.google.com/go/translate"
+	translatev3 "cloud.google.com/go/translate/apiv3"
+
+	"github.com/weiyuan-lane/google-translate-api/internal/services/googletranslatewrapper"
+	"github.com/weiyuan-lane/google-translate-api/internal/services/tenancy"
+	"github.com/weiyuan-lane/google-translate-api/internal/services/usage"
+	"github.com/weiyuan-lane/google-translate-api/internal/utils/config"
+	"github.com/weiyuan-lane/google-translate-api/internal/utils/googletranslate"
+	loggerutils "github.com/weiyuan-lane/google-translate-api/internal/utils/logger"
+	"github.com/weiyuan-lane/google-translate-api/internal/utils/metrics"
+	"github.com/weiyuan-lane/google-translate-api/internal/utils/ratelimit"
+)
+
+// tenantBackends builds the wrappers of a tenant on top of the clients of
+// the deployment, creating clients of its own only for a V2 API key or a V3
+// location on another endpoint. Those clients live as long as the process.
+type tenantBackends struct {
+	appConfig        config.AppConfig
+	v2Client         *translate.Client
+	v3Client         *translatev3.TranslationClient
+	v3RegionalClient *translatev3.TranslationClient
+	v2Limiter        *ratelimit.Limiter
+	v3Limiter        *ratelimit.Limiter
+	tenantLimiters   *tenantLimiters
+	failoverPolicy   googletranslatewrapper.FailoverPolicy
+	meter            *usage.Meter
+	metrics          *metrics.Metrics
+	logger           *loggerutils.Logger
+}
+
+func (b tenantBackends) create(tenant tenancy.Tenant) (tenancy.Backends, error) {
+	projectID := b.appConfig.GoogleTranslateV3ProjectID
+	if tenant.V3ProjectID != "" {
+		projectID = tenant.V3ProjectID
+	}
+
+	location := b.appConfig.GoogleTranslateV3RegionalLocation
+	regionalClient := b.v3RegionalClient
+	if tenant.V3Location != "" && tenant.V3Location != location && b.v3Client != nil {
+		location = tenant.V3Location
+
+		client, err := googletranslate.InitTranslateV3RegionalClient(
+			b.appConfig.GoogleTranslateV3ClientConfig(),
+			location,
+			"",
+		)
+		if err != nil {
+			return tenancy.Backends{}, err
+		}
+		regionalClient = client
+	}
+
+	// The limits of the deployment keep the quota of its project and key, so
+	// they are only skipped for a tenant billed to a project or key of its own
+	v3Limiter, v2Limiter := b.v3Limiter, b.v2Limiter
+	if projectID != b.appConfig.GoogleTranslateV3ProjectID {
+		v3Limiter = nil
+	}
+	if tenant.V2APIKey() != "" {
+		v2Limiter = nil
+	}
+	v3Limiter = b.tenantLimiters.create(tenant.ID, fmt.Sprintf("Google translate V3 of tenant %q", tenant.ID), v3Limiter)
+	v2Limiter = b.tenantLimiters.create(tenant.ID, fmt.Sprintf("Google translate V2 of tenant %q", tenant.ID), v2Limiter)
+
+	budget := b.appConfig.UsageBudget()
+	if tenant.MonthlyBudget.IsSet() {
+		budget = tenant.MonthlyBudget
+	}
+	meter := b.meter.ForTenant(tenant.ID, budget)
+
+	translateV3Wrapper := googletranslatewrapper.NewTranslateV3Wrapper(
+		b.v3Client,
+		regionalClient,
+		projectID,
+		location,
+	).WithLimiter(v3Limiter).WithMeter(meter).WithMetrics(b.metrics)
+
+	// Tenants without a project of their own share the glossaries namespace
+	// of the deployment, and are kept to glossaries under their ID
+	if projectID == b.appConfig.GoogleTranslateV3ProjectID {
+		translateV3Wrapper = translateV3Wrapper.WithGlossaryTenant(tenant.ID)
+	}
+
+	v2Client := b.v2Client
+	if apiKey := tenant.V2APIKey(); apiKey != "" && b.v2Client != nil {
+		clientConfig := b.appConfig.GoogleTranslateV2ClientConfig()
+		clientConfig.APIKey = apiKey
+		clientConfig.CredentialsFile = ""
+		clientConfig.CredentialsJSON = ""
+		clientConfig.ImpersonateServiceAccount = ""
+
+		client, err := googletranslate.InitTranslateV2Client(clientConfig)
+		if err != nil {
+			return tenancy.Backends{}, err
+		}
+		v2Client = client
+	}
+
+	translateV2Wrapper := googletranslatewrapper.NewTranslateV2WrapperWithV3Wrapper(
+		v2Client,
+		translateV3Wrapper,
+	).WithLimiter(v2Limiter).WithMeter(meter).WithMetrics(b.metrics).WithFailover(b.failoverPolicy, b.logger)
+
+	b.logger.Info("Created Google translate backends of tenant", map[string]string{
+		"tenant":      tenant.ID,
+		"v3_project":  projectID,
+		"v3_location": location,
+	})
+
+	return tenancy.Backends{
+		V2: translateV2Wrapper,
+		V3: translateV3Wrapper,
+	}, nil
+}
+
+// tenantLimiters keep the limiters of each tenant, so a config reload can
+// put the rate limits in the tenants file in place
+type tenantLimiters struct {
+	mutex    sync.Mutex
+	limits   map[string]ratelimit.Limits
+	limiters map[string][]*ratelimit.Limiter
+}
+
+func newTenantLimiters(tenants tenancy.Tenants) *tenantLimiters {
+	limiters := &tenantLimiters{
+		limits:   map[string]ratelimit.Limits{},
+		limiters: map[string][]*ratelimit.Limiter{},
+	}
+	limiters.setLimits(tenants)
+
+	return limiters
+}
+
+// create is called when the backends of a tenant are built. Its limiters
+// are set up even without limits, so a reload can add them.
+func (t *tenantLimiters) create(tenantID, name string, parent *ratelimit.Limiter) *ratelimit.Limiter {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	limiter := ratelimit.NewWithin(parent, name, t.limits[tenantID])
+	t.limiters[tenantID] = append(t.limiters[tenantID], limiter)
+
+	return limiter
+}
+
+// setLimits updates the tenants listed, others keep their limits as the
+// tenants themselves only change on restart
+func (t *tenantLimiters) setLimits(tenants tenancy.Tenants) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	for _, tenant := range tenants.Tenants {
+		limits := tenant.RateLimits.Limits()
+		t.limits[tenant.ID] = limits
+		for _, limiter := range t.limiters[tenant.ID] {
+			limiter.SetLimits(limits)
+		}
+	}
+}
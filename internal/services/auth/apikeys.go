@@ -0,0 +1,152 @@
+package auth
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"sync/atomic"
+
+	"github.com/weiyuan-lane/google-translate-api/internal/utils/errorhandlers"
+)
+
+// APIKeys holds the SHA-256 hashes of client keys, never the keys
+// themselves, eg.
+//
+//	{
+//	  "keys": [
+//	    {"id": "web-frontend", "sha256": "9f86d0...", "scopes": ["translate", "detect"]},
+//	    {"id": "glossary-admin", "sha256": "60303a...", "scopes": ["glossary:read", "glossary:write"]},
+//	    {"id": "acme-backend", "sha256": "fcde2b...", "scopes": ["translate"], "tenant": "acme"}
+//	  ]
+//	}
+//
+// Use cmd/apikey to generate a key and its hash.
+type APIKeys struct {
+	Keys []APIKey `json:"keys"`
+}
+
+type APIKey struct {
+	ID     string   `json:"id"`
+	SHA256 string   `json:"sha256"`
+	Scopes []string `json:"scopes"`
+	// Requests with the key act for this tenant, if set
+	Tenant string `json:"tenant,omitempty"`
+}
+
+type KeyStore struct {
+	// Shared by copies of the store, which all see a Replace
+	principals *atomic.Pointer[map[string]Principal]
+}
+
+// LoadKeyStore reads keys from the JSON file at path, or from inlineJSON
+// when path is empty
+func LoadKeyStore(path, inlineJSON string) (KeyStore, error) {
+	keysBytes := []byte(inlineJSON)
+	source := "API_KEYS"
+	if path != "" {
+		var err error
+		keysBytes, err = os.ReadFile(path)
+		if err != nil {
+			return KeyStore{}, errorhandlers.Wrap(
+				errorhandlers.ErrAuthInvalidKeys,
+				fmt.Sprintf("API keys could not be read: %s", err.Error()),
+			)
+		}
+		source = path
+	}
+
+	apiKeys := APIKeys{}
+	if err := json.Unmarshal(keysBytes, &apiKeys); err != nil {
+		return KeyStore{}, errorhandlers.Wrap(
+			errorhandlers.ErrAuthInvalidKeys,
+			fmt.Sprintf("API keys in %s are not valid JSON: %s", source, err.Error()),
+		)
+	}
+
+	return NewKeyStore(apiKeys, source)
+}
+
+func NewKeyStore(apiKeys APIKeys, source string) (KeyStore, error) {
+	principals := map[string]Principal{}
+
+	problems := []string{}
+	seenIDs := map[string]bool{}
+	for i, key := range apiKeys.Keys {
+		if key.ID == "" || seenIDs[key.ID] {
+			problems = append(problems, fmt.Sprintf("keys[%d].id %q must be set and unique", i, key.ID))
+		}
+		seenIDs[key.ID] = true
+
+		hash := strings.ToLower(key.SHA256)
+		if decoded, err := hex.DecodeString(hash); err != nil || len(decoded) != sha256.Size {
+			problems = append(problems, fmt.Sprintf("keys[%d].sha256 must be a hex encoded SHA-256 hash", i))
+		}
+
+		for _, scope := range key.Scopes {
+			if !isKnownScope(scope) {
+				problems = append(problems, fmt.Sprintf("keys[%d].scopes has unknown scope %q, expected one of %s", i, scope, strings.Join(Scopes, ", ")))
+			}
+		}
+
+		principals[hash] = Principal{
+			ID:     key.ID,
+			Scopes: key.Scopes,
+			Tenant: key.Tenant,
+		}
+	}
+
+	if len(problems) > 0 {
+		return KeyStore{}, errorhandlers.Wrap(
+			errorhandlers.ErrAuthInvalidKeys,
+			fmt.Sprintf("API keys in %s are invalid: %s", source, strings.Join(problems, "; ")),
+		)
+	}
+
+	store := KeyStore{principals: &atomic.Pointer[map[string]Principal]{}}
+	store.principals.Store(&principals)
+
+	return store, nil
+}
+
+// Replace swaps in the keys of other, eg. once the keys file is reloaded
+func (s KeyStore) Replace(other KeyStore) {
+	s.principals.Store(other.principals.Load())
+}
+
+func (s KeyStore) Len() int {
+	return len(*s.principals.Load())
+}
+
+func (s KeyStore) Authenticate(key string) (Principal, bool) {
+	principal, ok := (*s.principals.Load())[HashKey(key)]
+	return principal, ok
+}
+
+func HashKey(key string) string {
+	hash := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(hash[:])
+}
+
+// GenerateKey returns a random key with 256 bits of entropy
+func GenerateKey() (string, error) {
+	keyBytes := make([]byte, 32)
+	if _, err := rand.Read(keyBytes); err != nil {
+		return "", err
+	}
+
+	return "gta_" + hex.EncodeToString(keyBytes), nil
+}
+
+func isKnownScope(scope string) bool {
+	for _, s := range Scopes {
+		if s == scope {
+			return true
+		}
+	}
+
+	return false
+}
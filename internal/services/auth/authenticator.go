@@ -0,0 +1,94 @@
+package auth
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+
+	"github.com/weiyuan-lane/google-translate-api/internal/utils/errorhandlers"
+)
+
+// Authenticator accepts API keys, JWTs, client certificates, or any of them
+// together, depending on which is set
+type Authenticator struct {
+	APIKeys     *KeyStore
+	JWT         *JWTVerifier
+	ClientCerts *ClientCertStore
+}
+
+func (a Authenticator) IsEnabled() bool {
+	return a.APIKeys != nil || a.JWT != nil || a.ClientCerts != nil
+}
+
+// Authenticate checks a credential from the "Authorization: Bearer" header.
+// Credentials shaped like a JWT are verified as one when JWTs are accepted.
+func (a Authenticator) Authenticate(ctx context.Context, credential string) (Principal, error) {
+	if a.JWT != nil && IsJWT(credential) {
+		principal, err := a.JWT.Authenticate(ctx, credential)
+		if err != nil {
+			return Principal{}, errorhandlers.Wrap(
+				errorhandlers.ErrAuthInvalidCredentials,
+				fmt.Sprintf("JWT is not valid: %s", err.Error()),
+			)
+		}
+
+		return principal, nil
+	}
+
+	return a.AuthenticateAPIKey(credential)
+}
+
+// AuthenticateAPIKey checks a credential that can only be an API key, such
+// as one from the "X-API-Key" header
+func (a Authenticator) AuthenticateAPIKey(key string) (Principal, error) {
+	if a.APIKeys == nil && a.JWT == nil {
+		return Principal{}, errorhandlers.Wrap(
+			errorhandlers.ErrAuthInvalidCredentials,
+			"Only client certificates are accepted",
+		)
+	}
+
+	if a.APIKeys == nil {
+		return Principal{}, errorhandlers.Wrap(
+			errorhandlers.ErrAuthInvalidCredentials,
+			"Only JWTs are accepted, as \"Authorization: Bearer <token>\"",
+		)
+	}
+
+	if principal, ok := a.APIKeys.Authenticate(key); ok {
+		return principal, nil
+	}
+
+	return Principal{}, errorhandlers.Wrap(
+		errorhandlers.ErrAuthInvalidCredentials,
+		"API key is not valid",
+	)
+}
+
+// AuthenticateClientCert checks the client certificate verified by the TLS
+// handshake, if any, against the mapped subjects
+func (a Authenticator) AuthenticateClientCert(state *tls.ConnectionState) (Principal, error) {
+	if a.ClientCerts == nil {
+		return Principal{}, errorhandlers.Wrap(
+			errorhandlers.ErrAuthMissingCredentials,
+			"Client certificates are not accepted",
+		)
+	}
+
+	principal, subject, ok := a.ClientCerts.Authenticate(state)
+	if subject == "" {
+		return Principal{}, errorhandlers.Wrap(
+			errorhandlers.ErrAuthMissingCredentials,
+			"No verified client certificate given",
+		)
+	}
+
+	if !ok {
+		return Principal{}, errorhandlers.Wrap(
+			errorhandlers.ErrAuthInvalidCredentials,
+			fmt.Sprintf("Client certificate subject %q is not mapped to a client", subject),
+		)
+	}
+
+	return principal, nil
+}
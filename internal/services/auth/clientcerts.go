@@ -0,0 +1,114 @@
+package auth
+
+import (
+	"crypto/tls"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/weiyuan-lane/google-translate-api/internal/utils/errorhandlers"
+)
+
+// ClientCerts maps the subjects of verified client certificates to clients,
+// with subjects written as in RFC 2253, eg.
+//
+//	{
+//	  "clients": [
+//	    {"id": "billing", "subject": "CN=billing,OU=payments,O=Acme", "scopes": ["translate"]},
+//	    {"id": "acme-backend", "subject": "CN=backend,O=Acme", "scopes": ["translate", "detect"], "tenant": "acme"}
+//	  ]
+//	}
+type ClientCerts struct {
+	Clients []ClientCert `json:"clients"`
+}
+
+type ClientCert struct {
+	ID      string   `json:"id"`
+	Subject string   `json:"subject"`
+	Scopes  []string `json:"scopes"`
+	// Requests with the certificate act for this tenant, if set
+	Tenant string `json:"tenant,omitempty"`
+}
+
+type ClientCertStore struct {
+	principals map[string]Principal
+}
+
+func LoadClientCertStore(path string) (ClientCertStore, error) {
+	clientCertsBytes, err := os.ReadFile(path)
+	if err != nil {
+		return ClientCertStore{}, errorhandlers.Wrap(
+			errorhandlers.ErrAuthInvalidClientCerts,
+			fmt.Sprintf("Client certificate principals could not be read: %s", err.Error()),
+		)
+	}
+
+	clientCerts := ClientCerts{}
+	if err := json.Unmarshal(clientCertsBytes, &clientCerts); err != nil {
+		return ClientCertStore{}, errorhandlers.Wrap(
+			errorhandlers.ErrAuthInvalidClientCerts,
+			fmt.Sprintf("Client certificate principals in %s are not valid JSON: %s", path, err.Error()),
+		)
+	}
+
+	return NewClientCertStore(clientCerts, path)
+}
+
+func NewClientCertStore(clientCerts ClientCerts, source string) (ClientCertStore, error) {
+	principals := map[string]Principal{}
+
+	problems := []string{}
+	seenIDs := map[string]bool{}
+	for i, client := range clientCerts.Clients {
+		if client.ID == "" || seenIDs[client.ID] {
+			problems = append(problems, fmt.Sprintf("clients[%d].id %q must be set and unique", i, client.ID))
+		}
+		seenIDs[client.ID] = true
+
+		if client.Subject == "" {
+			problems = append(problems, fmt.Sprintf("clients[%d].subject must be set", i))
+		} else if _, ok := principals[client.Subject]; ok {
+			problems = append(problems, fmt.Sprintf("clients[%d].subject %q is mapped more than once", i, client.Subject))
+		}
+
+		for _, scope := range client.Scopes {
+			if !isKnownScope(scope) {
+				problems = append(problems, fmt.Sprintf("clients[%d].scopes has unknown scope %q, expected one of %s", i, scope, strings.Join(Scopes, ", ")))
+			}
+		}
+
+		principals[client.Subject] = Principal{
+			ID:     client.ID,
+			Scopes: client.Scopes,
+			Tenant: client.Tenant,
+		}
+	}
+
+	if len(problems) > 0 {
+		return ClientCertStore{}, errorhandlers.Wrap(
+			errorhandlers.ErrAuthInvalidClientCerts,
+			fmt.Sprintf("Client certificate principals in %s are invalid: %s", source, strings.Join(problems, "; ")),
+		)
+	}
+
+	return ClientCertStore{principals: principals}, nil
+}
+
+func (s ClientCertStore) Len() int {
+	return len(s.principals)
+}
+
+// Authenticate maps the certificate the connection verified, and returns
+// its subject for when it is not mapped. Unverified certificates are never
+// looked at.
+func (s ClientCertStore) Authenticate(state *tls.ConnectionState) (principal Principal, subject string, ok bool) {
+	if state == nil || len(state.VerifiedChains) == 0 || len(state.VerifiedChains[0]) == 0 {
+		return Principal{}, "", false
+	}
+
+	subject = state.VerifiedChains[0][0].Subject.String()
+	principal, ok = s.principals[subject]
+
+	return principal, subject, ok
+}
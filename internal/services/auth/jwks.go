@@ -0,0 +1,263 @@
+package auth
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math/big"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	loggerutils "github.com/weiyuan-lane/google-translate-api/internal/utils/logger"
+)
+
+// Unknown key IDs trigger a refresh, at most this often, so keys rotated in
+// by the identity provider are picked up before the next scheduled refresh
+const minJWKSRefreshInterval = time.Minute
+
+// KeySet caches the public keys of a JWKS URL or file
+type KeySet struct {
+	url             string
+	file            string
+	refreshInterval time.Duration
+	httpClient      *http.Client
+	logger          *loggerutils.Logger
+
+	mutex       sync.RWMutex
+	keys        map[string]crypto.PublicKey
+	refreshedAt time.Time
+	attemptedAt time.Time
+}
+
+type jsonWebKeySet struct {
+	Keys []jsonWebKey `json:"keys"`
+}
+
+type jsonWebKey struct {
+	Kid string `json:"kid"`
+	Kty string `json:"kty"`
+	Use string `json:"use"`
+	Crv string `json:"crv"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+	X   string `json:"x"`
+	Y   string `json:"y"`
+}
+
+// NewKeySet loads keys from url, or from file when url is empty. The keys
+// are fetched again after refreshInterval.
+func NewKeySet(url, file string, refreshInterval time.Duration, httpClient *http.Client, logger *loggerutils.Logger) *KeySet {
+	if httpClient == nil {
+		httpClient = &http.Client{Timeout: 10 * time.Second}
+	}
+
+	return &KeySet{
+		url:             url,
+		file:            file,
+		refreshInterval: refreshInterval,
+		httpClient:      httpClient,
+		logger:          logger,
+		keys:            map[string]crypto.PublicKey{},
+	}
+}
+
+// Refresh skips the keys it cannot use, eg. of a type or curve that is not
+// supported, which identity providers may publish along with their signing
+// keys. It only fails when no signing key is left.
+func (k *KeySet) Refresh(ctx context.Context) error {
+	jwksBytes, err := k.read(ctx)
+	if err != nil {
+		return err
+	}
+
+	jwks := jsonWebKeySet{}
+	if err := json.Unmarshal(jwksBytes, &jwks); err != nil {
+		return fmt.Errorf("JWKS is not valid JSON: %w", err)
+	}
+
+	keys := map[string]crypto.PublicKey{}
+	skipped := []string{}
+	for i, jwk := range jwks.Keys {
+		if jwk.Use != "" && jwk.Use != "sig" {
+			continue
+		}
+
+		publicKey, err := jwk.publicKey()
+		if err != nil {
+			skipped = append(skipped, fmt.Sprintf("keys[%d] (kid %q): %s", i, jwk.Kid, err.Error()))
+			continue
+		}
+		keys[jwk.Kid] = publicKey
+	}
+
+	if len(keys) == 0 {
+		if len(skipped) > 0 {
+			return fmt.Errorf("JWKS has no usable signing keys, skipped %s", strings.Join(skipped, "; "))
+		}
+		return fmt.Errorf("JWKS has no signing keys")
+	}
+	for _, reason := range skipped {
+		k.logger.Warn("Skipped JWKS key", map[string]string{"key": reason})
+	}
+
+	k.mutex.Lock()
+	defer k.mutex.Unlock()
+	k.keys = keys
+	k.refreshedAt = time.Now()
+
+	return nil
+}
+
+func (k *KeySet) Len() int {
+	k.mutex.RLock()
+	defer k.mutex.RUnlock()
+
+	return len(k.keys)
+}
+
+// Key returns the key with kid, refreshing the set when it is stale or does
+// not have the key. Tokens without a kid match the only key of a set.
+func (k *KeySet) Key(ctx context.Context, kid string) (crypto.PublicKey, error) {
+	key, found := k.lookup(kid)
+
+	if k.shouldRefresh(found) {
+		if err := k.Refresh(ctx); err != nil && !found {
+			return nil, err
+		}
+		key, found = k.lookup(kid)
+	}
+
+	if !found {
+		return nil, fmt.Errorf("no JWKS key with kid %q", kid)
+	}
+
+	return key, nil
+}
+
+func (k *KeySet) lookup(kid string) (crypto.PublicKey, bool) {
+	k.mutex.RLock()
+	defer k.mutex.RUnlock()
+
+	if kid == "" && len(k.keys) == 1 {
+		for _, key := range k.keys {
+			return key, true
+		}
+	}
+
+	key, ok := k.keys[kid]
+	return key, ok
+}
+
+// shouldRefresh lets one caller at a time refresh a stale set, or a set
+// missing a key, and no more often than minJWKSRefreshInterval
+func (k *KeySet) shouldRefresh(found bool) bool {
+	k.mutex.Lock()
+	defer k.mutex.Unlock()
+
+	stale := time.Since(k.refreshedAt) > k.refreshInterval
+	if (!stale && found) || time.Since(k.attemptedAt) < minJWKSRefreshInterval {
+		return false
+	}
+
+	k.attemptedAt = time.Now()
+	return true
+}
+
+func (k *KeySet) read(ctx context.Context) ([]byte, error) {
+	if k.url == "" {
+		return os.ReadFile(k.file)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, k.url, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	res, err := k.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("fetch JWKS: %w", err)
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("fetch JWKS: %s responded with status %d", k.url, res.StatusCode)
+	}
+
+	return io.ReadAll(io.LimitReader(res.Body, 1<<20))
+}
+
+func (j jsonWebKey) publicKey() (crypto.PublicKey, error) {
+	switch j.Kty {
+	case "RSA":
+		n, err := decodeBigInt(j.N)
+		if err != nil {
+			return nil, fmt.Errorf("invalid \"n\": %w", err)
+		}
+		e, err := decodeBigInt(j.E)
+		if err != nil || !e.IsInt64() {
+			return nil, fmt.Errorf("invalid \"e\"")
+		}
+
+		return &rsa.PublicKey{N: n, E: int(e.Int64())}, nil
+	case "EC":
+		var curve elliptic.Curve
+		switch j.Crv {
+		case "P-256":
+			curve = elliptic.P256()
+		case "P-384":
+			curve = elliptic.P384()
+		case "P-521":
+			curve = elliptic.P521()
+		default:
+			return nil, fmt.Errorf("unsupported curve %q", j.Crv)
+		}
+
+		x, err := decodeBigInt(j.X)
+		if err != nil {
+			return nil, fmt.Errorf("invalid \"x\": %w", err)
+		}
+		y, err := decodeBigInt(j.Y)
+		if err != nil {
+			return nil, fmt.Errorf("invalid \"y\": %w", err)
+		}
+		if !curve.IsOnCurve(x, y) {
+			return nil, fmt.Errorf("point is not on curve %s", j.Crv)
+		}
+
+		return &ecdsa.PublicKey{Curve: curve, X: x, Y: y}, nil
+	case "OKP":
+		if j.Crv != "Ed25519" {
+			return nil, fmt.Errorf("unsupported curve %q", j.Crv)
+		}
+		x, err := base64.RawURLEncoding.DecodeString(j.X)
+		if err != nil || len(x) != ed25519.PublicKeySize {
+			return nil, fmt.Errorf("invalid \"x\"")
+		}
+
+		return ed25519.PublicKey(x), nil
+	default:
+		return nil, fmt.Errorf("unsupported key type %q", j.Kty)
+	}
+}
+
+func decodeBigInt(value string) (*big.Int, error) {
+	valueBytes, err := base64.RawURLEncoding.DecodeString(value)
+	if err != nil {
+		return nil, err
+	}
+	if len(valueBytes) == 0 {
+		return nil, fmt.Errorf("is empty")
+	}
+
+	return new(big.Int).SetBytes(valueBytes), nil
+}
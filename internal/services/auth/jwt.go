@@ -0,0 +1,149 @@
+package auth
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// Clock skew allowed between the identity provider and this service
+const jwtLeeway = 30 * time.Second
+
+var jwtSigningMethods = []string{
+	"RS256", "RS384", "RS512",
+	"PS256", "PS384", "PS512",
+	"ES256", "ES384", "ES512",
+	"EdDSA",
+}
+
+// JWTConfig describes the tokens accepted and how their claims map to a
+// Principal
+type JWTConfig struct {
+	Issuer   string
+	Audience string
+	// Claim holding scopes, either a space separated string (as in OAuth2)
+	// or an array of strings. Defaults to "scope".
+	ScopeClaim string
+	// Claim holding the tenant of the caller. Defaults to "tenant".
+	TenantClaim string
+	// Maps claim values that are not scopes themselves (eg. group names) to
+	// the scopes they grant
+	ScopeMapping map[string][]string
+}
+
+// JWTVerifier checks the signature, issuer, audience and expiry of JWTs,
+// such as OIDC ID tokens
+type JWTVerifier struct {
+	config JWTConfig
+	keySet *KeySet
+	parser *jwt.Parser
+}
+
+func NewJWTVerifier(config JWTConfig, keySet *KeySet) *JWTVerifier {
+	if config.ScopeClaim == "" {
+		config.ScopeClaim = "scope"
+	}
+	if config.TenantClaim == "" {
+		config.TenantClaim = "tenant"
+	}
+
+	options := []jwt.ParserOption{
+		jwt.WithValidMethods(jwtSigningMethods),
+		jwt.WithExpirationRequired(),
+		jwt.WithLeeway(jwtLeeway),
+	}
+	if config.Issuer != "" {
+		options = append(options, jwt.WithIssuer(config.Issuer))
+	}
+	if config.Audience != "" {
+		options = append(options, jwt.WithAudience(config.Audience))
+	}
+
+	return &JWTVerifier{
+		config: config,
+		keySet: keySet,
+		parser: jwt.NewParser(options...),
+	}
+}
+
+func (v *JWTVerifier) Authenticate(ctx context.Context, tokenString string) (Principal, error) {
+	claims := jwt.MapClaims{}
+	_, err := v.parser.ParseWithClaims(tokenString, claims, func(token *jwt.Token) (interface{}, error) {
+		kid, _ := token.Header["kid"].(string)
+		return v.keySet.Key(ctx, kid)
+	})
+	if err != nil {
+		return Principal{}, err
+	}
+
+	subject, err := claims.GetSubject()
+	if err != nil || subject == "" {
+		return Principal{}, fmt.Errorf("token has no \"sub\" claim")
+	}
+
+	tenant, _ := claims[v.config.TenantClaim].(string)
+
+	return Principal{
+		ID:     subject,
+		Scopes: v.scopes(claims[v.config.ScopeClaim]),
+		Tenant: tenant,
+	}, nil
+}
+
+func (v *JWTVerifier) scopes(claim interface{}) []string {
+	values := []string{}
+	switch claim := claim.(type) {
+	case string:
+		values = strings.Fields(claim)
+	case []interface{}:
+		for _, value := range claim {
+			if value, ok := value.(string); ok {
+				values = append(values, value)
+			}
+		}
+	}
+
+	scopes := []string{}
+	for _, value := range values {
+		if isKnownScope(value) {
+			scopes = append(scopes, value)
+		}
+		scopes = append(scopes, v.config.ScopeMapping[value]...)
+	}
+
+	return scopes
+}
+
+// IsJWT tells a JWT apart from an API key, which has no dots
+func IsJWT(credential string) bool {
+	return strings.Count(credential, ".") == 2
+}
+
+// ParseScopeMapping reads claim values and the scopes they grant, eg.
+// "translators=translate detect;glossary-editors=glossary:read glossary:write"
+func ParseScopeMapping(mapping string) (map[string][]string, error) {
+	scopeMapping := map[string][]string{}
+	for _, entry := range strings.Split(mapping, ";") {
+		if strings.TrimSpace(entry) == "" {
+			continue
+		}
+
+		value, scopes, ok := strings.Cut(entry, "=")
+		value = strings.TrimSpace(value)
+		if !ok || value == "" {
+			return nil, fmt.Errorf("entry %q is not in the form <claim value>=<scope> <scope>", entry)
+		}
+
+		for _, scope := range strings.Fields(scopes) {
+			if !isKnownScope(scope) {
+				return nil, fmt.Errorf("entry %q has unknown scope %q, expected one of %s", entry, scope, strings.Join(Scopes, ", "))
+			}
+			scopeMapping[value] = append(scopeMapping[value], scope)
+		}
+	}
+
+	return scopeMapping, nil
+}
@@ -0,0 +1,363 @@
+package auth
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+
+	loggerutils "github.com/weiyuan-lane/google-translate-api/internal/utils/logger"
+)
+
+const (
+	testIssuer   = "https://issuer.example.com"
+	testAudience = "google-translate-api"
+)
+
+type signingKey struct {
+	kid     string
+	private crypto.Signer
+}
+
+func newRSAKey(t *testing.T, kid string) signingKey {
+	t.Helper()
+
+	private, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return signingKey{kid: kid, private: private}
+}
+
+func newECKey(t *testing.T, kid string) signingKey {
+	t.Helper()
+
+	private, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return signingKey{kid: kid, private: private}
+}
+
+func (k signingKey) jwk() map[string]string {
+	encode := func(value *big.Int) string {
+		return base64.RawURLEncoding.EncodeToString(value.Bytes())
+	}
+
+	switch public := k.private.Public().(type) {
+	case *rsa.PublicKey:
+		return map[string]string{"kid": k.kid, "kty": "RSA", "use": "sig", "n": encode(public.N), "e": encode(big.NewInt(int64(public.E)))}
+	case *ecdsa.PublicKey:
+		return map[string]string{"kid": k.kid, "kty": "EC", "crv": "P-256", "x": encode(public.X), "y": encode(public.Y)}
+	}
+
+	panic("unsupported key type")
+}
+
+func (k signingKey) sign(t *testing.T, claims jwt.MapClaims) string {
+	t.Helper()
+
+	method := jwt.SigningMethod(jwt.SigningMethodRS256)
+	if _, ok := k.private.(*ecdsa.PrivateKey); ok {
+		method = jwt.SigningMethodES256
+	}
+
+	token := jwt.NewWithClaims(method, claims)
+	token.Header["kid"] = k.kid
+
+	tokenString, err := token.SignedString(k.private)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return tokenString
+}
+
+// jwksServer serves the JWKS of its current keys, counting the fetches
+type jwksServer struct {
+	*httptest.Server
+
+	mutex   sync.Mutex
+	keys    []signingKey
+	fetches atomic.Int32
+}
+
+func newJWKSServer(t *testing.T, keys ...signingKey) *jwksServer {
+	t.Helper()
+
+	server := &jwksServer{keys: keys}
+	server.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		server.fetches.Add(1)
+
+		server.mutex.Lock()
+		jwks := map[string][]map[string]string{"keys": {}}
+		for _, key := range server.keys {
+			jwks["keys"] = append(jwks["keys"], key.jwk())
+		}
+		server.mutex.Unlock()
+
+		json.NewEncoder(w).Encode(jwks)
+	}))
+	t.Cleanup(server.Close)
+
+	return server
+}
+
+func (s *jwksServer) rotate(keys ...signingKey) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.keys = keys
+}
+
+func newTestVerifier(t *testing.T, server *jwksServer, config JWTConfig) *JWTVerifier {
+	t.Helper()
+
+	keySet := NewKeySet(server.URL, "", time.Hour, nil, loggerutils.New("test", false))
+	if err := keySet.Refresh(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	config.Issuer = testIssuer
+	config.Audience = testAudience
+
+	return NewJWTVerifier(config, keySet)
+}
+
+func validClaims() jwt.MapClaims {
+	return jwt.MapClaims{
+		"sub":   "client-1",
+		"iss":   testIssuer,
+		"aud":   testAudience,
+		"exp":   time.Now().Add(time.Hour).Unix(),
+		"scope": "translate detect",
+	}
+}
+
+func TestJWTVerifierAcceptsValidTokens(t *testing.T) {
+	for _, key := range []signingKey{newRSAKey(t, "rsa"), newECKey(t, "ec")} {
+		t.Run(key.kid, func(t *testing.T) {
+			verifier := newTestVerifier(t, newJWKSServer(t, key), JWTConfig{})
+
+			principal, err := verifier.Authenticate(context.Background(), key.sign(t, validClaims()))
+			if err != nil {
+				t.Fatalf("Authenticate() error = %v", err)
+			}
+
+			want := Principal{ID: "client-1", Scopes: []string{ScopeTranslate, ScopeDetect}}
+			if !reflect.DeepEqual(principal, want) {
+				t.Errorf("Authenticate() = %+v, want %+v", principal, want)
+			}
+		})
+	}
+}
+
+func TestJWTVerifierRejectsInvalidTokens(t *testing.T) {
+	key := newRSAKey(t, "current")
+	verifier := newTestVerifier(t, newJWKSServer(t, key), JWTConfig{})
+
+	withClaim := func(name string, value interface{}) jwt.MapClaims {
+		claims := validClaims()
+		claims[name] = value
+		return claims
+	}
+
+	forged := newRSAKey(t, "current")
+	tests := []struct {
+		name  string
+		token string
+	}{
+		{"bad signature", forged.sign(t, validClaims())},
+		{"wrong issuer", key.sign(t, withClaim("iss", "https://other.example.com"))},
+		{"wrong audience", key.sign(t, withClaim("aud", "other-api"))},
+		{"expired", key.sign(t, withClaim("exp", time.Now().Add(-time.Hour).Unix()))},
+		{"expired beyond the leeway", key.sign(t, withClaim("exp", time.Now().Add(-2*jwtLeeway).Unix()))},
+		{"missing expiry", key.sign(t, func() jwt.MapClaims { claims := validClaims(); delete(claims, "exp"); return claims }())},
+		{"missing subject", key.sign(t, withClaim("sub", ""))},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if principal, err := verifier.Authenticate(context.Background(), tt.token); err == nil {
+				t.Errorf("Authenticate() = %+v, want an error", principal)
+			}
+		})
+	}
+}
+
+func TestJWTVerifierAllowsClockSkew(t *testing.T) {
+	key := newRSAKey(t, "current")
+	verifier := newTestVerifier(t, newJWKSServer(t, key), JWTConfig{})
+
+	claims := validClaims()
+	claims["exp"] = time.Now().Add(-jwtLeeway / 2).Unix()
+	if _, err := verifier.Authenticate(context.Background(), key.sign(t, claims)); err != nil {
+		t.Errorf("Authenticate() error = %v, want tokens within the leeway accepted", err)
+	}
+}
+
+func TestKeySetRefreshesOnUnknownKid(t *testing.T) {
+	oldKey, newKey := newECKey(t, "old"), newECKey(t, "new")
+	server := newJWKSServer(t, oldKey)
+	verifier := newTestVerifier(t, server, JWTConfig{})
+
+	if _, err := verifier.Authenticate(context.Background(), oldKey.sign(t, validClaims())); err != nil {
+		t.Fatalf("Authenticate() with the old key error = %v", err)
+	}
+	if fetches := server.fetches.Load(); fetches != 1 {
+		t.Fatalf("JWKS fetched %d times, want once while keys are known", fetches)
+	}
+
+	server.rotate(oldKey, newKey)
+	if _, err := verifier.Authenticate(context.Background(), newKey.sign(t, validClaims())); err != nil {
+		t.Fatalf("Authenticate() with the rotated key error = %v", err)
+	}
+	if fetches := server.fetches.Load(); fetches != 2 {
+		t.Fatalf("JWKS fetched %d times, want a refresh for the unknown kid", fetches)
+	}
+
+	// Unknown kids do not refresh again within minJWKSRefreshInterval, so
+	// they cannot be used to flood the identity provider
+	unknownKey := newECKey(t, "unknown")
+	if _, err := verifier.Authenticate(context.Background(), unknownKey.sign(t, validClaims())); err == nil {
+		t.Error("Authenticate() with an unknown kid succeeded")
+	}
+	if fetches := server.fetches.Load(); fetches != 2 {
+		t.Errorf("JWKS fetched %d times, want no refresh within the minimum interval", fetches)
+	}
+}
+
+// Keys an identity provider may publish for other algorithms or uses
+var unsupportedJWKs = []map[string]string{
+	{"kid": "hmac", "kty": "oct", "k": "c2VjcmV0"},
+	{"kid": "x25519", "kty": "OKP", "crv": "X25519", "x": "hSDwCYkwp1R0i33ctD73Wg2_Og0mOBr066SpjqqbTmo"},
+	{"kid": "secp256k1", "kty": "EC", "crv": "secp256k1", "x": "AQ", "y": "AQ"},
+}
+
+func writeJWKS(t *testing.T, keys []map[string]string) string {
+	t.Helper()
+
+	jwksBytes, err := json.Marshal(map[string][]map[string]string{"keys": keys})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	path := filepath.Join(t.TempDir(), "jwks.json")
+	if err := os.WriteFile(path, jwksBytes, 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	return path
+}
+
+func TestKeySetSkipsUnsupportedKeys(t *testing.T) {
+	key := newRSAKey(t, "rsa")
+	keySet := NewKeySet("", writeJWKS(t, append([]map[string]string{key.jwk()}, unsupportedJWKs...)), time.Hour, nil, loggerutils.New("test", false))
+
+	if err := keySet.Refresh(context.Background()); err != nil {
+		t.Fatalf("Refresh() error = %v", err)
+	}
+	if keys := keySet.Len(); keys != 1 {
+		t.Errorf("Len() = %d, want only the RSA key", keys)
+	}
+	if _, err := keySet.Key(context.Background(), "rsa"); err != nil {
+		t.Errorf("Key(\"rsa\") error = %v", err)
+	}
+}
+
+func TestKeySetFailsWithoutUsableKeys(t *testing.T) {
+	keySet := NewKeySet("", writeJWKS(t, unsupportedJWKs), time.Hour, nil, loggerutils.New("test", false))
+
+	err := keySet.Refresh(context.Background())
+	if err == nil || !strings.Contains(err.Error(), "secp256k1") {
+		t.Errorf("Refresh() error = %v, want the skipped keys reported", err)
+	}
+}
+
+func TestJWTVerifierMapsClaims(t *testing.T) {
+	key := newRSAKey(t, "current")
+	server := newJWKSServer(t, key)
+
+	scopeMapping, err := ParseScopeMapping("translators=translate detect;glossary-editors=glossary:read glossary:write")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name   string
+		config JWTConfig
+		claims jwt.MapClaims
+		want   Principal
+	}{
+		{
+			name:   "space separated scopes, unknown ones dropped",
+			claims: jwt.MapClaims{"scope": "translate openid admin", "tenant": "acme"},
+			want:   Principal{ID: "client-1", Scopes: []string{ScopeTranslate, ScopeAdmin}, Tenant: "acme"},
+		},
+		{
+			name:   "array of scopes",
+			claims: jwt.MapClaims{"scope": []interface{}{"detect", "glossary:read"}},
+			want:   Principal{ID: "client-1", Scopes: []string{ScopeDetect, ScopeGlossaryRead}},
+		},
+		{
+			name:   "custom claims with mapped groups",
+			config: JWTConfig{ScopeClaim: "groups", TenantClaim: "org", ScopeMapping: scopeMapping},
+			claims: jwt.MapClaims{"groups": []interface{}{"translators", "glossary-editors", "sales"}, "org": "globex", "tenant": "acme"},
+			want: Principal{
+				ID:     "client-1",
+				Scopes: []string{ScopeTranslate, ScopeDetect, ScopeGlossaryRead, ScopeGlossaryWrite},
+				Tenant: "globex",
+			},
+		},
+		{
+			name:   "no scopes",
+			claims: jwt.MapClaims{"scope": nil},
+			want:   Principal{ID: "client-1", Scopes: []string{}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			claims := validClaims()
+			delete(claims, "scope")
+			for name, value := range tt.claims {
+				claims[name] = value
+			}
+
+			principal, err := newTestVerifier(t, server, tt.config).Authenticate(context.Background(), key.sign(t, claims))
+			if err != nil {
+				t.Fatalf("Authenticate() error = %v", err)
+			}
+			if !reflect.DeepEqual(principal, tt.want) {
+				t.Errorf("Authenticate() = %+v, want %+v", principal, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseScopeMappingRejectsUnknownScopes(t *testing.T) {
+	for _, mapping := range []string{"translators=translate superuser", "=translate", "translators"} {
+		if _, err := ParseScopeMapping(mapping); err == nil {
+			t.Errorf("ParseScopeMapping(%q) error = nil, want an error", mapping)
+		}
+	}
+}
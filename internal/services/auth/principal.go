@@ -0,0 +1,51 @@
+package auth
+
+import (
+	"context"
+)
+
+// Scopes granted to API clients
+const (
+	ScopeTranslate     = "translate"
+	ScopeDetect        = "detect"
+	ScopeGlossaryRead  = "glossary:read"
+	ScopeGlossaryWrite = "glossary:write"
+	ScopeAdmin         = "admin"
+)
+
+var Scopes = []string{
+	ScopeTranslate,
+	ScopeDetect,
+	ScopeGlossaryRead,
+	ScopeGlossaryWrite,
+	ScopeAdmin,
+}
+
+// Principal is the authenticated client of a request
+type Principal struct {
+	ID     string
+	Scopes []string
+	// Tenant the client belongs to, if any
+	Tenant string
+}
+
+type principalContextKey struct{}
+
+func (p Principal) HasScope(scope string) bool {
+	for _, s := range p.Scopes {
+		if s == scope {
+			return true
+		}
+	}
+
+	return false
+}
+
+func WithPrincipal(ctx context.Context, principal Principal) context.Context {
+	return context.WithValue(ctx, principalContextKey{}, principal)
+}
+
+func PrincipalFromContext(ctx context.Context) (Principal, bool) {
+	principal, ok := ctx.Value(principalContextKey{}).(Principal)
+	return principal, ok
+}
@@ -0,0 +1,165 @@
+package configreload
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"sync"
+	"sync/atomic"
+	"syscall"
+	"time"
+
+	"github.com/weiyuan-lane/google-translate-api/internal/utils/config"
+	"github.com/weiyuan-lane/google-translate-api/internal/utils/errorhandlers"
+	loggerutils "github.com/weiyuan-lane/google-translate-api/internal/utils/logger"
+)
+
+// Result of a reload. Rejected changes are of settings only read at
+// startup, which keep their values until a restart.
+type Result struct {
+	Applied  []config.Change
+	Rejected []config.Change
+}
+
+// Reloader reads the config again on SIGHUP, when the config, API keys or
+// tenants file changes, or when asked to, and swaps in its reloadable
+// settings
+type Reloader struct {
+	file   string
+	apply  func(config.Reloadable) error
+	logger *loggerutils.Logger
+
+	// One reload at a time
+	mutex   sync.Mutex
+	current atomic.Pointer[config.AppConfig]
+}
+
+// New takes the config the server started with, and apply to put the
+// reloadable settings in place. A config is only kept once apply succeeds.
+func New(file string, current config.AppConfig, apply func(config.Reloadable) error, logger *loggerutils.Logger) *Reloader {
+	reloader := &Reloader{
+		file:   file,
+		apply:  apply,
+		logger: logger,
+	}
+	reloader.current.Store(&current)
+
+	return reloader
+}
+
+// Current is the config in effect
+func (r *Reloader) Current() config.AppConfig {
+	return *r.current.Load()
+}
+
+func (r *Reloader) Reload() (Result, error) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	next, err := config.Load(r.file)
+	if err != nil {
+		return Result{}, errorhandlers.Wrap(
+			errorhandlers.ErrConfigReloadFailed,
+			fmt.Sprintf("Keeping previous config: %s", err.Error()),
+		)
+	}
+
+	if err := r.apply(next.Reloadable()); err != nil {
+		return Result{}, errorhandlers.Wrap(
+			errorhandlers.ErrConfigReloadFailed,
+			fmt.Sprintf("Keeping previous config: %s", err.Error()),
+		)
+	}
+
+	current := r.Current()
+	result := Result{Applied: []config.Change{}, Rejected: []config.Change{}}
+	for _, change := range config.Diff(current, next) {
+		fields := map[string]string{
+			"setting": change.Name,
+			"from":    change.From,
+			"to":      change.To,
+		}
+
+		if change.Reloadable {
+			result.Applied = append(result.Applied, change)
+			r.logger.Info("Config setting changed", fields)
+		} else {
+			result.Rejected = append(result.Rejected, change)
+			r.logger.Warn("Config setting can only change on restart, keeping the previous value", fields)
+		}
+	}
+
+	updated := current.WithReloaded(next)
+	r.current.Store(&updated)
+	r.logger.Info(fmt.Sprintf("Reloaded config, %d settings changed, %d need a restart", len(result.Applied), len(result.Rejected)))
+
+	return result, nil
+}
+
+// Watch reloads on SIGHUP, and whenever the modification time of the config,
+// API keys or tenants file changes, until ctx is done
+func (r *Reloader) Watch(ctx context.Context, interval time.Duration) {
+	hangups := make(chan os.Signal, 1)
+	signal.Notify(hangups, syscall.SIGHUP)
+	defer signal.Stop(hangups)
+
+	var ticks <-chan time.Time
+	if interval > 0 {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		ticks = ticker.C
+	}
+
+	modTimes := r.modTimes()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-hangups:
+			r.logger.Info("Reloading config on SIGHUP")
+		case <-ticks:
+			latest := r.modTimes()
+			if equalModTimes(modTimes, latest) {
+				continue
+			}
+			modTimes = latest
+		}
+
+		// Failed reloads are only retried once a file changes again
+		if _, err := r.Reload(); err != nil {
+			r.logger.Error(err.Error())
+		}
+		modTimes = r.modTimes()
+	}
+}
+
+func (r *Reloader) modTimes() map[string]time.Time {
+	modTimes := map[string]time.Time{}
+	current := r.Current()
+	for _, path := range []string{r.file, current.APIKeysFile, current.TenantsFile} {
+		if path == "" {
+			continue
+		}
+
+		if fileInfo, err := os.Stat(path); err == nil {
+			modTimes[path] = fileInfo.ModTime()
+		}
+	}
+
+	return modTimes
+}
+
+func equalModTimes(a, b map[string]time.Time) bool {
+	if len(a) != len(b) {
+		return false
+	}
+
+	for path, modTime := range a {
+		if !modTime.Equal(b[path]) {
+			return false
+		}
+	}
+
+	return true
+}
@@ -0,0 +1,126 @@
+package glossaryexplain
+
+import (
+	"path"
+	"strings"
+
+	"github.com/weiyuan-lane/google-translate-api/internal/services/glossarysync"
+	"github.com/weiyuan-lane/google-translate-api/internal/services/localglossary"
+	"github.com/weiyuan-lane/google-translate-api/internal/utils/textdiff"
+)
+
+type Glossary struct {
+	SourceLocale string
+	TargetLocale string
+	TermSets     []localglossary.TermSet
+}
+
+// Catalog holds the entries of V3 glossaries whose source files the service
+// has access to, keyed by glossary ID
+type Catalog struct {
+	glossaries map[string]Glossary
+}
+
+type TermCoverage struct {
+	SourceTerm     string
+	TargetTerm     string
+	FoundInInput   bool
+	TargetInOutput bool
+}
+
+type Explanation struct {
+	EntriesKnown    bool
+	GlossaryChanged bool
+	Diff            []textdiff.Chunk
+	TermCoverage    []TermCoverage
+	TermsFound      int
+	TermsSatisfied  int
+}
+
+func NewCatalog(glossaries map[string]Glossary) Catalog {
+	return Catalog{
+		glossaries: glossaries,
+	}
+}
+
+// NewCatalogFromManifest loads the glossary files declared in a glossary
+// sync manifest
+func NewCatalogFromManifest(manifest glossarysync.Manifest) (Catalog, error) {
+	glossaries := map[string]Glossary{}
+
+	for _, entry := range manifest.Glossaries {
+		termSets, err := localglossary.LoadFile(entry.Path())
+		if err != nil {
+			return Catalog{}, err
+		}
+
+		glossaries[entry.Name] = Glossary{
+			SourceLocale: entry.SourceLocale,
+			TargetLocale: entry.TargetLocale,
+			TermSets:     termSets,
+		}
+	}
+
+	return NewCatalog(glossaries), nil
+}
+
+func (c Catalog) Len() int {
+	return len(c.glossaries)
+}
+
+// Lookup accepts either a glossary ID or its full resource name
+// (projects/*/locations/*/glossaries/*)
+func (c Catalog) Lookup(glossaryID string) (Glossary, bool) {
+	glossary, ok := c.glossaries[path.Base(glossaryID)]
+	return glossary, ok
+}
+
+// Explain compares the plain and glossary translations of input, and checks
+// each glossary source term found in input against the glossary output.
+// Terms match as whole words, ignoring case, so "cat" is not found in
+// "category".
+func (c Catalog) Explain(glossaryID, input, translatedText, glossaryTranslatedText string) Explanation {
+	explanation := Explanation{
+		GlossaryChanged: translatedText != glossaryTranslatedText,
+		Diff:            textdiff.Diff(translatedText, glossaryTranslatedText),
+		TermCoverage:    []TermCoverage{},
+	}
+
+	glossary, ok := c.Lookup(glossaryID)
+	if !ok {
+		return explanation
+	}
+
+	explanation.EntriesKnown = true
+
+	lowerInput := strings.ToLower(input)
+	lowerOutput := strings.ToLower(glossaryTranslatedText)
+
+	for _, termSet := range glossary.TermSets {
+		sourceTerm, hasSource := termSet.Term(glossary.SourceLocale)
+		targetTerm, hasTarget := termSet.Term(glossary.TargetLocale)
+		if !hasSource || !hasTarget {
+			continue
+		}
+
+		if !localglossary.ContainsWord(lowerInput, strings.ToLower(sourceTerm)) {
+			continue
+		}
+
+		coverage := TermCoverage{
+			SourceTerm:     sourceTerm,
+			TargetTerm:     targetTerm,
+			FoundInInput:   true,
+			TargetInOutput: localglossary.ContainsWord(lowerOutput, strings.ToLower(targetTerm)),
+		}
+
+		explanation.TermsFound++
+		if coverage.TargetInOutput {
+			explanation.TermsSatisfied++
+		}
+
+		explanation.TermCoverage = append(explanation.TermCoverage, coverage)
+	}
+
+	return explanation
+}
@@ -0,0 +1,37 @@
+package glossaryexplain
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/weiyuan-lane/google-translate-api/internal/services/localglossary"
+)
+
+func TestExplainMatchesWholeWords(t *testing.T) {
+	catalog := NewCatalog(map[string]Glossary{
+		"pets": {
+			SourceLocale: "en",
+			TargetLocale: "fr",
+			TermSets: []localglossary.TermSet{
+				{"en": "cat", "fr": "chat"},
+				{"en": "dog", "fr": "chien"},
+			},
+		},
+	})
+
+	explanation := catalog.Explain(
+		"projects/p/locations/us-central1/glossaries/pets",
+		"Pick a category for the Dog",
+		"Choisissez une catégorie pour le chiot",
+		"Choisissez une catégorie pour le chienne",
+	)
+
+	// "cat" is only part of "category", and "chien" only of "chienne"
+	want := []TermCoverage{{SourceTerm: "dog", TargetTerm: "chien", FoundInInput: true, TargetInOutput: false}}
+	if !reflect.DeepEqual(explanation.TermCoverage, want) {
+		t.Errorf("TermCoverage = %+v, want %+v", explanation.TermCoverage, want)
+	}
+	if explanation.TermsFound != 1 || explanation.TermsSatisfied != 0 {
+		t.Errorf("TermsFound, TermsSatisfied = %d, %d, want 1, 0", explanation.TermsFound, explanation.TermsSatisfied)
+	}
+}
@@ -0,0 +1,59 @@
+package glossarysync
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"cloud.google.com/go/storage"
+	"github.com/weiyuan-lane/google-translate-api/internal/utils/errorhandlers"
+)
+
+type GCSUploader struct {
+	storageClient *storage.Client
+}
+
+func NewGCSUploader(storageClient *storage.Client) GCSUploader {
+	return GCSUploader{
+		storageClient: storageClient,
+	}
+}
+
+func (g GCSUploader) ObjectURI(prefix, objectName string) string {
+	return strings.TrimSuffix(prefix, "/") + "/" + objectName
+}
+
+// Upload skips objects that already exist, as object names carry the
+// content hash
+func (g GCSUploader) Upload(ctx context.Context, gcsURI string, content []byte) error {
+	bucket, object, ok := strings.Cut(strings.TrimPrefix(gcsURI, "gs://"), "/")
+	if !strings.HasPrefix(gcsURI, "gs://") || !ok || bucket == "" || object == "" {
+		return errorhandlers.Wrap(
+			errorhandlers.ErrGlossarySyncUploadErrResponse,
+			fmt.Sprintf("Glossary upload target %q is not a gs://bucket/object URI", gcsURI),
+		)
+	}
+
+	objectHandle := g.storageClient.Bucket(bucket).Object(object)
+	if _, err := objectHandle.Attrs(ctx); err == nil {
+		return nil
+	}
+
+	writer := objectHandle.If(storage.Conditions{DoesNotExist: true}).NewWriter(ctx)
+	if _, err := writer.Write(content); err != nil {
+		writer.Close()
+		return errorhandlers.Wrap(
+			errorhandlers.ErrGlossarySyncUploadErrResponse,
+			fmt.Sprintf("Glossary upload to %s returning error: %s", gcsURI, err.Error()),
+		)
+	}
+
+	if err := writer.Close(); err != nil {
+		return errorhandlers.Wrap(
+			errorhandlers.ErrGlossarySyncUploadErrResponse,
+			fmt.Sprintf("Glossary upload to %s returning error: %s", gcsURI, err.Error()),
+		)
+	}
+
+	return nil
+}
@@ -0,0 +1,176 @@
+package glossarysync
+
+import (
+	"context"
+	"sort"
+	"strings"
+
+	"github.com/weiyuan-lane/google-translate-api/internal/services/googletranslatewrapper"
+)
+
+const (
+	ActionCreate    = "create"
+	ActionRecreate  = "recreate"
+	ActionDelete    = "delete"
+	ActionUnchanged = "unchanged"
+)
+
+// GlossaryBackend is satisfied by googletranslatewrapper.TranslateV3Wrapper
+type GlossaryBackend interface {
+	ListGlossaries(ctx context.Context) ([]googletranslatewrapper.GlossariesV3, error)
+	CreateGlossaryAndWait(ctx context.Context, id, gcsSource, sourceLocale, targetLocale string) error
+	DeleteGlossaryAndWait(ctx context.Context, id string) error
+	GlossaryName(id string) string
+}
+
+type Uploader interface {
+	ObjectURI(prefix, objectName string) string
+	Upload(ctx context.Context, gcsURI string, content []byte) error
+}
+
+type Syncer struct {
+	backend  GlossaryBackend
+	uploader Uploader
+}
+
+type PlanItem struct {
+	Action           string
+	Name             string
+	File             string
+	GCSSource        string
+	CurrentGCSSource string
+	SourceLocale     string
+	TargetLocale     string
+
+	content []byte
+}
+
+type Plan struct {
+	Items []PlanItem
+}
+
+func NewSyncer(backend GlossaryBackend, uploader Uploader) Syncer {
+	return Syncer{
+		backend:  backend,
+		uploader: uploader,
+	}
+}
+
+// Sync works out the plan for manifest and, unless dryRun is set, applies
+// it. On failure the returned plan holds the items applied so far.
+func (s Syncer) Sync(ctx context.Context, manifest Manifest, dryRun bool) (Plan, error) {
+	plan, err := s.Plan(ctx, manifest)
+	if err != nil || dryRun {
+		return plan, err
+	}
+
+	return s.Apply(ctx, plan)
+}
+
+func (s Syncer) Plan(ctx context.Context, manifest Manifest) (Plan, error) {
+	existingGlossaries, err := s.backend.ListGlossaries(ctx)
+	if err != nil {
+		return Plan{}, err
+	}
+
+	existingSources := map[string]string{}
+	for _, glossary := range existingGlossaries {
+		existingSources[glossary.ID] = glossary.GCSSource
+	}
+
+	plan := Plan{}
+	declaredNames := map[string]bool{}
+	for _, entry := range manifest.Glossaries {
+		name := s.backend.GlossaryName(entry.Name)
+		declaredNames[name] = true
+
+		item := PlanItem{
+			Name:         name,
+			File:         entry.File,
+			GCSSource:    s.uploader.ObjectURI(manifest.GCSPrefix, entry.objectName()),
+			SourceLocale: entry.SourceLocale,
+			TargetLocale: entry.TargetLocale,
+			content:      entry.content,
+		}
+
+		currentSource, exists := existingSources[name]
+		item.CurrentGCSSource = currentSource
+		switch {
+		case !exists:
+			item.Action = ActionCreate
+		case currentSource != item.GCSSource:
+			item.Action = ActionRecreate
+		default:
+			item.Action = ActionUnchanged
+		}
+
+		plan.Items = append(plan.Items, item)
+	}
+
+	// Only glossaries created by a sync are removed, unless the manifest
+	// claims ownership of every glossary in the project
+	managedPrefix := strings.TrimSuffix(manifest.GCSPrefix, "/") + "/"
+	for _, glossary := range existingGlossaries {
+		if declaredNames[glossary.ID] {
+			continue
+		}
+
+		if !manifest.PruneUnmanaged && !strings.HasPrefix(glossary.GCSSource, managedPrefix) {
+			continue
+		}
+
+		plan.Items = append(plan.Items, PlanItem{
+			Action:           ActionDelete,
+			Name:             glossary.ID,
+			CurrentGCSSource: glossary.GCSSource,
+		})
+	}
+
+	sort.SliceStable(plan.Items, func(i, j int) bool {
+		return plan.Items[i].Name < plan.Items[j].Name
+	})
+
+	return plan, nil
+}
+
+func (s Syncer) Apply(ctx context.Context, plan Plan) (Plan, error) {
+	applied := Plan{}
+
+	for _, item := range plan.Items {
+		switch item.Action {
+		case ActionCreate, ActionRecreate:
+			if err := s.uploader.Upload(ctx, item.GCSSource, item.content); err != nil {
+				return applied, err
+			}
+
+			if item.Action == ActionRecreate {
+				if err := s.backend.DeleteGlossaryAndWait(ctx, item.Name); err != nil {
+					return applied, err
+				}
+			}
+
+			err := s.backend.CreateGlossaryAndWait(ctx, item.Name, item.GCSSource, item.SourceLocale, item.TargetLocale)
+			if err != nil {
+				return applied, err
+			}
+		case ActionDelete:
+			if err := s.backend.DeleteGlossaryAndWait(ctx, item.Name); err != nil {
+				return applied, err
+			}
+		}
+
+		applied.Items = append(applied.Items, item)
+	}
+
+	return applied, nil
+}
+
+func (p Plan) HasChanges() bool {
+	for _, item := range p.Items {
+		if item.Action != ActionUnchanged {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,283 @@
+package glossarysync
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/weiyuan-lane/google-translate-api/internal/services/googletranslatewrapper"
+)
+
+const testPrefix = "gs://bucket/glossaries"
+
+// fakeBackend stands in for the V3 glossaries of a project, and records the
+// calls made to change them
+type fakeBackend struct {
+	glossaries []googletranslatewrapper.GlossariesV3
+	calls      []string
+	failOn     string
+}
+
+func (b *fakeBackend) ListGlossaries(ctx context.Context) ([]googletranslatewrapper.GlossariesV3, error) {
+	return b.glossaries, nil
+}
+
+func (b *fakeBackend) CreateGlossaryAndWait(ctx context.Context, id, gcsSource, sourceLocale, targetLocale string) error {
+	return b.call("create " + id)
+}
+
+func (b *fakeBackend) DeleteGlossaryAndWait(ctx context.Context, id string) error {
+	return b.call("delete " + id)
+}
+
+func (b *fakeBackend) GlossaryName(id string) string {
+	return "projects/p/locations/us-central1/glossaries/" + id
+}
+
+func (b *fakeBackend) call(call string) error {
+	if call == b.failOn {
+		return errors.New("backend failed")
+	}
+
+	b.calls = append(b.calls, call)
+	return nil
+}
+
+type fakeUploader struct {
+	uploads []string
+}
+
+func (u *fakeUploader) ObjectURI(prefix, objectName string) string {
+	return prefix + "/" + objectName
+}
+
+func (u *fakeUploader) Upload(ctx context.Context, gcsURI string, content []byte) error {
+	u.uploads = append(u.uploads, gcsURI)
+	return nil
+}
+
+// writeManifest declares a glossary per name, each with its own file
+func writeManifest(t *testing.T, pruneUnmanaged bool, names ...string) Manifest {
+	t.Helper()
+
+	dir := t.TempDir()
+	entries := []map[string]string{}
+	for _, name := range names {
+		file := name + ".csv"
+		if err := os.WriteFile(filepath.Join(dir, file), []byte("en,zh\n"+name+",术语\n"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+
+		entries = append(entries, map[string]string{
+			"name":          name,
+			"file":          file,
+			"source_locale": "en",
+			"target_locale": "zh",
+		})
+	}
+
+	manifestBytes, err := json.Marshal(map[string]interface{}{
+		"gcs_prefix":      testPrefix,
+		"prune_unmanaged": pruneUnmanaged,
+		"glossaries":      entries,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	path := filepath.Join(dir, "manifest.json")
+	if err := os.WriteFile(path, manifestBytes, 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	manifest, err := LoadManifest(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return manifest
+}
+
+func glossaryName(id string) string {
+	return (&fakeBackend{}).GlossaryName(id)
+}
+
+func sourceOf(manifest Manifest, name string) string {
+	for _, entry := range manifest.Glossaries {
+		if entry.Name == name {
+			return testPrefix + "/" + entry.objectName()
+		}
+	}
+
+	return ""
+}
+
+func actions(plan Plan) map[string]string {
+	actions := map[string]string{}
+	for _, item := range plan.Items {
+		actions[item.Name] = item.Action
+	}
+
+	return actions
+}
+
+func TestSyncPlansAndApplies(t *testing.T) {
+	manifest := writeManifest(t, false, "created", "changed", "same")
+
+	tests := []struct {
+		name           string
+		pruneUnmanaged bool
+		existing       []googletranslatewrapper.GlossariesV3
+		wantActions    map[string]string
+		wantCalls      []string
+		wantUploads    int
+	}{
+		{
+			name: "creates missing glossaries, recreates changed ones, and keeps unchanged ones",
+			existing: []googletranslatewrapper.GlossariesV3{
+				{ID: glossaryName("changed"), GCSSource: testPrefix + "/changed/0000000000000000.csv"},
+				{ID: glossaryName("same"), GCSSource: sourceOf(manifest, "same")},
+			},
+			wantActions: map[string]string{
+				glossaryName("created"): ActionCreate,
+				glossaryName("changed"): ActionRecreate,
+				glossaryName("same"):    ActionUnchanged,
+			},
+			wantCalls: []string{
+				"delete " + glossaryName("changed"),
+				"create " + glossaryName("changed"),
+				"create " + glossaryName("created"),
+			},
+			wantUploads: 2,
+		},
+		{
+			name: "deletes undeclared glossaries under the prefix only",
+			existing: []googletranslatewrapper.GlossariesV3{
+				{ID: glossaryName("created"), GCSSource: sourceOf(manifest, "created")},
+				{ID: glossaryName("changed"), GCSSource: sourceOf(manifest, "changed")},
+				{ID: glossaryName("same"), GCSSource: sourceOf(manifest, "same")},
+				{ID: glossaryName("stale"), GCSSource: testPrefix + "/stale/0000000000000000.csv"},
+				{ID: glossaryName("manual"), GCSSource: "gs://elsewhere/manual.csv"},
+			},
+			wantActions: map[string]string{
+				glossaryName("created"): ActionUnchanged,
+				glossaryName("changed"): ActionUnchanged,
+				glossaryName("same"):    ActionUnchanged,
+				glossaryName("stale"):   ActionDelete,
+			},
+			wantCalls: []string{
+				"delete " + glossaryName("stale"),
+			},
+		},
+		{
+			name:           "prunes every undeclared glossary when the manifest owns the project",
+			pruneUnmanaged: true,
+			existing: []googletranslatewrapper.GlossariesV3{
+				{ID: glossaryName("created"), GCSSource: sourceOf(manifest, "created")},
+				{ID: glossaryName("changed"), GCSSource: sourceOf(manifest, "changed")},
+				{ID: glossaryName("same"), GCSSource: sourceOf(manifest, "same")},
+				{ID: glossaryName("manual"), GCSSource: "gs://elsewhere/manual.csv"},
+			},
+			wantActions: map[string]string{
+				glossaryName("created"): ActionUnchanged,
+				glossaryName("changed"): ActionUnchanged,
+				glossaryName("same"):    ActionUnchanged,
+				glossaryName("manual"):  ActionDelete,
+			},
+			wantCalls: []string{
+				"delete " + glossaryName("manual"),
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			backend := &fakeBackend{glossaries: tt.existing}
+			uploader := &fakeUploader{}
+			syncer := NewSyncer(backend, uploader)
+
+			manifest := manifest
+			manifest.PruneUnmanaged = tt.pruneUnmanaged
+
+			plan, err := syncer.Sync(context.Background(), manifest, false)
+			if err != nil {
+				t.Fatalf("Sync() error = %v", err)
+			}
+
+			if got := actions(plan); !reflect.DeepEqual(got, tt.wantActions) {
+				t.Errorf("Sync() actions = %v, want %v", got, tt.wantActions)
+			}
+			if !reflect.DeepEqual(backend.calls, tt.wantCalls) {
+				t.Errorf("backend calls = %v, want %v", backend.calls, tt.wantCalls)
+			}
+			if len(uploader.uploads) != tt.wantUploads {
+				t.Errorf("uploads = %v, want %d of them", uploader.uploads, tt.wantUploads)
+			}
+		})
+	}
+}
+
+func TestSyncDryRunChangesNothing(t *testing.T) {
+	manifest := writeManifest(t, false, "created")
+	backend := &fakeBackend{glossaries: []googletranslatewrapper.GlossariesV3{
+		{ID: glossaryName("stale"), GCSSource: testPrefix + "/stale/0000000000000000.csv"},
+	}}
+	uploader := &fakeUploader{}
+
+	plan, err := NewSyncer(backend, uploader).Sync(context.Background(), manifest, true)
+	if err != nil {
+		t.Fatalf("Sync() error = %v", err)
+	}
+
+	want := map[string]string{
+		glossaryName("created"): ActionCreate,
+		glossaryName("stale"):   ActionDelete,
+	}
+	if got := actions(plan); !reflect.DeepEqual(got, want) {
+		t.Errorf("Sync() actions = %v, want %v", got, want)
+	}
+	if len(backend.calls) != 0 || len(uploader.uploads) != 0 {
+		t.Errorf("dry run made calls %v and uploads %v", backend.calls, uploader.uploads)
+	}
+	if !plan.HasChanges() {
+		t.Errorf("HasChanges() = false, want true")
+	}
+}
+
+func TestSyncReturnsItemsAppliedBeforeAFailure(t *testing.T) {
+	manifest := writeManifest(t, false, "a", "b", "c")
+	backend := &fakeBackend{failOn: "create " + glossaryName("b")}
+
+	plan, err := NewSyncer(backend, &fakeUploader{}).Sync(context.Background(), manifest, false)
+	if err == nil {
+		t.Fatal("Sync() error = nil, want the backend error")
+	}
+
+	want := map[string]string{glossaryName("a"): ActionCreate}
+	if got := actions(plan); !reflect.DeepEqual(got, want) {
+		t.Errorf("Sync() applied = %v, want %v", got, want)
+	}
+	if wantCalls := []string{"create " + glossaryName("a")}; !reflect.DeepEqual(backend.calls, wantCalls) {
+		t.Errorf("backend calls = %v, want %v", backend.calls, wantCalls)
+	}
+}
+
+func TestPlanIsUnchangedWhenInSync(t *testing.T) {
+	manifest := writeManifest(t, false, "same")
+	backend := &fakeBackend{glossaries: []googletranslatewrapper.GlossariesV3{
+		{ID: glossaryName("same"), GCSSource: sourceOf(manifest, "same")},
+	}}
+
+	plan, err := NewSyncer(backend, &fakeUploader{}).Plan(context.Background(), manifest)
+	if err != nil {
+		t.Fatalf("Plan() error = %v", err)
+	}
+
+	if plan.HasChanges() {
+		t.Errorf("HasChanges() = true for %v, want false", actions(plan))
+	}
+}
@@ -0,0 +1,129 @@
+package glossarysync
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/weiyuan-lane/google-translate-api/internal/services/localglossary"
+	"github.com/weiyuan-lane/google-translate-api/internal/utils/errorhandlers"
+)
+
+var glossaryIDPattern = regexp.MustCompile(`^[A-Za-z0-9_-]+$`)
+
+// Manifest declares the glossaries that should exist, eg.
+//
+//	{
+//	  "gcs_prefix": "gs://my-bucket/glossaries",
+//	  "prune_unmanaged": false,
+//	  "glossaries": [
+//	    {"name": "branding", "file": "branding.tmx", "source_locale": "en", "target_locale": "zh"}
+//	  ]
+//	}
+//
+// File paths are relative to the manifest itself.
+type Manifest struct {
+	GCSPrefix      string          `json:"gcs_prefix"`
+	PruneUnmanaged bool            `json:"prune_unmanaged"`
+	Glossaries     []ManifestEntry `json:"glossaries"`
+}
+
+type ManifestEntry struct {
+	Name         string `json:"name"`
+	File         string `json:"file"`
+	SourceLocale string `json:"source_locale"`
+	TargetLocale string `json:"target_locale"`
+
+	path    string
+	content []byte
+}
+
+func LoadManifest(path string) (Manifest, error) {
+	manifestBytes, err := os.ReadFile(path)
+	if err != nil {
+		return Manifest{}, errorhandlers.Wrap(
+			errorhandlers.ErrGlossarySyncInvalidManifest,
+			fmt.Sprintf("Glossary sync manifest could not be read: %s", err.Error()),
+		)
+	}
+
+	manifest := Manifest{}
+	if err := json.Unmarshal(manifestBytes, &manifest); err != nil {
+		return Manifest{}, errorhandlers.Wrap(
+			errorhandlers.ErrGlossarySyncInvalidManifest,
+			fmt.Sprintf("Glossary sync manifest %s is not valid JSON: %s", path, err.Error()),
+		)
+	}
+
+	problems := []string{}
+	if !strings.HasPrefix(manifest.GCSPrefix, "gs://") {
+		problems = append(problems, "\"gcs_prefix\" must start with gs://")
+	}
+
+	seenNames := map[string]bool{}
+	baseDir := filepath.Dir(path)
+	for i := range manifest.Glossaries {
+		entry := &manifest.Glossaries[i]
+
+		if !glossaryIDPattern.MatchString(entry.Name) {
+			problems = append(problems, fmt.Sprintf("glossaries[%d].name %q must only contain letters, numbers, \"-\" and \"_\"", i, entry.Name))
+		}
+		if seenNames[entry.Name] {
+			problems = append(problems, fmt.Sprintf("glossaries[%d].name %q is declared more than once", i, entry.Name))
+		}
+		seenNames[entry.Name] = true
+
+		if entry.SourceLocale == "" || entry.TargetLocale == "" {
+			problems = append(problems, fmt.Sprintf("glossaries[%d] needs both \"source_locale\" and \"target_locale\"", i))
+		}
+
+		filePath := entry.File
+		if !filepath.IsAbs(filePath) {
+			filePath = filepath.Join(baseDir, filePath)
+		}
+		entry.path = filePath
+
+		if _, err := localglossary.LoadFile(filePath); err != nil {
+			problems = append(problems, fmt.Sprintf("glossaries[%d].file: %s", i, err.Error()))
+			continue
+		}
+
+		entry.content, err = os.ReadFile(filePath)
+		if err != nil {
+			problems = append(problems, fmt.Sprintf("glossaries[%d].file: %s", i, err.Error()))
+		}
+	}
+
+	if len(problems) > 0 {
+		return Manifest{}, errorhandlers.Wrap(
+			errorhandlers.ErrGlossarySyncInvalidManifest,
+			fmt.Sprintf("Glossary sync manifest %s is invalid: %s", path, strings.Join(problems, "; ")),
+		)
+	}
+
+	return manifest, nil
+}
+
+// ContentHash covers the file contents and the language config, so changing
+// either one leads to the glossary being recreated
+func (m ManifestEntry) ContentHash() string {
+	hash := sha256.New()
+	hash.Write(m.content)
+	hash.Write([]byte("\n" + m.SourceLocale + "\n" + m.TargetLocale))
+
+	return hex.EncodeToString(hash.Sum(nil))[:16]
+}
+
+// Path is the glossary file location resolved against the manifest directory
+func (m ManifestEntry) Path() string {
+	return m.path
+}
+
+func (m ManifestEntry) objectName() string {
+	return m.Name + "/" + m.ContentHash() + strings.ToLower(filepath.Ext(m.File))
+}
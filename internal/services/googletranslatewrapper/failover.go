@@ -0,0 +1,319 @@
+package googletranslatewrapper
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/weiyuan-lane/google-translate-api/internal/utils/errorhandlers"
+	loggerutils "github.com/weiyuan-lane/google-translate-api/internal/utils/logger"
+	"github.com/weiyuan-lane/google-translate-api/internal/utils/metrics"
+	"github.com/weiyuan-lane/google-translate-api/internal/utils/tracing"
+	"go.opentelemetry.io/otel/trace"
+	"google.golang.org/api/googleapi"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+const (
+	BackendV2 = "v2"
+	BackendV3 = "v3"
+)
+
+// Error classes a failover policy can trigger on
+const (
+	ErrorClassUnavailable    = "unavailable"
+	ErrorClassTimeout        = "timeout"
+	ErrorClassRateLimited    = "rate_limited"
+	ErrorClassAuth           = "auth"
+	ErrorClassDisabled       = "disabled"
+	ErrorClassInvalidRequest = "invalid_request"
+	ErrorClassOther          = "other"
+)
+
+var ErrorClasses = []string{
+	ErrorClassUnavailable,
+	ErrorClassTimeout,
+	ErrorClassRateLimited,
+	ErrorClassAuth,
+	ErrorClassDisabled,
+	ErrorClassInvalidRequest,
+	ErrorClassOther,
+}
+
+// FailoverPolicy decides the backends of the unified endpoints. An empty
+// Primary follows the "v3" flag of each request, and an empty Secondary is
+// whichever backend is not the primary.
+type FailoverPolicy struct {
+	Enabled   bool
+	Primary   string
+	Secondary string
+	OnClasses []string
+}
+
+// Served tells which backend answered a unified request, and the backend
+// that failed before it when the request failed over
+type Served struct {
+	Backend        string
+	FailedOverFrom string
+	FailoverReason string
+	// Routing rule that picked the backend, if any
+	Rule string
+}
+
+type failover struct {
+	policy FailoverPolicy
+	logger *loggerutils.Logger
+
+	mutex  sync.Mutex
+	counts map[string]int
+}
+
+func (p FailoverPolicy) Validate() error {
+	for _, backend := range []string{p.Primary, p.Secondary} {
+		if backend != "" && backend != BackendV2 && backend != BackendV3 {
+			return fmt.Errorf("unknown backend %q, expected %q or %q", backend, BackendV2, BackendV3)
+		}
+	}
+
+	for _, class := range p.OnClasses {
+		if !containsString(ErrorClasses, class) {
+			return fmt.Errorf("unknown error class %q, expected one of %s", class, strings.Join(ErrorClasses, ", "))
+		}
+	}
+
+	return nil
+}
+
+// WithFailover returns a copy of the wrapper whose unified methods follow
+// policy, logging every failover with its running count
+func (t TranslateV2Wrapper) WithFailover(policy FailoverPolicy, logger *loggerutils.Logger) TranslateV2Wrapper {
+	t.failover = &failover{
+		policy: policy,
+		logger: logger,
+		counts: map[string]int{},
+	}
+
+	return t
+}
+
+func (t TranslateV2Wrapper) FailoverEnabled() bool {
+	return t.failover != nil && t.failover.policy.Enabled
+}
+
+// backends lists the backends to try in order, leaving out disabled ones
+func (t TranslateV2Wrapper) backends(useV3API bool) []string {
+	if !t.FailoverEnabled() {
+		if t.resolveUseV3API(useV3API) {
+			return []string{BackendV3}
+		}
+		return []string{BackendV2}
+	}
+
+	primary := t.failover.policy.Primary
+	if primary == "" {
+		primary = BackendV2
+		if useV3API {
+			primary = BackendV3
+		}
+	}
+
+	secondary := t.failover.policy.Secondary
+	if secondary == "" {
+		secondary = otherBackend(primary)
+	}
+
+	backends := []string{}
+	for _, backend := range []string{primary, secondary} {
+		if t.isBackendEnabled(backend) && !containsString(backends, backend) {
+			backends = append(backends, backend)
+		}
+	}
+
+	// Let the primary report why it cannot serve the request
+	if len(backends) == 0 {
+		backends = append(backends, primary)
+	}
+
+	return backends
+}
+
+// PrimaryBackend is the backend a unified request tries first, "" when none
+// is enabled
+func (t TranslateV2Wrapper) PrimaryBackend(useV3API bool) string {
+	backends := t.backends(useV3API)
+	if len(backends) == 0 {
+		return ""
+	}
+
+	return backends[0]
+}
+
+func (t TranslateV2Wrapper) isBackendEnabled(backend string) bool {
+	if backend == BackendV3 {
+		return t.translateV3Wrapper.IsEnabled()
+	}
+
+	return t.IsEnabled()
+}
+
+// shouldFailover logs the failover when err is of a class the policy
+// triggers on and there is a next backend to try
+func (f *failover) shouldFailover(err error, from, to string) (string, bool) {
+	if f == nil || to == "" {
+		return "", false
+	}
+
+	class := ClassifyError(err)
+	if !containsString(f.policy.OnClasses, class) {
+		return class, false
+	}
+
+	f.mutex.Lock()
+	key := from + "->" + to
+	f.counts[key]++
+	count := f.counts[key]
+	f.mutex.Unlock()
+
+	f.logger.Info(
+		fmt.Sprintf("Failed over from %s to %s backend", from, to),
+		map[string]string{
+			"failover_from":   from,
+			"failover_to":     to,
+			"failover_reason": class,
+			"failover_count":  strconv.Itoa(count),
+			"upstream_error":  err.Error(),
+		},
+	)
+
+	return class, true
+}
+
+// ClassifyError maps a wrapper error to one of ErrorClasses, based on the
+// upstream error where there is one
+func ClassifyError(err error) string {
+	if errors.Is(err, errorhandlers.ErrGoogleTranslateV2BackendDisabled) ||
+		errors.Is(err, errorhandlers.ErrGoogleTranslateV3BackendDisabled) {
+		return ErrorClassDisabled
+	}
+
+	// Provider quotas are not always answered with 429, eg. DeepL's 456
+	if errors.Is(err, errorhandlers.ErrRateLimitExceeded) ||
+		errors.Is(err, errorhandlers.ErrConcurrencyLimitExceeded) ||
+		errors.Is(err, errorhandlers.ErrProviderQuotaExceeded) {
+		return ErrorClassRateLimited
+	}
+
+	cause := errorhandlers.UpstreamCause(err)
+	if cause == nil {
+		return ErrorClassOther
+	}
+
+	if errors.Is(cause, context.DeadlineExceeded) {
+		return ErrorClassTimeout
+	}
+
+	var apiErr *googleapi.Error
+	if errors.As(cause, &apiErr) {
+		return classifyHTTPStatus(apiErr.Code)
+	}
+
+	var httpErr interface{ HTTPStatusCode() int }
+	if errors.As(cause, &httpErr) {
+		return classifyHTTPStatus(httpErr.HTTPStatusCode())
+	}
+
+	if grpcStatus, ok := status.FromError(cause); ok && grpcStatus.Code() != codes.Unknown {
+		return classifyGRPCCode(grpcStatus.Code())
+	}
+
+	var netErr net.Error
+	if errors.As(cause, &netErr) {
+		if netErr.Timeout() {
+			return ErrorClassTimeout
+		}
+		return ErrorClassUnavailable
+	}
+
+	return ErrorClassOther
+}
+
+func classifyHTTPStatus(statusCode int) string {
+	switch {
+	case statusCode == http.StatusTooManyRequests:
+		return ErrorClassRateLimited
+	case statusCode == http.StatusRequestTimeout || statusCode == http.StatusGatewayTimeout:
+		return ErrorClassTimeout
+	case statusCode == http.StatusUnauthorized || statusCode == http.StatusForbidden:
+		return ErrorClassAuth
+	case statusCode >= 500:
+		return ErrorClassUnavailable
+	case statusCode >= 400:
+		return ErrorClassInvalidRequest
+	default:
+		return ErrorClassOther
+	}
+}
+
+func classifyGRPCCode(code codes.Code) string {
+	switch code {
+	case codes.Unavailable, codes.Internal, codes.Aborted:
+		return ErrorClassUnavailable
+	case codes.DeadlineExceeded:
+		return ErrorClassTimeout
+	case codes.ResourceExhausted:
+		return ErrorClassRateLimited
+	case codes.Unauthenticated, codes.PermissionDenied:
+		return ErrorClassAuth
+	case codes.InvalidArgument, codes.NotFound, codes.FailedPrecondition, codes.OutOfRange:
+		return ErrorClassInvalidRequest
+	default:
+		return ErrorClassOther
+	}
+}
+
+func otherBackend(backend string) string {
+	if backend == BackendV3 {
+		return BackendV2
+	}
+
+	return BackendV3
+}
+
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+
+	return false
+}
+
+// observeUpstreamCall records the latency of a call to Google and, when it
+// failed, the class of its error
+func observeUpstreamCall(m *metrics.Metrics, backend, method string, start time.Time, err error) {
+	errorClass := ""
+	if err != nil {
+		errorClass = ClassifyError(err)
+	}
+
+	m.ObserveUpstreamCall(backend, method, start, errorClass)
+}
+
+// endServedSpan ends the span of a unified call with the backend that
+// served it
+func endServedSpan(span trace.Span, served Served, err error) {
+	span.SetAttributes(tracing.BackendKey.String(served.Backend))
+	if served.FailedOverFrom != "" {
+		span.SetAttributes(tracing.FailedOverKey.String(served.FailedOverFrom))
+	}
+
+	tracing.End(span, err)
+}
@@ -0,0 +1,233 @@
+package googletranslatewrapper
+
+import (
+	"context"
+	"encoding/json"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"cloud.google.com/go/translate"
+	translatev3 "cloud.google.com/go/translate/apiv3"
+	translatepb "cloud.google.com/go/translate/apiv3/translatepb"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+	"golang.org/x/text/language"
+	"google.golang.org/api/option"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+
+	"github.com/weiyuan-lane/google-translate-api/internal/utils/tracing"
+)
+
+// recordSpans makes the global tracer provider record every span, for the
+// rest of the test
+func recordSpans(t *testing.T) *tracetest.SpanRecorder {
+	t.Helper()
+
+	recorder := tracetest.NewSpanRecorder()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+	t.Cleanup(func() { otel.SetTracerProvider(trace.NewNoopTracerProvider()) })
+
+	return recorder
+}
+
+func endedSpan(t *testing.T, recorder *tracetest.SpanRecorder, name string) sdktrace.ReadOnlySpan {
+	t.Helper()
+
+	for _, span := range recorder.Ended() {
+		if span.Name() == name {
+			return span
+		}
+	}
+
+	t.Fatalf("no span %q was ended", name)
+	return nil
+}
+
+func assertAttributes(t *testing.T, span sdktrace.ReadOnlySpan, want ...attribute.KeyValue) {
+	t.Helper()
+
+	got := map[attribute.Key]attribute.Value{}
+	for _, attribute := range span.Attributes() {
+		got[attribute.Key] = attribute.Value
+	}
+
+	for _, attribute := range want {
+		if value, ok := got[attribute.Key]; !ok || value != attribute.Value {
+			t.Errorf("span %q attribute %s = %v, want %v", span.Name(), attribute.Key, value.Emit(), attribute.Value.Emit())
+		}
+	}
+}
+
+func assertChildOf(t *testing.T, child, parent sdktrace.ReadOnlySpan) {
+	t.Helper()
+
+	if child.Parent().SpanID() != parent.SpanContext().SpanID() {
+		t.Errorf("span %q is not a child of %q", child.Name(), parent.Name())
+	}
+}
+
+// fakeTranslationServer answers V3 translate calls, keeping the
+// "traceparent" of the last one
+type fakeTranslationServer struct {
+	translatepb.UnimplementedTranslationServiceServer
+
+	mutex       sync.Mutex
+	traceparent string
+}
+
+func (s *fakeTranslationServer) TranslateText(ctx context.Context, req *translatepb.TranslateTextRequest) (*translatepb.TranslateTextResponse, error) {
+	s.mutex.Lock()
+	if md, ok := metadata.FromIncomingContext(ctx); ok && len(md.Get("traceparent")) > 0 {
+		s.traceparent = md.Get("traceparent")[0]
+	}
+	s.mutex.Unlock()
+
+	translation := &translatepb.Translation{TranslatedText: "bonjour", DetectedLanguageCode: "en"}
+	return &translatepb.TranslateTextResponse{
+		Translations:         []*translatepb.Translation{translation},
+		GlossaryTranslations: []*translatepb.Translation{translation},
+	}, nil
+}
+
+func newV3Wrapper(t *testing.T, server *fakeTranslationServer) TranslateV3Wrapper {
+	t.Helper()
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	grpcServer := grpc.NewServer()
+	translatepb.RegisterTranslationServiceServer(grpcServer, server)
+	go grpcServer.Serve(listener)
+	t.Cleanup(grpcServer.Stop)
+
+	client, err := translatev3.NewTranslationClient(context.Background(),
+		option.WithEndpoint(listener.Addr().String()),
+		option.WithoutAuthentication(),
+		option.WithGRPCDialOption(grpc.WithTransportCredentials(insecure.NewCredentials())),
+		option.WithGRPCDialOption(grpc.WithChainUnaryInterceptor(tracing.UnaryClientInterceptor())),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { client.Close() })
+
+	return NewTranslateV3Wrapper(client, nil, "project", "us-central1")
+}
+
+// newV2Client reaches a stand-in for the V2 REST API, answering with status
+func newV2Client(t *testing.T, status int) *translate.Client {
+	t.Helper()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(status)
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"data": map[string]interface{}{
+				"translations": []map[string]string{
+					{"translatedText": "bonjour", "detectedSourceLanguage": "en"},
+				},
+			},
+		})
+	}))
+	t.Cleanup(server.Close)
+
+	client, err := translate.NewClient(context.Background(),
+		option.WithEndpoint(server.URL+"/"),
+		option.WithAPIKey("key"),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { client.Close() })
+
+	return client
+}
+
+func TestV3OptionsSpans(t *testing.T) {
+	recorder := recordSpans(t)
+	server := &fakeTranslationServer{}
+	wrapper := NewTranslateV2WrapperWithV3Wrapper(nil, newV3Wrapper(t, server))
+
+	_, _, err := wrapper.TranslateTextWithV3Options(context.Background(), "hello", language.French, V3Options{GlossaryID: "terms"})
+	if err != nil {
+		t.Fatalf("TranslateTextWithV3Options() error = %v", err)
+	}
+
+	unified := endedSpan(t, recorder, "TranslateV2Wrapper.TranslateTextWithV3Options")
+	assertAttributes(t, unified,
+		tracing.BackendKey.String(BackendV3),
+		tracing.TargetLocaleKey.String("fr"),
+		tracing.GlossaryKey.String("terms"),
+	)
+
+	call := endedSpan(t, recorder, "TranslateV3Wrapper.TranslateText")
+	assertChildOf(t, call, unified)
+	assertAttributes(t, call,
+		tracing.BackendKey.String(BackendV3),
+		tracing.SourceLocaleKey.String("en"),
+		tracing.TargetLocaleKey.String("fr"),
+		tracing.GlossaryKey.String("terms"),
+		tracing.CharactersKey.Int64(5),
+	)
+
+	// The trace is passed on to Google
+	if traceID := call.SpanContext().TraceID().String(); !strings.Contains(server.traceparent, traceID) {
+		t.Errorf("traceparent sent to Google = %q, want trace %s", server.traceparent, traceID)
+	}
+}
+
+func TestV2Spans(t *testing.T) {
+	recorder := recordSpans(t)
+	wrapper := NewTranslateV2Wrapper(newV2Client(t, http.StatusOK))
+
+	_, _, err := wrapper.TranslateTextWithV3API(context.Background(), "hello", language.French, false)
+	if err != nil {
+		t.Fatalf("TranslateTextWithV3API() error = %v", err)
+	}
+
+	unified := endedSpan(t, recorder, "TranslateV2Wrapper.TranslateTextWithV3API")
+	assertAttributes(t, unified,
+		tracing.BackendKey.String(BackendV2),
+		tracing.TargetLocaleKey.String("fr"),
+	)
+
+	call := endedSpan(t, recorder, "TranslateV2Wrapper.TranslateText")
+	assertChildOf(t, call, unified)
+	assertAttributes(t, call,
+		tracing.BackendKey.String(BackendV2),
+		tracing.SourceLocaleKey.String("en"),
+		tracing.TargetLocaleKey.String("fr"),
+		tracing.CharactersKey.Int64(5),
+	)
+}
+
+func TestFailedCallSpans(t *testing.T) {
+	recorder := recordSpans(t)
+	wrapper := NewTranslateV2Wrapper(newV2Client(t, http.StatusServiceUnavailable))
+
+	if _, err := wrapper.TranslateText(context.Background(), "hello", language.French); err == nil {
+		t.Fatal("TranslateText() error = nil, want the upstream error")
+	}
+
+	span := endedSpan(t, recorder, "TranslateV2Wrapper.TranslateText")
+	if span.Status().Code != codes.Error {
+		t.Errorf("span status = %v, want %v", span.Status().Code, codes.Error)
+	}
+	if len(span.Events()) == 0 || span.Events()[0].Name != "exception" {
+		t.Errorf("span events = %v, want the error recorded", span.Events())
+	}
+}
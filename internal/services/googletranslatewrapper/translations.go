@@ -17,6 +17,13 @@ type Detection struct {
 	Language   language.Tag
 }
 
+// V3Options are the V3 only settings of a unified translate request
+type V3Options struct {
+	SourceLocale string
+	GlossaryID   string
+	Model        string
+}
+
 type TranslationV3 struct {
 	TranslatedText         string
 	OriginalText           string
@@ -2,16 +2,28 @@ package googletranslatewrapper
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"net/url"
+	"time"
 
 	"cloud.google.com/go/translate"
+	"github.com/weiyuan-lane/google-translate-api/internal/services/usage"
 	"github.com/weiyuan-lane/google-translate-api/internal/utils/errorhandlers"
+	"github.com/weiyuan-lane/google-translate-api/internal/utils/metrics"
+	"github.com/weiyuan-lane/google-translate-api/internal/utils/ratelimit"
+	"github.com/weiyuan-lane/google-translate-api/internal/utils/requestinfo"
+	"github.com/weiyuan-lane/google-translate-api/internal/utils/tracing"
 	"golang.org/x/text/language"
 )
 
 type TranslateV2Wrapper struct {
 	translateClient    *translate.Client
 	translateV3Wrapper TranslateV3Wrapper
+	failover           *failover
+	limiter            *ratelimit.Limiter
+	meter              *usage.TenantMeter
+	metrics            *metrics.Metrics
 }
 
 func NewTranslateV2Wrapper(translateClient *translate.Client) TranslateV2Wrapper {
@@ -27,7 +39,56 @@ func NewTranslateV2WrapperWithV3Wrapper(translateClient *translate.Client, trans
 	}
 }
 
-func (t TranslateV2Wrapper) TranslateText(ctx context.Context, text string, targetLocale language.Tag) (Translation, error) {
+// IsEnabled is false when the V2 backend was turned off at startup
+func (t TranslateV2Wrapper) IsEnabled() bool {
+	return t.translateClient != nil
+}
+
+// WithLimiter returns a copy of the wrapper whose calls to Google wait on
+// limiter
+func (t TranslateV2Wrapper) WithLimiter(limiter *ratelimit.Limiter) TranslateV2Wrapper {
+	t.limiter = limiter
+	return t
+}
+
+// WithMeter returns a copy of the wrapper whose calls to Google are checked
+// against the budget of meter and recorded by it
+func (t TranslateV2Wrapper) WithMeter(meter *usage.TenantMeter) TranslateV2Wrapper {
+	t.meter = meter
+	return t
+}
+
+// WithMetrics returns a copy of the wrapper whose calls to Google are timed
+// and counted in metrics
+func (t TranslateV2Wrapper) WithMetrics(metrics *metrics.Metrics) TranslateV2Wrapper {
+	t.metrics = metrics
+	return t
+}
+
+func (t TranslateV2Wrapper) TranslateText(ctx context.Context, text string, targetLocale language.Tag) (_ Translation, err error) {
+	characters := usage.Characters(text)
+	ctx, span := tracing.Start(ctx, "TranslateV2Wrapper.TranslateText",
+		tracing.BackendKey.String(BackendV2),
+		tracing.TargetLocaleKey.String(targetLocale.String()),
+		tracing.CharactersKey.Int64(characters),
+	)
+	defer func() { tracing.End(span, err) }()
+
+	if !t.IsEnabled() {
+		return Translation{}, errV2BackendDisabled()
+	}
+
+	if err := t.meter.Check(ctx, characters); err != nil {
+		return Translation{}, err
+	}
+
+	release, err := t.limiter.Acquire(ctx, text)
+	if err != nil {
+		return Translation{}, err
+	}
+	defer release()
+
+	start := time.Now()
 	googleTranslations, err := t.translateClient.Translate(
 		ctx,
 		[]string{text},
@@ -36,11 +97,15 @@ func (t TranslateV2Wrapper) TranslateText(ctx context.Context, text string, targ
 	)
 
 	if err != nil {
-		return Translation{}, errorhandlers.Wrap(
+		err = errorhandlers.WrapUpstream(
 			errorhandlers.ErrGoogleTranslateV2EmptyTranslationResponse,
 			fmt.Sprintf("Google translate returned error %s", err.Error()),
+			err,
 		)
+		observeUpstreamCall(t.metrics, BackendV2, "translate", start, err)
+		return Translation{}, err
 	}
+	observeUpstreamCall(t.metrics, BackendV2, "translate", start, nil)
 
 	if len(googleTranslations) == 0 {
 		return Translation{}, errorhandlers.Wrap(
@@ -50,22 +115,58 @@ func (t TranslateV2Wrapper) TranslateText(ctx context.Context, text string, targ
 	}
 
 	translation := t.makeTranslationResponse(googleTranslations[0], text, targetLocale)
+	t.meter.Record(ctx, usage.Record{
+		API:          BackendV2,
+		Operation:    usage.OperationTranslate,
+		SourceLocale: translation.DetectedLang.String(),
+		TargetLocale: targetLocale.String(),
+		Characters:   characters,
+	})
+	t.metrics.AddTranslatedCharacters(BackendV2, translation.DetectedLang.String(), targetLocale.String(), characters)
+	span.SetAttributes(tracing.SourceLocaleKey.String(translation.DetectedLang.String()))
+	requestinfo.FromContext(ctx).SetBackend(BackendV2)
 
 	return translation, nil
 }
 
-func (t TranslateV2Wrapper) DetectionsFromText(ctx context.Context, text string) ([]Detection, error) {
+func (t TranslateV2Wrapper) DetectionsFromText(ctx context.Context, text string) (_ []Detection, err error) {
+	characters := usage.Characters(text)
+	ctx, span := tracing.Start(ctx, "TranslateV2Wrapper.DetectionsFromText",
+		tracing.BackendKey.String(BackendV2),
+		tracing.CharactersKey.Int64(characters),
+	)
+	defer func() { tracing.End(span, err) }()
+
+	if !t.IsEnabled() {
+		return []Detection{}, errV2BackendDisabled()
+	}
+
+	if err := t.meter.Check(ctx, characters); err != nil {
+		return []Detection{}, err
+	}
+
+	release, err := t.limiter.Acquire(ctx, text)
+	if err != nil {
+		return []Detection{}, err
+	}
+	defer release()
+
+	start := time.Now()
 	googleDetections, err := t.translateClient.DetectLanguage(
 		ctx,
 		[]string{text},
 	)
 
 	if err != nil {
-		return []Detection{}, errorhandlers.Wrap(
+		err = errorhandlers.WrapUpstream(
 			errorhandlers.ErrGoogleTranslateV2DetectErrResponse,
 			fmt.Sprintf("Google translate detection returned error %s", err.Error()),
+			err,
 		)
+		observeUpstreamCall(t.metrics, BackendV2, "detect", start, err)
+		return []Detection{}, err
 	}
+	observeUpstreamCall(t.metrics, BackendV2, "detect", start, nil)
 
 	if len(googleDetections) == 0 {
 		return []Detection{}, errorhandlers.Wrap(
@@ -75,75 +176,249 @@ func (t TranslateV2Wrapper) DetectionsFromText(ctx context.Context, text string)
 	}
 
 	detections := t.makeDetectionsResponse(googleDetections[0])
+	requestinfo.FromContext(ctx).SetBackend(BackendV2)
+	t.meter.Record(ctx, usage.Record{
+		API:        BackendV2,
+		Operation:  usage.OperationDetect,
+		Characters: characters,
+	})
 
 	return detections, nil
 }
 
-func (t TranslateV2Wrapper) TranslateTextWithV3API(ctx context.Context, text string, targetLocale language.Tag, useV3API bool) (Translation, error) {
-	if useV3API {
-		v3TranslationResult, wrappedErr := t.translateV3Wrapper.TranslateText(ctx, text, targetLocale.String(), nil, nil)
-		if wrappedErr != nil {
-			return Translation{}, wrappedErr
+// Ping lists the supported languages, a call that is not billed, to check
+// that Google is reachable with the credentials of the client
+func (t TranslateV2Wrapper) Ping(ctx context.Context) error {
+	if !t.IsEnabled() {
+		return errV2BackendDisabled()
+	}
+
+	start := time.Now()
+	_, err := t.translateClient.SupportedLanguages(ctx, language.English)
+	if err != nil {
+		// The URL of a failed request carries the API key
+		message := err.Error()
+		var urlErr *url.Error
+		if errors.As(err, &urlErr) {
+			message = urlErr.Err.Error()
 		}
 
-		detectedLangTag, err := language.Parse(v3TranslationResult.DetectedLang)
-		if err != nil {
-			return Translation{}, errorhandlers.Wrap(
-				errorhandlers.ErrGoogleTranslateV2ConvertLangTagErrResponse,
-				fmt.Sprintf("Google translate detected lang convert tag err %s", err.Error()),
-			)
+		err = errorhandlers.WrapUpstream(
+			errorhandlers.ErrGoogleTranslateV2SupportedLanguagesErrResponse,
+			fmt.Sprintf("Google translate supported languages returned error %s", message),
+			err,
+		)
+	}
+	observeUpstreamCall(t.metrics, BackendV2, "supported_languages", start, err)
+
+	return err
+}
+
+// TranslateTextWithV3API serves the unified translate endpoint, from the V2
+// or V3 backend depending on useV3API and the failover policy
+func (t TranslateV2Wrapper) TranslateTextWithV3API(ctx context.Context, text string, targetLocale language.Tag, useV3API bool) (_ Translation, served Served, err error) {
+	ctx, span := tracing.Start(ctx, "TranslateV2Wrapper.TranslateTextWithV3API",
+		tracing.TargetLocaleKey.String(targetLocale.String()),
+	)
+	defer func() { endServedSpan(span, served, err) }()
+
+	backends := t.backends(useV3API)
+
+	var lastErr error
+
+	for i, backend := range backends {
+		var translation Translation
+		if backend == BackendV3 {
+			translation, err = t.translateTextFromV3(ctx, text, targetLocale, V3Options{})
+		} else {
+			translation, err = t.TranslateText(ctx, text, targetLocale)
+		}
+
+		if err == nil {
+			served.Backend = backend
+			return translation, served, nil
+		}
+
+		lastErr = err
+		nextBackend := ""
+		if i+1 < len(backends) {
+			nextBackend = backends[i+1]
+		}
+		class, ok := t.failover.shouldFailover(err, backend, nextBackend)
+		if !ok {
+			return Translation{}, served, err
 		}
 
-		targetLangTag, err := language.Parse(v3TranslationResult.TargetLang)
+		served.FailedOverFrom = backend
+		served.FailoverReason = class
+	}
+
+	return Translation{}, served, lastErr
+}
+
+// DetectionsFromTextWithV3API serves the unified detect endpoint, from the V2
+// or V3 backend depending on useV3API and the failover policy
+func (t TranslateV2Wrapper) DetectionsFromTextWithV3API(ctx context.Context, text string, useV3API bool) (_ []Detection, served Served, err error) {
+	ctx, span := tracing.Start(ctx, "TranslateV2Wrapper.DetectionsFromTextWithV3API")
+	defer func() { endServedSpan(span, served, err) }()
+
+	backends := t.backends(useV3API)
+
+	var lastErr error
+
+	for i, backend := range backends {
+		var detections []Detection
+		if backend == BackendV3 {
+			detections, err = t.detectionsFromV3(ctx, text)
+		} else {
+			detections, err = t.DetectionsFromText(ctx, text)
+		}
+
+		if err == nil {
+			served.Backend = backend
+			return detections, served, nil
+		}
+
+		lastErr = err
+		nextBackend := ""
+		if i+1 < len(backends) {
+			nextBackend = backends[i+1]
+		}
+		class, ok := t.failover.shouldFailover(err, backend, nextBackend)
+		if !ok {
+			return []Detection{}, served, err
+		}
+
+		served.FailedOverFrom = backend
+		served.FailoverReason = class
+	}
+
+	return []Detection{}, served, lastErr
+}
+
+// TranslateTextWithV3Options serves a unified request that needs V3 only
+// settings, so it is not failed over to V2
+func (t TranslateV2Wrapper) TranslateTextWithV3Options(ctx context.Context, text string, targetLocale language.Tag, options V3Options) (_ Translation, served Served, err error) {
+	ctx, span := tracing.Start(ctx, "TranslateV2Wrapper.TranslateTextWithV3Options",
+		tracing.SourceLocaleKey.String(options.SourceLocale),
+		tracing.TargetLocaleKey.String(targetLocale.String()),
+		tracing.GlossaryKey.String(options.GlossaryID),
+		tracing.ModelKey.String(options.Model),
+	)
+	defer func() { endServedSpan(span, served, err) }()
+
+	translation, err := t.translateTextFromV3(ctx, text, targetLocale, options)
+	if err != nil {
+		return Translation{}, Served{}, err
+	}
+
+	return translation, Served{Backend: BackendV3}, nil
+}
+
+func (t TranslateV2Wrapper) translateTextFromV3(ctx context.Context, text string, targetLocale language.Tag, options V3Options) (Translation, error) {
+	v3TranslationResult, wrappedErr := t.translateV3Wrapper.TranslateText(
+		ctx,
+		text,
+		targetLocale.String(),
+		optionalString(options.SourceLocale),
+		optionalString(options.GlossaryID),
+		optionalString(options.Model),
+	)
+	if wrappedErr != nil {
+		return Translation{}, wrappedErr
+	}
+
+	translatedText := v3TranslationResult.TranslatedText
+	if options.GlossaryID != "" && v3TranslationResult.GlossaryTranslatedText != "" {
+		translatedText = v3TranslationResult.GlossaryTranslatedText
+	}
+
+	// No language is detected when the source locale is given
+	detectedLang := v3TranslationResult.DetectedLang
+	if detectedLang == "" {
+		detectedLang = options.SourceLocale
+	}
+
+	detectedLangTag, err := language.Parse(detectedLang)
+	if err != nil {
+		return Translation{}, errorhandlers.Wrap(
+			errorhandlers.ErrGoogleTranslateV2ConvertLangTagErrResponse,
+			fmt.Sprintf("Google translate detected lang convert tag err %s", err.Error()),
+		)
+	}
+
+	targetLangTag, err := language.Parse(v3TranslationResult.TargetLang)
+	if err != nil {
+		return Translation{}, errorhandlers.Wrap(
+			errorhandlers.ErrGoogleTranslateV2ConvertLangTagErrResponse,
+			fmt.Sprintf("Google translate target lang convert tag err %s", err.Error()),
+		)
+	}
+
+	return Translation{
+		TranslatedText: translatedText,
+		DetectedLang:   detectedLangTag,
+		OriginalText:   v3TranslationResult.OriginalText,
+		TargetLang:     targetLangTag,
+	}, nil
+}
+
+func (t TranslateV2Wrapper) detectionsFromV3(ctx context.Context, text string) ([]Detection, error) {
+	v3DetectionResults, wrappedErr := t.translateV3Wrapper.DetectionsFromText(ctx, text)
+	if wrappedErr != nil {
+		return []Detection{}, wrappedErr
+	}
+
+	detectionResults := make([]Detection, len(v3DetectionResults))
+
+	for i, v3DetectionResult := range v3DetectionResults {
+		confidence := float64(v3DetectionResult.Confidence)
+		isReliable := v3DetectionResult.Confidence > 0.8
+		detectedLangTag, err := language.Parse(v3DetectionResult.Language)
 		if err != nil {
-			return Translation{}, errorhandlers.Wrap(
+			return []Detection{}, errorhandlers.Wrap(
 				errorhandlers.ErrGoogleTranslateV2ConvertLangTagErrResponse,
-				fmt.Sprintf("Google translate target lang convert tag err %s", err.Error()),
+				fmt.Sprintf("Google translate detect lang convert tag err %s", err.Error()),
 			)
 		}
 
-		return Translation{
-			TranslatedText: v3TranslationResult.TranslatedText,
-			DetectedLang:   detectedLangTag,
-			OriginalText:   v3TranslationResult.OriginalText,
-			TargetLang:     targetLangTag,
-		}, nil
+		detectionResults[i] = Detection{
+			Confidence: confidence,
+			IsReliable: isReliable,
+			Language:   detectedLangTag,
+		}
 	}
 
-	return t.TranslateText(ctx, text, targetLocale)
+	return detectionResults, nil
 }
 
-func (t TranslateV2Wrapper) DetectionsFromTextWithV3API(ctx context.Context, text string, useV3API bool) ([]Detection, error) {
-	if useV3API {
-		v3DetectionResults, wrappedErr := t.translateV3Wrapper.DetectionsFromText(ctx, text)
-		if wrappedErr != nil {
-			return []Detection{}, wrappedErr
-		}
+// The requested backend is used when enabled, otherwise the request falls
+// back to whichever backend is
+func (t TranslateV2Wrapper) resolveUseV3API(useV3API bool) bool {
+	if useV3API && !t.translateV3Wrapper.IsEnabled() {
+		return !t.IsEnabled()
+	}
 
-		detectionResults := make([]Detection, len(v3DetectionResults))
-
-		for i, v3DetectionResult := range v3DetectionResults {
-			confidence := float64(v3DetectionResult.Confidence)
-			isReliable := v3DetectionResult.Confidence > 0.8
-			detectedLangTag, err := language.Parse(v3DetectionResult.Language)
-			if err != nil {
-				return []Detection{}, errorhandlers.Wrap(
-					errorhandlers.ErrGoogleTranslateV2ConvertLangTagErrResponse,
-					fmt.Sprintf("Google translate detect lang convert tag err %s", err.Error()),
-				)
-			}
-
-			detectionResults[i] = Detection{
-				Confidence: confidence,
-				IsReliable: isReliable,
-				Language:   detectedLangTag,
-			}
-		}
+	if !useV3API && !t.IsEnabled() {
+		return t.translateV3Wrapper.IsEnabled()
+	}
+
+	return useV3API
+}
 
-		return detectionResults, nil
+func optionalString(value string) *string {
+	if value == "" {
+		return nil
 	}
 
-	return t.DetectionsFromText(ctx, text)
+	return &value
+}
+
+func errV2BackendDisabled() error {
+	return errorhandlers.Wrap(
+		errorhandlers.ErrGoogleTranslateV2BackendDisabled,
+		"Google translate V2 backend is disabled",
+	)
 }
 
 func (t TranslateV2Wrapper) makeTranslationResponse(googleTranslation translate.Translation, originalText string, targetLocale language.Tag) Translation {
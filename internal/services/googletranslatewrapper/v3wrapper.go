@@ -3,28 +3,136 @@ package googletranslatewrapper
 import (
 	"context"
 	"fmt"
+	"strings"
+	"time"
 
 	translate "cloud.google.com/go/translate/apiv3"
 	translatepb "cloud.google.com/go/translate/apiv3/translatepb"
+	"github.com/weiyuan-lane/google-translate-api/internal/services/usage"
 	"github.com/weiyuan-lane/google-translate-api/internal/utils/errorhandlers"
+	"github.com/weiyuan-lane/google-translate-api/internal/utils/metrics"
+	"github.com/weiyuan-lane/google-translate-api/internal/utils/ratelimit"
+	"github.com/weiyuan-lane/google-translate-api/internal/utils/requestinfo"
+	"github.com/weiyuan-lane/google-translate-api/internal/utils/tracing"
 	"google.golang.org/api/iterator"
 )
 
+const globalLocation = "global"
+
+// TranslateV3Wrapper routes each request to the global location, unless it
+// needs a regional resource (glossaries and custom models), in which case
+// the regional location and its API endpoint are used instead
 type TranslateV3Wrapper struct {
-	translateClient *translate.TranslationClient
-	projectKey      string
+	translateClient         *translate.TranslationClient
+	regionalTranslateClient *translate.TranslationClient
+	projectID               string
+	regionalLocation        string
+	limiter                 *ratelimit.Limiter
+	glossaryTenant          string
+	meter                   *usage.TenantMeter
+	metrics                 *metrics.Metrics
 }
 
-func NewTranslateV3Wrapper(translateClient *translate.TranslationClient, projectKey string) TranslateV3Wrapper {
+func NewTranslateV3Wrapper(
+	translateClient *translate.TranslationClient,
+	regionalTranslateClient *translate.TranslationClient,
+	projectID string,
+	regionalLocation string,
+) TranslateV3Wrapper {
+	if regionalTranslateClient == nil {
+		regionalTranslateClient = translateClient
+	}
+
 	return TranslateV3Wrapper{
-		translateClient: translateClient,
-		projectKey:      projectKey,
+		translateClient:         translateClient,
+		regionalTranslateClient: regionalTranslateClient,
+		projectID:               projectID,
+		regionalLocation:        regionalLocation,
 	}
 }
 
-func (t TranslateV3Wrapper) TranslateText(ctx context.Context, text, targetLocale string, sourceLocale, glossaryID *string) (TranslationV3, error) {
+// IsEnabled is false when the V3 backend was turned off at startup
+func (t TranslateV3Wrapper) IsEnabled() bool {
+	return t.translateClient != nil
+}
+
+// WithLimiter returns a copy of the wrapper whose translate and detect calls
+// wait on limiter
+func (t TranslateV3Wrapper) WithLimiter(limiter *ratelimit.Limiter) TranslateV3Wrapper {
+	t.limiter = limiter
+	return t
+}
+
+// WithMeter returns a copy of the wrapper whose translate and detect calls
+// are checked against the budget of meter and recorded by it
+func (t TranslateV3Wrapper) WithMeter(meter *usage.TenantMeter) TranslateV3Wrapper {
+	t.meter = meter
+	return t
+}
+
+// WithMetrics returns a copy of the wrapper whose calls to Google are timed
+// and counted in metrics
+func (t TranslateV3Wrapper) WithMetrics(metrics *metrics.Metrics) TranslateV3Wrapper {
+	t.metrics = metrics
+	return t
+}
+
+// WithGlossaryTenant returns a copy of the wrapper that only reaches the
+// glossaries of tenant, for tenants sharing a project. Their IDs are the
+// tenant ID and GlossaryTenantSeparator, eg. "acme--terms".
+func (t TranslateV3Wrapper) WithGlossaryTenant(tenant string) TranslateV3Wrapper {
+	t.glossaryTenant = tenant
+	return t
+}
+
+// GlossaryTenantSeparator ends the tenant segment of a glossary ID, and
+// cannot be part of a tenant ID
+const GlossaryTenantSeparator = "--"
+
+func (t TranslateV3Wrapper) TranslateText(ctx context.Context, text, targetLocale string, sourceLocale, glossaryID, model *string) (_ TranslationV3, err error) {
+	characters, glossaryCharacters := usage.Characters(text), int64(0)
+	if glossaryID != nil {
+		glossaryCharacters = characters
+	}
+
+	ctx, span := tracing.Start(ctx, "TranslateV3Wrapper.TranslateText",
+		tracing.BackendKey.String(BackendV3),
+		tracing.TargetLocaleKey.String(targetLocale),
+		tracing.CharactersKey.Int64(characters),
+	)
+	defer func() { tracing.End(span, err) }()
+	if sourceLocale != nil {
+		span.SetAttributes(tracing.SourceLocaleKey.String(*sourceLocale))
+	}
+	if glossaryID != nil {
+		span.SetAttributes(tracing.GlossaryKey.String(*glossaryID))
+	}
+	if model != nil {
+		span.SetAttributes(tracing.ModelKey.String(*model))
+	}
+
+	if !t.IsEnabled() {
+		return TranslationV3{}, errV3BackendDisabled()
+	}
+
+	if err := t.meter.Check(ctx, characters+glossaryCharacters); err != nil {
+		return TranslationV3{}, err
+	}
+
+	release, err := t.limiter.Acquire(ctx, text)
+	if err != nil {
+		return TranslationV3{}, err
+	}
+	defer release()
+
+	isRegional := glossaryID != nil || (model != nil && isCustomModel(*model))
+	client, parent := t.translateClient, t.globalParent()
+	if isRegional {
+		client, parent = t.regionalTranslateClient, t.regionalParent()
+	}
+
 	req := &translatepb.TranslateTextRequest{
-		Parent:             t.projectKey, // Required
+		Parent:             parent, // Required
 		MimeType:           "text/plain",
 		Contents:           []string{text},
 		TargetLanguageCode: targetLocale,
@@ -33,25 +141,34 @@ func (t TranslateV3Wrapper) TranslateText(ctx context.Context, text, targetLocal
 	// Use glossary if indicated
 	if glossaryID != nil {
 		req.GlossaryConfig = &translatepb.TranslateTextGlossaryConfig{
-			Glossary: *glossaryID,
+			Glossary: t.GlossaryName(*glossaryID),
 		}
 	}
 
+	if model != nil {
+		req.Model = t.modelName(parent, *model)
+	}
+
 	if sourceLocale != nil {
 		req.SourceLanguageCode = *sourceLocale
 	}
 
-	googleTranslationResponse, err := t.translateClient.TranslateText(
+	start := time.Now()
+	googleTranslationResponse, err := client.TranslateText(
 		ctx,
 		req,
 	)
 
 	if err != nil {
-		return TranslationV3{}, errorhandlers.Wrap(
+		err = errorhandlers.WrapUpstream(
 			errorhandlers.ErrGoogleTranslateV3EmptyTranslationResponse,
 			fmt.Sprintf("Google translate returned error %s", err.Error()),
+			err,
 		)
+		observeUpstreamCall(t.metrics, BackendV3, "translate", start, err)
+		return TranslationV3{}, err
 	}
+	observeUpstreamCall(t.metrics, BackendV3, "translate", start, nil)
 
 	if googleTranslationResponse == nil || len(googleTranslationResponse.Translations) == 0 {
 		return TranslationV3{}, errorhandlers.Wrap(
@@ -70,29 +187,74 @@ func (t TranslateV3Wrapper) TranslateText(ctx context.Context, text, targetLocal
 		return TranslationV3{}, wrappedErr
 	}
 
+	record := usage.Record{
+		API:                BackendV3,
+		Operation:          usage.OperationTranslate,
+		SourceLocale:       translation.DetectedLang,
+		TargetLocale:       targetLocale,
+		Characters:         characters,
+		GlossaryCharacters: glossaryCharacters,
+	}
+	if sourceLocale != nil {
+		record.SourceLocale = *sourceLocale
+	}
+	if model != nil {
+		record.Model = *model
+	}
+	t.meter.Record(ctx, record)
+	t.metrics.AddTranslatedCharacters(BackendV3, record.SourceLocale, targetLocale, characters)
+	span.SetAttributes(tracing.SourceLocaleKey.String(record.SourceLocale))
+	requestinfo.FromContext(ctx).SetBackend(BackendV3)
+
 	return translation, nil
 }
 
-func (t TranslateV3Wrapper) DetectionsFromText(ctx context.Context, text string) ([]DetectionV3, error) {
+func (t TranslateV3Wrapper) DetectionsFromText(ctx context.Context, text string) (_ []DetectionV3, err error) {
+	characters := usage.Characters(text)
+	ctx, span := tracing.Start(ctx, "TranslateV3Wrapper.DetectionsFromText",
+		tracing.BackendKey.String(BackendV3),
+		tracing.CharactersKey.Int64(characters),
+	)
+	defer func() { tracing.End(span, err) }()
+
+	if !t.IsEnabled() {
+		return []DetectionV3{}, errV3BackendDisabled()
+	}
+
+	if err := t.meter.Check(ctx, characters); err != nil {
+		return []DetectionV3{}, err
+	}
+
+	release, err := t.limiter.Acquire(ctx, text)
+	if err != nil {
+		return []DetectionV3{}, err
+	}
+	defer release()
+
 	req := &translatepb.DetectLanguageRequest{
-		Parent:   t.projectKey, // Required
+		Parent:   t.globalParent(), // Required
 		MimeType: "text/plain",
 		Source: &translatepb.DetectLanguageRequest_Content{
 			Content: text,
 		},
 	}
 
+	start := time.Now()
 	googleDetectionResponse, err := t.translateClient.DetectLanguage(
 		ctx,
 		req,
 	)
 
 	if err != nil {
-		return []DetectionV3{}, errorhandlers.Wrap(
+		err = errorhandlers.WrapUpstream(
 			errorhandlers.ErrGoogleTranslateV3DetectErrResponse,
 			fmt.Sprintf("Google translate detection returned error %s", err.Error()),
+			err,
 		)
+		observeUpstreamCall(t.metrics, BackendV3, "detect", start, err)
+		return []DetectionV3{}, err
 	}
+	observeUpstreamCall(t.metrics, BackendV3, "detect", start, nil)
 
 	if googleDetectionResponse == nil {
 		return []DetectionV3{}, errorhandlers.Wrap(
@@ -101,14 +263,66 @@ func (t TranslateV3Wrapper) DetectionsFromText(ctx context.Context, text string)
 		)
 	}
 
-	detections := t.makeDetectionsResponse(*googleDetectionResponse)
+	detections := t.makeDetectionsResponse(googleDetectionResponse)
+	requestinfo.FromContext(ctx).SetBackend(BackendV3)
+	t.meter.Record(ctx, usage.Record{
+		API:        BackendV3,
+		Operation:  usage.OperationDetect,
+		Characters: characters,
+	})
 
 	return detections, nil
 }
 
+// Ping lists the supported languages of the project, a call that is not
+// billed, to check that Google is reachable with the credentials of the
+// client and that the project exists
+func (t TranslateV3Wrapper) Ping(ctx context.Context) error {
+	if !t.IsEnabled() {
+		return errV3BackendDisabled()
+	}
+
+	start := time.Now()
+	_, err := t.translateClient.GetSupportedLanguages(ctx, &translatepb.GetSupportedLanguagesRequest{
+		Parent: t.globalParent(),
+	})
+	if err != nil {
+		err = errorhandlers.WrapUpstream(
+			errorhandlers.ErrGoogleTranslateV3SupportedLanguagesErrResponse,
+			fmt.Sprintf("Google translate supported languages returned error %s", err.Error()),
+			err,
+		)
+	}
+	observeUpstreamCall(t.metrics, BackendV3, "supported_languages", start, err)
+
+	return err
+}
+
 func (t TranslateV3Wrapper) CreateGlossary(ctx context.Context, id, gcsSource, sourceLocale, targetLocale string) error {
+	return t.createGlossary(ctx, id, gcsSource, sourceLocale, targetLocale, false)
+}
+
+// CreateGlossaryAndWait blocks until the long running create operation is
+// done, for callers that act on the glossary right after
+func (t TranslateV3Wrapper) CreateGlossaryAndWait(ctx context.Context, id, gcsSource, sourceLocale, targetLocale string) error {
+	return t.createGlossary(ctx, id, gcsSource, sourceLocale, targetLocale, true)
+}
+
+func (t TranslateV3Wrapper) createGlossary(ctx context.Context, id, gcsSource, sourceLocale, targetLocale string, wait bool) (err error) {
+	ctx, span := tracing.Start(ctx, "TranslateV3Wrapper.CreateGlossary",
+		tracing.BackendKey.String(BackendV3),
+		tracing.GlossaryKey.String(id),
+		tracing.SourceLocaleKey.String(sourceLocale),
+		tracing.TargetLocaleKey.String(targetLocale),
+	)
+	defer func() { tracing.End(span, err) }()
+
+	if !t.IsEnabled() {
+		return errV3BackendDisabled()
+	}
+
 	glossary := &translatepb.Glossary{
-		Name:        id,
+		Name:        t.GlossaryName(id),
 		DisplayName: id,
 		InputConfig: &translatepb.GlossaryInputConfig{
 			Source: &translatepb.GlossaryInputConfig_GcsSource{
@@ -125,30 +339,46 @@ func (t TranslateV3Wrapper) CreateGlossary(ctx context.Context, id, gcsSource, s
 		},
 	}
 	req := &translatepb.CreateGlossaryRequest{
-		Parent:   t.projectKey, // Required
+		Parent:   t.regionalParent(), // Required
 		Glossary: glossary,
 	}
 
-	_, err := t.translateClient.CreateGlossary(
+	start := time.Now()
+	op, err := t.regionalTranslateClient.CreateGlossary(
 		ctx,
 		req,
 	)
+	if err == nil && wait {
+		_, err = op.Wait(ctx)
+	}
 	if err != nil {
-		return errorhandlers.Wrap(
+		err = errorhandlers.WrapUpstream(
 			errorhandlers.ErrGoogleTranslateV3CreateGlossaryErrResponse,
 			fmt.Sprintf("Google translate create glossary returning error: %s", err.Error()),
+			err,
 		)
 	}
+	observeUpstreamCall(t.metrics, BackendV3, "create_glossary", start, err)
 
-	return nil
+	return err
 }
 
-func (t TranslateV3Wrapper) ListGlossaries(ctx context.Context) ([]GlossariesV3, error) {
+func (t TranslateV3Wrapper) ListGlossaries(ctx context.Context) (_ []GlossariesV3, err error) {
+	ctx, span := tracing.Start(ctx, "TranslateV3Wrapper.ListGlossaries",
+		tracing.BackendKey.String(BackendV3),
+	)
+	defer func() { tracing.End(span, err) }()
+
+	if !t.IsEnabled() {
+		return []GlossariesV3{}, errV3BackendDisabled()
+	}
+
 	req := &translatepb.ListGlossariesRequest{
-		Parent: t.projectKey,
+		Parent: t.regionalParent(),
 	}
 
-	glossaries := t.translateClient.ListGlossaries(
+	start := time.Now()
+	glossaries := t.regionalTranslateClient.ListGlossaries(
 		ctx,
 		req,
 	)
@@ -156,10 +386,17 @@ func (t TranslateV3Wrapper) ListGlossaries(ctx context.Context) ([]GlossariesV3,
 	results := []GlossariesV3{}
 	for currItem, err := glossaries.Next(); err != iterator.Done; currItem, err = glossaries.Next() {
 		if err != nil {
-			return []GlossariesV3{}, errorhandlers.Wrap(
+			err = errorhandlers.WrapUpstream(
 				errorhandlers.ErrGoogleTranslateV3ListGlossaryErrResponse,
 				fmt.Sprintf("Google translate list glossary returning error: %s", err.Error()),
+				err,
 			)
+			observeUpstreamCall(t.metrics, BackendV3, "list_glossaries", start, err)
+			return []GlossariesV3{}, err
+		}
+
+		if !t.ownsGlossary(glossaryID(currItem.Name)) {
+			continue
 		}
 
 		results = append(results, GlossariesV3{
@@ -167,27 +404,123 @@ func (t TranslateV3Wrapper) ListGlossaries(ctx context.Context) ([]GlossariesV3,
 			GCSSource: currItem.InputConfig.GetGcsSource().GetInputUri(),
 		})
 	}
+	observeUpstreamCall(t.metrics, BackendV3, "list_glossaries", start, nil)
 
 	return results, nil
 }
 
 func (t TranslateV3Wrapper) DeleteGlossary(ctx context.Context, id string) error {
+	return t.deleteGlossary(ctx, id, false)
+}
+
+// DeleteGlossaryAndWait blocks until the long running delete operation is
+// done, for callers that recreate the glossary right after
+func (t TranslateV3Wrapper) DeleteGlossaryAndWait(ctx context.Context, id string) error {
+	return t.deleteGlossary(ctx, id, true)
+}
+
+// GlossaryName expands a glossary ID into its full resource name under the
+// regional location. Full resource names are returned as is, unless the
+// wrapper has a glossary tenant, which every name is kept under.
+func (t TranslateV3Wrapper) GlossaryName(id string) string {
+	if t.glossaryTenant != "" {
+		id = glossaryID(id)
+		if !t.ownsGlossary(id) {
+			id = t.glossaryTenant + GlossaryTenantSeparator + id
+		}
+
+		return t.regionalParent() + "/glossaries/" + id
+	}
+
+	if strings.Contains(id, "/") {
+		return id
+	}
+
+	return t.regionalParent() + "/glossaries/" + id
+}
+
+// ownsGlossary matches the tenant segment of id exactly, so that tenant
+// "acme" cannot reach the glossaries of "acme-eu"
+func (t TranslateV3Wrapper) ownsGlossary(id string) bool {
+	if t.glossaryTenant == "" {
+		return true
+	}
+
+	tenant, _, ok := strings.Cut(id, GlossaryTenantSeparator)
+	return ok && tenant == t.glossaryTenant
+}
+
+func glossaryID(name string) string {
+	return name[strings.LastIndex(name, "/")+1:]
+}
+
+func (t TranslateV3Wrapper) globalParent() string {
+	return "projects/" + t.projectID + "/locations/" + globalLocation
+}
+
+func (t TranslateV3Wrapper) regionalParent() string {
+	return "projects/" + t.projectID + "/locations/" + t.regionalLocation
+}
+
+// Models are given either as a full resource name, or relative to the
+// location, eg. "general/nmt" or "TRL1234567890"
+func (t TranslateV3Wrapper) modelName(parent, model string) string {
+	if strings.HasPrefix(model, "projects/") {
+		return model
+	}
+
+	return parent + "/models/" + model
+}
+
+func errV3BackendDisabled() error {
+	return errorhandlers.Wrap(
+		errorhandlers.ErrGoogleTranslateV3BackendDisabled,
+		"Google translate V3 backend is disabled",
+	)
+}
+
+func isCustomModel(model string) bool {
+	modelID := model
+	if _, afterModels, found := strings.Cut(model, "/models/"); found {
+		modelID = afterModels
+	}
+
+	return !strings.HasPrefix(modelID, "general/")
+}
+
+func (t TranslateV3Wrapper) deleteGlossary(ctx context.Context, id string, wait bool) (err error) {
+	ctx, span := tracing.Start(ctx, "TranslateV3Wrapper.DeleteGlossary",
+		tracing.BackendKey.String(BackendV3),
+		tracing.GlossaryKey.String(id),
+	)
+	defer func() { tracing.End(span, err) }()
+
+	if !t.IsEnabled() {
+		return errV3BackendDisabled()
+	}
+
 	req := &translatepb.DeleteGlossaryRequest{
-		Name: id,
+		Name: t.GlossaryName(id),
 	}
 
-	_, err := t.translateClient.DeleteGlossary(
+	start := time.Now()
+	op, err := t.regionalTranslateClient.DeleteGlossary(
 		ctx,
 		req,
 	)
+	if err == nil && wait {
+		_, err = op.Wait(ctx)
+	}
 	if err != nil {
-		return errorhandlers.Wrap(
+		err = errorhandlers.WrapUpstream(
 			errorhandlers.ErrGoogleTranslateV3DeleteGlossaryErrResponse,
 			fmt.Sprintf("Google translate delete glossary returning error: %s", err.Error()),
+			err,
 		)
 	}
+	observeUpstreamCall(t.metrics, BackendV3, "delete_glossary", start, err)
 
-	return nil
+	return err
 }
 
 func (t TranslateV3Wrapper) makeTranslationResponse(
@@ -218,7 +551,7 @@ func (t TranslateV3Wrapper) makeTranslationResponse(
 	return translationResponse, nil
 }
 
-func (t TranslateV3Wrapper) makeDetectionsResponse(googleDetectionResponse translatepb.DetectLanguageResponse) []DetectionV3 {
+func (t TranslateV3Wrapper) makeDetectionsResponse(googleDetectionResponse *translatepb.DetectLanguageResponse) []DetectionV3 {
 	detections := make([]DetectionV3, len(googleDetectionResponse.Languages))
 
 	for i, googleDetection := range googleDetectionResponse.Languages {
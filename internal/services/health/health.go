@@ -0,0 +1,153 @@
+package health
+
+import (
+	"context"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	loggerutils "github.com/weiyuan-lane/google-translate-api/internal/utils/logger"
+)
+
+// Statuses of a check
+const (
+	StatusPending = "pending"
+	StatusOK      = "ok"
+	StatusFailing = "failing"
+)
+
+// Check is run in the background, and fails readiness when it is critical
+// and its last run failed
+type Check struct {
+	Name     string
+	Critical bool
+	Run      func(ctx context.Context) error
+}
+
+type Result struct {
+	Name      string
+	Critical  bool
+	Status    string
+	Error     string
+	CheckedAt time.Time
+	Latency   time.Duration
+}
+
+// Checker caches the results of its checks, so readiness probes never wait
+// on an upstream call
+type Checker struct {
+	checks   []Check
+	interval time.Duration
+	timeout  time.Duration
+	logger   *loggerutils.Logger
+
+	mutex   sync.RWMutex
+	results map[string]Result
+}
+
+func NewChecker(checks []Check, interval, timeout time.Duration, logger *loggerutils.Logger) *Checker {
+	results := map[string]Result{}
+	for _, check := range checks {
+		results[check.Name] = Result{
+			Name:     check.Name,
+			Critical: check.Critical,
+			Status:   StatusPending,
+		}
+	}
+
+	return &Checker{
+		checks:   checks,
+		interval: interval,
+		timeout:  timeout,
+		logger:   logger,
+		results:  results,
+	}
+}
+
+// Start runs every check right away, then each interval until ctx is done
+func (c *Checker) Start(ctx context.Context) {
+	for _, check := range c.checks {
+		go func(check Check) {
+			ticker := time.NewTicker(c.interval)
+			defer ticker.Stop()
+
+			for {
+				c.run(ctx, check)
+
+				select {
+				case <-ctx.Done():
+					return
+				case <-ticker.C:
+				}
+			}
+		}(check)
+	}
+}
+
+// Ready is false until every critical check has passed on its last run
+func (c *Checker) Ready() bool {
+	c.mutex.RLock()
+	defer c.mutex.RUnlock()
+
+	for _, result := range c.results {
+		if result.Critical && result.Status != StatusOK {
+			return false
+		}
+	}
+
+	return true
+}
+
+// Results are sorted by name
+func (c *Checker) Results() []Result {
+	c.mutex.RLock()
+	defer c.mutex.RUnlock()
+
+	results := make([]Result, 0, len(c.results))
+	for _, result := range c.results {
+		results = append(results, result)
+	}
+	sort.Slice(results, func(i, j int) bool {
+		return results[i].Name < results[j].Name
+	})
+
+	return results
+}
+
+func (c *Checker) run(ctx context.Context, check Check) {
+	checkCtx, cancel := context.WithTimeout(ctx, c.timeout)
+	defer cancel()
+
+	start := time.Now()
+	err := check.Run(checkCtx)
+	if ctx.Err() != nil {
+		return
+	}
+
+	result := Result{
+		Name:      check.Name,
+		Critical:  check.Critical,
+		Status:    StatusOK,
+		CheckedAt: start,
+		Latency:   time.Since(start),
+	}
+	if err != nil {
+		result.Status = StatusFailing
+		result.Error = err.Error()
+	}
+
+	c.mutex.Lock()
+	previous := c.results[check.Name]
+	c.results[check.Name] = result
+	c.mutex.Unlock()
+
+	// Only changes are logged, not every run
+	if previous.Status != result.Status {
+		c.logger.Info("Readiness check is "+result.Status, map[string]string{
+			"check":    check.Name,
+			"critical": strconv.FormatBool(check.Critical),
+			"error":    result.Error,
+		})
+	}
+}
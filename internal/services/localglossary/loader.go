@@ -0,0 +1,204 @@
+package localglossary
+
+import (
+	"encoding/csv"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// TermSet holds the equivalent terms of a single glossary entry, keyed by
+// the normalized language tag of each term (eg. "en", "zh-TW"), so regional
+// variants keep their own terms
+type TermSet map[string]string
+
+type tmxDocument struct {
+	Body struct {
+		TranslationUnits []struct {
+			Variants []struct {
+				XMLLang string `xml:"http://www.w3.org/XML/1998/namespace lang,attr"`
+				Lang    string `xml:"lang,attr"`
+				Segment string `xml:"seg"`
+			} `xml:"tuv"`
+		} `xml:"tu"`
+	} `xml:"body"`
+}
+
+// LoadDir reads every .tmx and .csv file in dir, in lexical order
+func LoadDir(dir string) ([]TermSet, error) {
+	dirEntries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, fmt.Errorf("read local glossary dir %q: %w", dir, err)
+	}
+
+	fileNames := []string{}
+	for _, dirEntry := range dirEntries {
+		if dirEntry.IsDir() {
+			continue
+		}
+
+		switch strings.ToLower(filepath.Ext(dirEntry.Name())) {
+		case ".tmx", ".csv":
+			fileNames = append(fileNames, dirEntry.Name())
+		}
+	}
+	sort.Strings(fileNames)
+
+	termSets := []TermSet{}
+	for _, fileName := range fileNames {
+		fileTermSets, err := LoadFile(filepath.Join(dir, fileName))
+		if err != nil {
+			return nil, err
+		}
+
+		termSets = append(termSets, fileTermSets...)
+	}
+
+	return termSets, nil
+}
+
+// LoadFile reads a single TMX or CSV glossary file, picking the format from
+// the file extension
+func LoadFile(path string) ([]TermSet, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("open local glossary file %q: %w", path, err)
+	}
+	defer file.Close()
+
+	var termSets []TermSet
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".tmx":
+		termSets, err = ParseTMX(file)
+	case ".csv":
+		termSets, err = ParseCSV(file)
+	default:
+		err = fmt.Errorf("unsupported file extension")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("parse local glossary file %q: %w", path, err)
+	}
+
+	return termSets, nil
+}
+
+// ParseTMX reads translation units in the same layout as
+// tools/sample_glossary.tmx, one term set per <tu>
+func ParseTMX(r io.Reader) ([]TermSet, error) {
+	document := tmxDocument{}
+	if err := xml.NewDecoder(r).Decode(&document); err != nil {
+		return nil, err
+	}
+
+	termSets := []TermSet{}
+	for _, translationUnit := range document.Body.TranslationUnits {
+		termSet := TermSet{}
+		for _, variant := range translationUnit.Variants {
+			lang := variant.XMLLang
+			if lang == "" {
+				lang = variant.Lang
+			}
+
+			addTerm(termSet, lang, variant.Segment)
+		}
+
+		if len(termSet) > 1 {
+			termSets = append(termSets, termSet)
+		}
+	}
+
+	return termSets, nil
+}
+
+// ParseCSV reads an equivalent term set CSV, where the header row holds the
+// language code of each column
+func ParseCSV(r io.Reader) ([]TermSet, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+
+	records, err := reader.ReadAll()
+	if err != nil {
+		return nil, err
+	}
+
+	if len(records) == 0 {
+		return []TermSet{}, nil
+	}
+
+	header := records[0]
+	if len(header) < 2 {
+		return nil, fmt.Errorf("header row needs at least two language codes")
+	}
+
+	termSets := []TermSet{}
+	for _, record := range records[1:] {
+		termSet := TermSet{}
+		for i, term := range record {
+			if i >= len(header) {
+				break
+			}
+
+			addTerm(termSet, header[i], term)
+		}
+
+		if len(termSet) > 1 {
+			termSets = append(termSets, termSet)
+		}
+	}
+
+	return termSets, nil
+}
+
+func (t TermSet) languages() []string {
+	langs := make([]string, 0, len(t))
+	for lang := range t {
+		langs = append(langs, lang)
+	}
+	sort.Strings(langs)
+
+	return langs
+}
+
+// Term is the term for locale, or else the term of its base language, eg.
+// "pt" for "pt-BR". A locale without a region, eg. "pt", falls back to the
+// first of its regional terms.
+func (t TermSet) Term(locale string) (string, bool) {
+	return t.term(NormalizeLanguage(locale))
+}
+
+// term takes a normalized tag
+func (t TermSet) term(tag string) (string, bool) {
+	if term, ok := t[tag]; ok {
+		return term, true
+	}
+
+	base := tagBase(tag)
+	if term, ok := t[base]; ok {
+		return term, true
+	}
+	if base != tag {
+		return "", false
+	}
+
+	for _, lang := range t.languages() {
+		if tagBase(lang) == base {
+			return t[lang], true
+		}
+	}
+
+	return "", false
+}
+
+func addTerm(termSet TermSet, lang, term string) {
+	lang = NormalizeLanguage(lang)
+	term = strings.TrimSpace(term)
+	if lang == "" || term == "" {
+		return
+	}
+
+	termSet[lang] = term
+}
@@ -0,0 +1,328 @@
+package localglossary
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"golang.org/x/text/language"
+)
+
+// Placeholders are restored case-insensitively and with any whitespace the
+// translation backend may have inserted around the index
+var placeholderPattern = regexp.MustCompile(`(?i)__\s*lg\s*(\d+)\s*__`)
+
+type Options struct {
+	CaseSensitive     bool
+	WholeWord         bool
+	LongestMatchFirst bool
+}
+
+type Engine struct {
+	options  Options
+	termSets []TermSet
+}
+
+type AppliedTerm struct {
+	SourceTerm string
+	TargetTerm string
+	Count      int
+}
+
+type ProtectedText struct {
+	Text         string
+	placeholders []placeholder
+}
+
+type placeholder struct {
+	sourceTerm string
+	targetTerm string
+}
+
+// termPair groups source terms sharing the same matching key. When matching
+// case-insensitively, "Grab" and "grab" share a pair but each keep their own
+// target term through variants.
+type termPair struct {
+	sourceTerm string
+	targetTerm string
+	variants   map[string]string
+	pattern    *regexp.Regexp
+}
+
+type match struct {
+	start     int
+	end       int
+	pairIndex int
+}
+
+func New(termSets []TermSet, options Options) *Engine {
+	return &Engine{
+		options:  options,
+		termSets: termSets,
+	}
+}
+
+func NewFromDir(dir string, options Options) (*Engine, error) {
+	termSets, err := LoadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	return New(termSets, options), nil
+}
+
+func (e *Engine) TermSetCount() int {
+	return len(e.termSets)
+}
+
+// Protect swaps every source term in text that has a target term for
+// targetLocale with a placeholder. An empty sourceLocale matches terms from
+// any language other than the target.
+func (e *Engine) Protect(text, sourceLocale, targetLocale string) ProtectedText {
+	pairs := e.termPairs(NormalizeLanguage(sourceLocale), NormalizeLanguage(targetLocale))
+	if len(pairs) == 0 {
+		return ProtectedText{Text: text}
+	}
+
+	matches := []match{}
+	for pairIndex, pair := range pairs {
+		for _, loc := range pair.pattern.FindAllStringIndex(text, -1) {
+			if e.options.WholeWord && !isWholeWord(text, loc[0], loc[1]) {
+				continue
+			}
+
+			if overlapsAny(matches, loc[0], loc[1]) {
+				continue
+			}
+
+			matches = append(matches, match{
+				start:     loc[0],
+				end:       loc[1],
+				pairIndex: pairIndex,
+			})
+		}
+	}
+
+	sort.Slice(matches, func(i, j int) bool {
+		return matches[i].start < matches[j].start
+	})
+
+	protectedText := ProtectedText{}
+	placeholderIndexes := map[placeholder]int{}
+	builder := strings.Builder{}
+	cursor := 0
+	for _, m := range matches {
+		pair := pairs[m.pairIndex]
+		term := placeholder{
+			sourceTerm: pair.sourceTerm,
+			targetTerm: pair.targetTerm,
+		}
+		if variantTarget, ok := pair.variants[text[m.start:m.end]]; ok {
+			term = placeholder{
+				sourceTerm: text[m.start:m.end],
+				targetTerm: variantTarget,
+			}
+		}
+
+		placeholderIndex, ok := placeholderIndexes[term]
+		if !ok {
+			placeholderIndex = len(protectedText.placeholders)
+			placeholderIndexes[term] = placeholderIndex
+			protectedText.placeholders = append(protectedText.placeholders, term)
+		}
+
+		builder.WriteString(text[cursor:m.start])
+		builder.WriteString(fmt.Sprintf("__LG%d__", placeholderIndex))
+		cursor = m.end
+	}
+	builder.WriteString(text[cursor:])
+	protectedText.Text = builder.String()
+
+	return protectedText
+}
+
+// Restore swaps placeholders in translatedText back to their target terms,
+// reporting how often each term was applied
+func (p ProtectedText) Restore(translatedText string) (string, []AppliedTerm) {
+	if len(p.placeholders) == 0 {
+		return translatedText, []AppliedTerm{}
+	}
+
+	counts := make([]int, len(p.placeholders))
+	restoredText := placeholderPattern.ReplaceAllStringFunc(translatedText, func(token string) string {
+		index, err := strconv.Atoi(placeholderPattern.FindStringSubmatch(token)[1])
+		if err != nil || index >= len(p.placeholders) {
+			return token
+		}
+
+		counts[index]++
+		return p.placeholders[index].targetTerm
+	})
+
+	appliedTerms := []AppliedTerm{}
+	for i, placeholder := range p.placeholders {
+		if counts[i] == 0 {
+			continue
+		}
+
+		appliedTerms = append(appliedTerms, AppliedTerm{
+			SourceTerm: placeholder.sourceTerm,
+			TargetTerm: placeholder.targetTerm,
+			Count:      counts[i],
+		})
+	}
+
+	return restoredText, appliedTerms
+}
+
+// termPairs takes normalized tags. Without a source, the terms of every
+// language besides that of the target are source terms.
+func (e *Engine) termPairs(sourceTag, targetTag string) []termPair {
+	if targetTag == "" {
+		return []termPair{}
+	}
+
+	pairs := []termPair{}
+	pairIndexes := map[string]int{}
+	for _, termSet := range e.termSets {
+		targetTerm, ok := termSet.term(targetTag)
+		if !ok {
+			continue
+		}
+
+		sourceTerms := []string{}
+		if sourceTag != "" {
+			if sourceTerm, ok := termSet.term(sourceTag); ok {
+				sourceTerms = append(sourceTerms, sourceTerm)
+			}
+		} else {
+			for _, lang := range termSet.languages() {
+				if tagBase(lang) != tagBase(targetTag) {
+					sourceTerms = append(sourceTerms, termSet[lang])
+				}
+			}
+		}
+
+		for _, sourceTerm := range sourceTerms {
+			key := sourceTerm
+			expr := regexp.QuoteMeta(sourceTerm)
+			if !e.options.CaseSensitive {
+				key = strings.ToLower(sourceTerm)
+				expr = "(?i)" + expr
+			}
+
+			if pairIndex, ok := pairIndexes[key]; ok {
+				if _, ok := pairs[pairIndex].variants[sourceTerm]; !ok {
+					pairs[pairIndex].variants[sourceTerm] = targetTerm
+				}
+				continue
+			}
+
+			pairIndexes[key] = len(pairs)
+			pairs = append(pairs, termPair{
+				sourceTerm: sourceTerm,
+				targetTerm: targetTerm,
+				variants:   map[string]string{sourceTerm: targetTerm},
+				pattern:    regexp.MustCompile(expr),
+			})
+		}
+	}
+
+	if e.options.LongestMatchFirst {
+		sort.SliceStable(pairs, func(i, j int) bool {
+			return utf8.RuneCountInString(pairs[i].sourceTerm) > utf8.RuneCountInString(pairs[j].sourceTerm)
+		})
+	}
+
+	return pairs
+}
+
+// ContainsWord reports whether text holds term as a whole word, as matched
+// by Protect with WholeWord set. Callers lowercase both to ignore case.
+func ContainsWord(text, term string) bool {
+	if term == "" {
+		return false
+	}
+
+	for offset := 0; offset < len(text); {
+		index := strings.Index(text[offset:], term)
+		if index < 0 {
+			return false
+		}
+
+		start := offset + index
+		if isWholeWord(text, start, start+len(term)) {
+			return true
+		}
+
+		_, size := utf8.DecodeRuneInString(text[start:])
+		offset = start + size
+	}
+
+	return false
+}
+
+// Scripts written without spaces have no word boundaries to check against
+func isWholeWord(text string, start, end int) bool {
+	firstRune, _ := utf8.DecodeRuneInString(text[start:])
+	if start > 0 && !isUnsegmentedScript(firstRune) {
+		previousRune, _ := utf8.DecodeLastRuneInString(text[:start])
+		if isWordRune(previousRune) {
+			return false
+		}
+	}
+
+	lastRune, _ := utf8.DecodeLastRuneInString(text[:end])
+	if end < len(text) && !isUnsegmentedScript(lastRune) {
+		nextRune, _ := utf8.DecodeRuneInString(text[end:])
+		if isWordRune(nextRune) {
+			return false
+		}
+	}
+
+	return true
+}
+
+func isWordRune(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsDigit(r) || r == '_'
+}
+
+func isUnsegmentedScript(r rune) bool {
+	return unicode.In(r, unicode.Han, unicode.Hiragana, unicode.Katakana, unicode.Thai, unicode.Lao, unicode.Khmer, unicode.Myanmar)
+}
+
+func overlapsAny(matches []match, start, end int) bool {
+	for _, m := range matches {
+		if start < m.end && m.start < end {
+			return true
+		}
+	}
+
+	return false
+}
+
+// NormalizeLanguage turns a locale into the language tag glossary terms are
+// keyed by, eg. "zh-cn" into "zh-CN"
+func NormalizeLanguage(locale string) string {
+	locale = strings.TrimSpace(locale)
+	if locale == "" {
+		return ""
+	}
+
+	tag, err := language.Parse(locale)
+	if err != nil {
+		return strings.ToLower(locale)
+	}
+
+	return tag.String()
+}
+
+// tagBase is the language code of a normalized tag, which comes first
+func tagBase(tag string) string {
+	base, _, _ := strings.Cut(tag, "-")
+	return base
+}
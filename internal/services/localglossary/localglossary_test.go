@@ -0,0 +1,109 @@
+package localglossary
+
+import (
+	"strings"
+	"testing"
+)
+
+const regionalCSV = `en,zh-CN,zh-TW,pt,pt-PT
+software,软件,軟體,software,software
+bus,公交车,公車,ônibus,autocarro
+`
+
+func TestRegionalVariantsKeepTheirTerms(t *testing.T) {
+	termSets, err := ParseCSV(strings.NewReader(regionalCSV))
+	if err != nil {
+		t.Fatal(err)
+	}
+	engine := New(termSets, Options{WholeWord: true})
+
+	tests := []struct {
+		targetLocale string
+		want         string
+	}{
+		{"zh-CN", "软件 公交车"},
+		{"zh-cn", "软件 公交车"},
+		{"zh-TW", "軟體 公車"},
+		{"pt-PT", "software autocarro"},
+		// No term for the exact tag, so that of the base language
+		{"pt-BR", "software ônibus"},
+		// No term for the base language, so the first regional one
+		{"zh", "软件 公交车"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.targetLocale, func(t *testing.T) {
+			protected := engine.Protect("software bus", "en", tt.targetLocale)
+			if got, _ := protected.Restore(protected.Text); got != tt.want {
+				t.Errorf("Protect() then Restore() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSourceTermsExcludeTheTargetLanguage(t *testing.T) {
+	termSets, err := ParseCSV(strings.NewReader(regionalCSV))
+	if err != nil {
+		t.Fatal(err)
+	}
+	engine := New(termSets, Options{})
+
+	// 軟體 is also Chinese, so not a source term for a zh-CN target
+	protected := engine.Protect("軟體 bus", "", "zh-CN")
+	if got, _ := protected.Restore(protected.Text); got != "軟體 公交车" {
+		t.Errorf("Protect() then Restore() = %q, want %q", got, "軟體 公交车")
+	}
+}
+
+func TestTerm(t *testing.T) {
+	termSet := TermSet{}
+	addTerm(termSet, "en-us", "color")
+	addTerm(termSet, "en-GB", "colour")
+	addTerm(termSet, "fr", "couleur")
+
+	tests := []struct {
+		locale string
+		want   string
+		wantOK bool
+	}{
+		{"en-US", "color", true},
+		{"en-GB", "colour", true},
+		{"en", "colour", true},
+		{"en-AU", "", false},
+		{"fr-CA", "couleur", true},
+		{"de", "", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.locale, func(t *testing.T) {
+			if got, ok := termSet.Term(tt.locale); got != tt.want || ok != tt.wantOK {
+				t.Errorf("Term(%q) = %q, %v, want %q, %v", tt.locale, got, ok, tt.want, tt.wantOK)
+			}
+		})
+	}
+}
+
+func TestContainsWord(t *testing.T) {
+	tests := []struct {
+		text string
+		term string
+		want bool
+	}{
+		{"the cat sat", "cat", true},
+		{"cat", "cat", true},
+		{"a category", "cat", false},
+		{"bobcat", "cat", false},
+		{"category and cat", "cat", true},
+		{"cat_food", "cat", false},
+		{"cat.", "cat", true},
+		{"我喜欢猫咪", "猫", true},
+		{"", "cat", false},
+		{"cat", "", false},
+	}
+
+	for _, tt := range tests {
+		if got := ContainsWord(tt.text, tt.term); got != tt.want {
+			t.Errorf("ContainsWord(%q, %q) = %v, want %v", tt.text, tt.term, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,155 @@
+package routing
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	loggerutils "github.com/weiyuan-lane/google-translate-api/internal/utils/logger"
+)
+
+const (
+	providerGoogle = "google"
+	backendV2      = "v2"
+	backendV3      = "v3"
+)
+
+// Router holds the rules loaded from a file, and swaps them when the file
+// changes
+type Router struct {
+	path           string
+	knownProviders []string
+
+	mutex    sync.RWMutex
+	rules    Rules
+	modTime  time.Time
+	loadedAt time.Time
+}
+
+// Decision is where a request goes. Matched is false when no rule matched,
+// in which case the request keeps its own settings.
+type Decision struct {
+	Matched   bool
+	RuleIndex int
+	Rule      Rule
+}
+
+func NewRouter(path string, knownProviders []string) (*Router, error) {
+	router := &Router{
+		path:           path,
+		knownProviders: knownProviders,
+	}
+
+	if err := router.Reload(); err != nil {
+		return nil, err
+	}
+
+	return router, nil
+}
+
+// Reload keeps the current rules when the file cannot be loaded
+func (r *Router) Reload() error {
+	rules, err := LoadRules(r.path, r.knownProviders)
+	if err != nil {
+		return err
+	}
+
+	modTime := time.Time{}
+	if fileInfo, err := os.Stat(r.path); err == nil {
+		modTime = fileInfo.ModTime()
+	}
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	r.rules = rules
+	r.modTime = modTime
+	r.loadedAt = time.Now()
+
+	return nil
+}
+
+// Watch reloads the rules whenever the modification time of the file
+// changes, until ctx is done
+func (r *Router) Watch(ctx context.Context, interval time.Duration, logger *loggerutils.Logger) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		fileInfo, err := os.Stat(r.path)
+		if err != nil || fileInfo.ModTime().Equal(r.ModTime()) {
+			continue
+		}
+
+		if err := r.Reload(); err != nil {
+			logger.Error(fmt.Sprintf("Keeping previous routing rules: %s", err.Error()))
+
+			// Only retry once the file changes again
+			r.mutex.Lock()
+			r.modTime = fileInfo.ModTime()
+			r.mutex.Unlock()
+			continue
+		}
+
+		logger.Info(fmt.Sprintf("Reloaded %d routing rules from %s", r.Len(), r.path))
+	}
+}
+
+func (r *Router) Match(sourceLocale, targetLocale, tenant string) Decision {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	for i, rule := range r.rules.Rules {
+		if rule.matches(sourceLocale, targetLocale, tenant) {
+			return Decision{
+				Matched:   true,
+				RuleIndex: i,
+				Rule:      rule,
+			}
+		}
+	}
+
+	return Decision{}
+}
+
+func (r *Router) Len() int {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	return len(r.rules.Rules)
+}
+
+func (r *Router) Path() string {
+	return r.path
+}
+
+func (r *Router) ModTime() time.Time {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	return r.modTime
+}
+
+func (r *Router) LoadedAt() time.Time {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	return r.loadedAt
+}
+
+// UsesV3 is true when the decision needs the V3 backend of the google
+// provider
+func (d Decision) UsesV3() bool {
+	return d.Rule.Backend == backendV3 || d.Rule.Model != "" || d.Rule.Glossary != ""
+}
+
+func (d Decision) IsGoogle() bool {
+	return d.Rule.Provider == providerGoogle
+}
@@ -0,0 +1,143 @@
+package routing
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/weiyuan-lane/google-translate-api/internal/utils/errorhandlers"
+)
+
+// Rules are evaluated in order, and the first rule matching a request
+// decides where it goes, eg.
+//
+//	{
+//	  "rules": [
+//	    {"name": "zh-custom", "source": "en", "target": "zh-*", "model": "my-custom-model", "glossary": "branding"},
+//	    {"name": "acme-deepl", "tenant": "acme", "target": "de", "provider": "deepl"},
+//	    {"name": "default", "source": "*", "target": "*", "backend": "v2"}
+//	  ]
+//	}
+//
+// Source and target patterns match locales case-insensitively, "*" matching
+// any locale and a trailing "*" any locale with that prefix (eg. "zh-*"
+// matches "zh-TW" and "zh"). An empty pattern is the same as "*". A source
+// pattern other than "*" never matches requests without a source locale.
+type Rules struct {
+	Rules []Rule `json:"rules"`
+}
+
+type Rule struct {
+	Name   string `json:"name"`
+	Source string `json:"source"`
+	Target string `json:"target"`
+	// Rules with a tenant only match requests of that tenant
+	Tenant string `json:"tenant"`
+
+	// Defaults to "google"
+	Provider string `json:"provider"`
+	// "v2" or "v3", for the google provider. A model or glossary implies v3.
+	Backend  string `json:"backend"`
+	Model    string `json:"model"`
+	Glossary string `json:"glossary"`
+}
+
+// LoadRules rejects rules naming a provider outside knownProviders, so a
+// typo fails at load time rather than on requests
+func LoadRules(path string, knownProviders []string) (Rules, error) {
+	rulesBytes, err := os.ReadFile(path)
+	if err != nil {
+		return Rules{}, errorhandlers.Wrap(
+			errorhandlers.ErrRoutingInvalidRules,
+			fmt.Sprintf("Routing rules could not be read: %s", err.Error()),
+		)
+	}
+
+	rules := Rules{}
+	if err := json.Unmarshal(rulesBytes, &rules); err != nil {
+		return Rules{}, errorhandlers.Wrap(
+			errorhandlers.ErrRoutingInvalidRules,
+			fmt.Sprintf("Routing rules %s are not valid JSON: %s", path, err.Error()),
+		)
+	}
+
+	problems := []string{}
+	for i := range rules.Rules {
+		rule := &rules.Rules[i]
+		if rule.Name == "" {
+			rule.Name = fmt.Sprintf("rules[%d]", i)
+		}
+		rule.Provider = strings.ToLower(rule.Provider)
+		if rule.Provider == "" {
+			rule.Provider = providerGoogle
+		}
+
+		if !containsString(knownProviders, rule.Provider) {
+			problems = append(problems, fmt.Sprintf("rules[%d].provider %q is not configured", i, rule.Provider))
+		}
+
+		if rule.Backend != "" && rule.Backend != backendV2 && rule.Backend != backendV3 {
+			problems = append(problems, fmt.Sprintf("rules[%d].backend must be %q or %q", i, backendV2, backendV3))
+		}
+
+		if rule.Provider != providerGoogle && (rule.Backend != "" || rule.Model != "" || rule.Glossary != "") {
+			problems = append(problems, fmt.Sprintf("rules[%d] can only set \"backend\", \"model\" and \"glossary\" for the %q provider", i, providerGoogle))
+		}
+
+		if rule.Backend == backendV2 && (rule.Model != "" || rule.Glossary != "") {
+			problems = append(problems, fmt.Sprintf("rules[%d] needs the %q backend for \"model\" and \"glossary\"", i, backendV3))
+		}
+
+		for field, pattern := range map[string]string{"source": rule.Source, "target": rule.Target} {
+			if strings.Contains(strings.TrimSuffix(pattern, "*"), "*") {
+				problems = append(problems, fmt.Sprintf("rules[%d].%s %q can only have \"*\" at the end", i, field, pattern))
+			}
+		}
+	}
+
+	if len(problems) > 0 {
+		return Rules{}, errorhandlers.Wrap(
+			errorhandlers.ErrRoutingInvalidRules,
+			fmt.Sprintf("Routing rules %s are invalid: %s", path, strings.Join(problems, "; ")),
+		)
+	}
+
+	return rules, nil
+}
+
+func (r Rule) matches(sourceLocale, targetLocale, tenant string) bool {
+	if r.Tenant != "" && r.Tenant != tenant {
+		return false
+	}
+
+	return patternMatches(r.Source, sourceLocale) && patternMatches(r.Target, targetLocale)
+}
+
+func patternMatches(pattern, locale string) bool {
+	pattern = strings.ToLower(pattern)
+	locale = strings.ToLower(locale)
+
+	switch {
+	case pattern == "" || pattern == "*":
+		return true
+	case locale == "":
+		return false
+	case strings.HasSuffix(pattern, "*"):
+		// "zh-*" also matches a plain "zh"
+		prefix := strings.TrimSuffix(pattern, "*")
+		return strings.HasPrefix(locale, prefix) || locale == strings.TrimSuffix(prefix, "-")
+	default:
+		return locale == pattern
+	}
+}
+
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+
+	return false
+}
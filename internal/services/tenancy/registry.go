@@ -0,0 +1,80 @@
+package tenancy
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/weiyuan-lane/google-translate-api/internal/services/googletranslatewrapper"
+	"github.com/weiyuan-lane/google-translate-api/internal/utils/errorhandlers"
+)
+
+// Backends are the Google translate wrappers serving one tenant
+type Backends struct {
+	V2 googletranslatewrapper.TranslateV2Wrapper
+	V3 googletranslatewrapper.TranslateV3Wrapper
+}
+
+// BackendFactory creates the clients and wrappers of a tenant
+type BackendFactory func(tenant Tenant) (Backends, error)
+
+// Registry builds the backends of a tenant on its first request, and keeps
+// them for the requests after
+type Registry struct {
+	tenants map[string]Tenant
+	factory BackendFactory
+
+	mutex    sync.Mutex
+	backends map[string]Backends
+}
+
+func NewRegistry(tenants Tenants, factory BackendFactory) *Registry {
+	registry := &Registry{
+		tenants:  map[string]Tenant{},
+		factory:  factory,
+		backends: map[string]Backends{},
+	}
+
+	for _, tenant := range tenants.Tenants {
+		registry.tenants[tenant.ID] = tenant
+	}
+
+	return registry
+}
+
+func (r *Registry) Len() int {
+	return len(r.tenants)
+}
+
+func (r *Registry) Lookup(id string) (Tenant, error) {
+	tenant, ok := r.tenants[id]
+	if !ok {
+		return Tenant{}, errorhandlers.Wrap(
+			errorhandlers.ErrTenantNotFound,
+			fmt.Sprintf("Tenant %q does not exist", id),
+		)
+	}
+
+	return tenant, nil
+}
+
+// Backends holds the lock while building, so concurrent first requests of a
+// tenant do not create its clients twice
+func (r *Registry) Backends(tenant Tenant) (Backends, error) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	if backends, ok := r.backends[tenant.ID]; ok {
+		return backends, nil
+	}
+
+	backends, err := r.factory(tenant)
+	if err != nil {
+		return Backends{}, errorhandlers.Wrap(
+			errorhandlers.ErrTenantBackendInitFailed,
+			fmt.Sprintf("Google translate clients of tenant %q could not be created: %s", tenant.ID, err.Error()),
+		)
+	}
+	r.backends[tenant.ID] = backends
+
+	return backends, nil
+}
@@ -0,0 +1,154 @@
+package tenancy
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/weiyuan-lane/google-translate-api/internal/services/usage"
+	"github.com/weiyuan-lane/google-translate-api/internal/utils/errorhandlers"
+	"github.com/weiyuan-lane/google-translate-api/internal/utils/ratelimit"
+)
+
+// Tenants describe the teams sharing a deployment, eg.
+//
+//	{
+//	  "tenants": [
+//	    {
+//	      "id": "acme",
+//	      "v3_project_id": "acme-translate",
+//	      "v3_location": "europe-west1",
+//	      "v2_api_key_env": "ACME_GOOGLE_TRANSLATE_V2_API_KEY",
+//	      "default_glossary": "acme-branding",
+//	      "allowed_languages": ["en", "de", "zh"],
+//	      "rate_limits": {"requests_per_minute": 600, "characters_per_minute": 100000},
+//	      "monthly_budget": {"soft_characters": 40000000, "hard_characters": 50000000}
+//	    }
+//	  ]
+//	}
+//
+// Unset fields fall back to the settings of the deployment.
+type Tenants struct {
+	Tenants []Tenant `json:"tenants"`
+}
+
+type Tenant struct {
+	ID          string `json:"id"`
+	V3ProjectID string `json:"v3_project_id"`
+	V3Location  string `json:"v3_location"`
+	// Name of the env var holding the V2 API key, so the key stays out of
+	// the tenants file
+	V2APIKeyEnv string `json:"v2_api_key_env"`
+	// Used by V3 translations that give a source locale but no glossary
+	DefaultGlossary string `json:"default_glossary"`
+	// Locales the tenant may translate from and to, all when empty. A
+	// language allows its regional variants, eg. "zh" allows "zh-TW".
+	AllowedLanguages []string   `json:"allowed_languages"`
+	RateLimits       RateLimits `json:"rate_limits"`
+	// Replaces the monthly budget of the deployment, unless all are 0
+	MonthlyBudget usage.Budget `json:"monthly_budget"`
+}
+
+// RateLimits of a tenant apply to its requests on top of the limits of the
+// deployment, unless all are 0
+type RateLimits struct {
+	RequestsPerMinute   int `json:"requests_per_minute"`
+	CharactersPerMinute int `json:"characters_per_minute"`
+	MaxConcurrent       int `json:"max_concurrent"`
+	MaxWaitMillis       int `json:"max_wait_ms"`
+}
+
+const defaultMaxWaitMillis = 5000
+
+// Tenant IDs are also the tenant segment of glossary IDs, so they cannot
+// hold the "--" that ends it, nor end with "-" or "_"
+var tenantIDPattern = regexp.MustCompile(`^[a-z0-9]+([_-][a-z0-9]+)*$`)
+
+func LoadTenants(path string) (Tenants, error) {
+	tenantsBytes, err := os.ReadFile(path)
+	if err != nil {
+		return Tenants{}, errorhandlers.Wrap(
+			errorhandlers.ErrTenantInvalidTenants,
+			fmt.Sprintf("Tenants could not be read: %s", err.Error()),
+		)
+	}
+
+	tenants := Tenants{}
+	if err := json.Unmarshal(tenantsBytes, &tenants); err != nil {
+		return Tenants{}, errorhandlers.Wrap(
+			errorhandlers.ErrTenantInvalidTenants,
+			fmt.Sprintf("Tenants in %s are not valid JSON: %s", path, err.Error()),
+		)
+	}
+
+	problems := []string{}
+	seenIDs := map[string]bool{}
+	for i, tenant := range tenants.Tenants {
+		if !tenantIDPattern.MatchString(tenant.ID) || seenIDs[tenant.ID] {
+			problems = append(problems, fmt.Sprintf("tenants[%d].id %q must be unique, lowercase letters and digits, separated by single \"-\" or \"_\"", i, tenant.ID))
+		}
+		seenIDs[tenant.ID] = true
+
+		if tenant.V2APIKeyEnv != "" && os.Getenv(tenant.V2APIKeyEnv) == "" {
+			problems = append(problems, fmt.Sprintf("tenants[%d].v2_api_key_env %q is not set", i, tenant.V2APIKeyEnv))
+		}
+
+		limits := tenant.RateLimits
+		if limits.RequestsPerMinute < 0 || limits.CharactersPerMinute < 0 || limits.MaxConcurrent < 0 || limits.MaxWaitMillis < 0 {
+			problems = append(problems, fmt.Sprintf("tenants[%d].rate_limits cannot be negative", i))
+		}
+
+		if tenant.MonthlyBudget.SoftCharacters < 0 || tenant.MonthlyBudget.HardCharacters < 0 {
+			problems = append(problems, fmt.Sprintf("tenants[%d].monthly_budget cannot be negative", i))
+		}
+	}
+
+	if len(problems) > 0 {
+		return Tenants{}, errorhandlers.Wrap(
+			errorhandlers.ErrTenantInvalidTenants,
+			fmt.Sprintf("Tenants in %s are invalid: %s", path, strings.Join(problems, "; ")),
+		)
+	}
+
+	return tenants, nil
+}
+
+func (t Tenant) V2APIKey() string {
+	if t.V2APIKeyEnv == "" {
+		return ""
+	}
+
+	return os.Getenv(t.V2APIKeyEnv)
+}
+
+func (t Tenant) AllowsLanguage(locale string) bool {
+	if len(t.AllowedLanguages) == 0 {
+		return true
+	}
+
+	for _, allowed := range t.AllowedLanguages {
+		if strings.EqualFold(locale, allowed) || (len(locale) > len(allowed) &&
+			strings.EqualFold(locale[:len(allowed)], allowed) && locale[len(allowed)] == '-') {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (r RateLimits) Limits() ratelimit.Limits {
+	maxWaitMillis := r.MaxWaitMillis
+	if maxWaitMillis == 0 {
+		maxWaitMillis = defaultMaxWaitMillis
+	}
+
+	return ratelimit.Limits{
+		RequestsPerMinute:   r.RequestsPerMinute,
+		CharactersPerMinute: r.CharactersPerMinute,
+		MaxConcurrent:       r.MaxConcurrent,
+		MaxWait:             time.Duration(maxWaitMillis) * time.Millisecond,
+	}
+}
@@ -0,0 +1,110 @@
+package translationproviders
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/weiyuan-lane/google-translate-api/internal/services/googletranslatewrapper"
+	"github.com/weiyuan-lane/google-translate-api/internal/utils/errorhandlers"
+	"golang.org/x/text/language"
+)
+
+const ProviderDeepL = "deepl"
+
+const (
+	deepLBaseURL     = "https://api.deepl.com"
+	deepLFreeBaseURL = "https://api-free.deepl.com"
+)
+
+// DeepL calls a DeepL compatible API (https://www.deepl.com/docs-api)
+type DeepL struct {
+	baseURL    string
+	apiKey     string
+	httpClient *http.Client
+}
+
+type deepLTranslateRequest struct {
+	Text       []string `json:"text"`
+	TargetLang string   `json:"target_lang"`
+}
+
+type deepLTranslateResponse struct {
+	Translations []struct {
+		DetectedSourceLanguage string `json:"detected_source_language"`
+		Text                   string `json:"text"`
+	} `json:"translations"`
+}
+
+// NewDeepL uses a default client when httpClient is nil. Without a baseURL,
+// keys of the free plan (ending with ":fx") go to the free API.
+func NewDeepL(baseURL, apiKey string, httpClient *http.Client) DeepL {
+	if baseURL == "" {
+		baseURL = deepLBaseURL
+		if strings.HasSuffix(apiKey, ":fx") {
+			baseURL = deepLFreeBaseURL
+		}
+	}
+
+	return DeepL{
+		baseURL:    strings.TrimRight(baseURL, "/"),
+		apiKey:     apiKey,
+		httpClient: newHTTPClient(httpClient),
+	}
+}
+
+func (d DeepL) Name() string {
+	return ProviderDeepL
+}
+
+func (d DeepL) TranslateText(ctx context.Context, text string, targetLocale language.Tag) (googletranslatewrapper.Translation, error) {
+	targetLang, ok := deepLTargetLanguages.providerCode(targetLocale)
+	if !ok {
+		return googletranslatewrapper.Translation{}, errorhandlers.Wrap(
+			errorhandlers.ErrProviderUnsupportedLanguage,
+			fmt.Sprintf("DeepL does not support target locale %s", targetLocale.String()),
+		)
+	}
+
+	res := deepLTranslateResponse{}
+	err := postJSON(
+		ctx,
+		d.httpClient,
+		"DeepL",
+		d.baseURL+"/v2/translate",
+		http.Header{"Authorization": []string{"DeepL-Auth-Key " + d.apiKey}},
+		deepLTranslateRequest{
+			Text:       []string{text},
+			TargetLang: targetLang,
+		},
+		&res,
+		errorhandlers.ErrProviderTranslateErrResponse,
+	)
+	if err != nil {
+		return googletranslatewrapper.Translation{}, err
+	}
+
+	if len(res.Translations) == 0 {
+		return googletranslatewrapper.Translation{}, errorhandlers.Wrap(
+			errorhandlers.ErrProviderEmptyResponse,
+			"DeepL not returning any results",
+		)
+	}
+
+	return googletranslatewrapper.Translation{
+		TranslatedText: res.Translations[0].Text,
+		OriginalText:   text,
+		DetectedLang:   deepLTargetLanguages.tag(res.Translations[0].DetectedSourceLanguage),
+		TargetLang:     targetLocale,
+	}, nil
+}
+
+// DetectionsFromText is not offered by the DeepL API, which only reports the
+// detected language of a (billed) translation
+func (d DeepL) DetectionsFromText(ctx context.Context, text string) ([]googletranslatewrapper.Detection, error) {
+	return []googletranslatewrapper.Detection{}, errorhandlers.Wrap(
+		errorhandlers.ErrProviderDetectNotSupported,
+		"DeepL does not support language detection",
+	)
+}
@@ -0,0 +1,119 @@
+package translationproviders
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"testing"
+
+	"github.com/weiyuan-lane/google-translate-api/internal/services/googletranslatewrapper"
+	"github.com/weiyuan-lane/google-translate-api/internal/utils/errorhandlers"
+	"golang.org/x/text/language"
+)
+
+func TestDeepLTranslateText(t *testing.T) {
+	tests := []struct {
+		name         string
+		target       language.Tag
+		wantTarget   string
+		detected     string
+		wantDetected language.Tag
+	}{
+		{"base language", language.German, "DE", "EN", language.English},
+		{"english defaults to american", language.English, "EN-US", "DE", language.German},
+		{"british english", language.MustParse("en-GB"), "EN-GB", "DE", language.German},
+		{"traditional chinese", language.MustParse("zh-TW"), "ZH-HANT", "ZH", language.Chinese},
+		{"simplified chinese", language.MustParse("zh-CN"), "ZH-HANS", "ZH", language.Chinese},
+		{"portuguese defaults to european", language.Portuguese, "PT-PT", "EN", language.English},
+		{"norwegian", language.Norwegian, "NB", "EN", language.English},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := newStandIn(t, "/v2/translate", func(w http.ResponseWriter, r *http.Request) {
+				if auth := r.Header.Get("Authorization"); auth != "DeepL-Auth-Key secret" {
+					t.Errorf("Authorization = %q, want the DeepL key", auth)
+				}
+
+				req := deepLTranslateRequest{}
+				decodeBody(t, r, &req)
+				if len(req.Text) != 1 || req.Text[0] != "hello" || req.TargetLang != tt.wantTarget {
+					t.Errorf("request = %+v, want hello to %s", req, tt.wantTarget)
+				}
+
+				json.NewEncoder(w).Encode(map[string]interface{}{
+					"translations": []map[string]string{
+						{"detected_source_language": tt.detected, "text": "translated"},
+					},
+				})
+			})
+
+			translation, err := NewDeepL(server.URL, "secret", nil).TranslateText(context.Background(), "hello", tt.target)
+			if err != nil {
+				t.Fatalf("TranslateText() error = %v", err)
+			}
+
+			want := googletranslatewrapper.Translation{
+				TranslatedText: "translated",
+				OriginalText:   "hello",
+				DetectedLang:   tt.wantDetected,
+				TargetLang:     tt.target,
+			}
+			if translation != want {
+				t.Errorf("TranslateText() = %+v, want %+v", translation, want)
+			}
+		})
+	}
+}
+
+func TestDeepLUnsupportedTarget(t *testing.T) {
+	server := newStandIn(t, "/v2/translate", func(w http.ResponseWriter, r *http.Request) {
+		t.Error("unsupported target locale reached DeepL")
+	})
+
+	_, err := NewDeepL(server.URL, "secret", nil).TranslateText(context.Background(), "hello", language.MustParse("sw"))
+	if !errors.Is(err, errorhandlers.ErrProviderUnsupportedLanguage) {
+		t.Errorf("TranslateText() error = %v, want %v", err, errorhandlers.ErrProviderUnsupportedLanguage)
+	}
+}
+
+func TestDeepLDetectIsNotSupported(t *testing.T) {
+	_, err := NewDeepL("http://127.0.0.1:0", "secret", nil).DetectionsFromText(context.Background(), "hello")
+	if !errors.Is(err, errorhandlers.ErrProviderDetectNotSupported) {
+		t.Errorf("DetectionsFromText() error = %v, want %v", err, errorhandlers.ErrProviderDetectNotSupported)
+	}
+}
+
+func TestDeepLErrorStatuses(t *testing.T) {
+	for _, tt := range statusTests {
+		t.Run(strconv.Itoa(tt.status), func(t *testing.T) {
+			server := newStandIn(t, "/v2/translate", func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(tt.status)
+				w.Write([]byte(`{"message": "nope"}`))
+			})
+
+			_, err := NewDeepL(server.URL, "secret", nil).TranslateText(context.Background(), "hello", language.German)
+			assertProviderError(t, err, tt.status, tt.wantCode, tt.wantClass, "nope")
+		})
+	}
+}
+
+func TestNewDeepLPicksTheAPIOfTheKey(t *testing.T) {
+	tests := []struct {
+		baseURL string
+		apiKey  string
+		want    string
+	}{
+		{"", "key", deepLBaseURL},
+		{"", "key:fx", deepLFreeBaseURL},
+		{"http://localhost:8000/", "key:fx", "http://localhost:8000"},
+	}
+
+	for _, tt := range tests {
+		if got := NewDeepL(tt.baseURL, tt.apiKey, nil).baseURL; got != tt.want {
+			t.Errorf("NewDeepL(%q, %q) base URL = %q, want %q", tt.baseURL, tt.apiKey, got, tt.want)
+		}
+	}
+}
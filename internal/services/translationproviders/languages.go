@@ -0,0 +1,117 @@
+package translationproviders
+
+import (
+	"strings"
+
+	"golang.org/x/text/language"
+)
+
+// languageMap translates between BCP 47 tags and the codes of a provider.
+// Tags missing from toProvider fall back to their base language, formatted
+// by the provider.
+type languageMap struct {
+	toProvider   map[string]string
+	fromProvider map[string]string
+	format       func(base string) string
+	// Empty when every language is passed through to the provider
+	supported map[string]bool
+}
+
+var libreTranslateLanguages = languageMap{
+	toProvider: map[string]string{
+		"zh-Hans": "zh",
+		"zh-CN":   "zh",
+		"zh-SG":   "zh",
+		"zh-Hant": "zt",
+		"zh-TW":   "zt",
+		"zh-HK":   "zt",
+		"pt-BR":   "pb",
+	},
+	fromProvider: map[string]string{
+		"zh": "zh-CN",
+		"zt": "zh-TW",
+		"pb": "pt-BR",
+	},
+	format: strings.ToLower,
+}
+
+var deepLTargetLanguages = languageMap{
+	toProvider: map[string]string{
+		"en":      "EN-US",
+		"en-US":   "EN-US",
+		"en-GB":   "EN-GB",
+		"pt":      "PT-PT",
+		"pt-PT":   "PT-PT",
+		"pt-BR":   "PT-BR",
+		"zh":      "ZH-HANS",
+		"zh-Hans": "ZH-HANS",
+		"zh-CN":   "ZH-HANS",
+		"zh-SG":   "ZH-HANS",
+		"zh-Hant": "ZH-HANT",
+		"zh-TW":   "ZH-HANT",
+		"zh-HK":   "ZH-HANT",
+		"no":      "NB",
+	},
+	fromProvider: map[string]string{
+		"EN-US":   "en-US",
+		"EN-GB":   "en-GB",
+		"PT-PT":   "pt-PT",
+		"PT-BR":   "pt-BR",
+		"ZH":      "zh",
+		"ZH-HANS": "zh-CN",
+		"ZH-HANT": "zh-TW",
+		"NB":      "nb",
+	},
+	format: strings.ToUpper,
+	supported: toSet(
+		"AR", "BG", "CS", "DA", "DE", "EL", "EN-GB", "EN-US", "ES", "ET", "FI",
+		"FR", "HU", "ID", "IT", "JA", "KO", "LT", "LV", "NB", "NL", "PL",
+		"PT-BR", "PT-PT", "RO", "RU", "SK", "SL", "SV", "TR", "UK", "ZH-HANS",
+		"ZH-HANT",
+	),
+}
+
+// providerCode returns the provider code for tag, and false if the provider
+// is known not to support it
+func (m languageMap) providerCode(tag language.Tag) (string, bool) {
+	code, ok := m.toProvider[tag.String()]
+	if !ok {
+		base, _ := tag.Base()
+		code, ok = m.toProvider[base.String()]
+		if !ok {
+			code = m.format(base.String())
+		}
+	}
+
+	if len(m.supported) > 0 && !m.supported[code] {
+		return code, false
+	}
+
+	return code, true
+}
+
+// tag parses a provider code, returning language.Und for codes that cannot
+// be parsed
+func (m languageMap) tag(code string) language.Tag {
+	if mapped, ok := m.fromProvider[code]; ok {
+		code = mapped
+	} else if mapped, ok := m.fromProvider[strings.ToUpper(code)]; ok {
+		code = mapped
+	}
+
+	tag, err := language.Parse(code)
+	if err != nil {
+		return language.Und
+	}
+
+	return tag
+}
+
+func toSet(values ...string) map[string]bool {
+	set := map[string]bool{}
+	for _, value := range values {
+		set[value] = true
+	}
+
+	return set
+}
@@ -0,0 +1,141 @@
+package translationproviders
+
+import (
+	"context"
+	"net/http"
+	"strings"
+
+	"github.com/weiyuan-lane/google-translate-api/internal/services/googletranslatewrapper"
+	"github.com/weiyuan-lane/google-translate-api/internal/utils/errorhandlers"
+	"golang.org/x/text/language"
+)
+
+const ProviderLibreTranslate = "libretranslate"
+
+// LibreTranslate calls a LibreTranslate compatible API
+// (https://github.com/LibreTranslate/LibreTranslate)
+type LibreTranslate struct {
+	baseURL    string
+	apiKey     string
+	httpClient *http.Client
+}
+
+type libreTranslateTranslateRequest struct {
+	Q      string `json:"q"`
+	Source string `json:"source"`
+	Target string `json:"target"`
+	Format string `json:"format"`
+	APIKey string `json:"api_key,omitempty"`
+}
+
+type libreTranslateDetection struct {
+	// Percentage, from 0 to 100
+	Confidence float64 `json:"confidence"`
+	Language   string  `json:"language"`
+}
+
+type libreTranslateTranslateResponse struct {
+	TranslatedText   *string                  `json:"translatedText"`
+	DetectedLanguage *libreTranslateDetection `json:"detectedLanguage"`
+}
+
+type libreTranslateDetectRequest struct {
+	Q      string `json:"q"`
+	APIKey string `json:"api_key,omitempty"`
+}
+
+// NewLibreTranslate uses a default client when httpClient is nil
+func NewLibreTranslate(baseURL, apiKey string, httpClient *http.Client) LibreTranslate {
+	return LibreTranslate{
+		baseURL:    strings.TrimRight(baseURL, "/"),
+		apiKey:     apiKey,
+		httpClient: newHTTPClient(httpClient),
+	}
+}
+
+func (l LibreTranslate) Name() string {
+	return ProviderLibreTranslate
+}
+
+func (l LibreTranslate) TranslateText(ctx context.Context, text string, targetLocale language.Tag) (googletranslatewrapper.Translation, error) {
+	target, _ := libreTranslateLanguages.providerCode(targetLocale)
+
+	res := libreTranslateTranslateResponse{}
+	err := postJSON(
+		ctx,
+		l.httpClient,
+		"LibreTranslate",
+		l.baseURL+"/translate",
+		http.Header{},
+		libreTranslateTranslateRequest{
+			Q:      text,
+			Source: "auto",
+			Target: target,
+			Format: "text",
+			APIKey: l.apiKey,
+		},
+		&res,
+		errorhandlers.ErrProviderTranslateErrResponse,
+	)
+	if err != nil {
+		return googletranslatewrapper.Translation{}, err
+	}
+
+	if res.TranslatedText == nil {
+		return googletranslatewrapper.Translation{}, errorhandlers.Wrap(
+			errorhandlers.ErrProviderEmptyResponse,
+			"LibreTranslate not returning any results",
+		)
+	}
+
+	detectedLang := language.Und
+	if res.DetectedLanguage != nil {
+		detectedLang = libreTranslateLanguages.tag(res.DetectedLanguage.Language)
+	}
+
+	return googletranslatewrapper.Translation{
+		TranslatedText: *res.TranslatedText,
+		OriginalText:   text,
+		DetectedLang:   detectedLang,
+		TargetLang:     targetLocale,
+	}, nil
+}
+
+func (l LibreTranslate) DetectionsFromText(ctx context.Context, text string) ([]googletranslatewrapper.Detection, error) {
+	res := []libreTranslateDetection{}
+	err := postJSON(
+		ctx,
+		l.httpClient,
+		"LibreTranslate",
+		l.baseURL+"/detect",
+		http.Header{},
+		libreTranslateDetectRequest{
+			Q:      text,
+			APIKey: l.apiKey,
+		},
+		&res,
+		errorhandlers.ErrProviderDetectErrResponse,
+	)
+	if err != nil {
+		return []googletranslatewrapper.Detection{}, err
+	}
+
+	if len(res) == 0 {
+		return []googletranslatewrapper.Detection{}, errorhandlers.Wrap(
+			errorhandlers.ErrProviderEmptyResponse,
+			"LibreTranslate not returning any results",
+		)
+	}
+
+	detections := make([]googletranslatewrapper.Detection, len(res))
+	for i, detection := range res {
+		confidence := detection.Confidence / 100
+		detections[i] = googletranslatewrapper.Detection{
+			Confidence: confidence,
+			IsReliable: confidence > 0.8,
+			Language:   libreTranslateLanguages.tag(detection.Language),
+		}
+	}
+
+	return detections, nil
+}
@@ -0,0 +1,177 @@
+package translationproviders
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+
+	"github.com/weiyuan-lane/google-translate-api/internal/services/googletranslatewrapper"
+	"github.com/weiyuan-lane/google-translate-api/internal/utils/errorhandlers"
+	"golang.org/x/text/language"
+)
+
+// newStandIn serves handler for path, failing the test on other paths
+func newStandIn(t *testing.T, path string, handler http.HandlerFunc) *httptest.Server {
+	t.Helper()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost || r.URL.Path != path {
+			t.Errorf("request to %s %s, want POST %s", r.Method, r.URL.Path, path)
+			http.NotFound(w, r)
+			return
+		}
+
+		handler(w, r)
+	}))
+	t.Cleanup(server.Close)
+
+	return server
+}
+
+func decodeBody(t *testing.T, r *http.Request, body interface{}) {
+	t.Helper()
+
+	if err := json.NewDecoder(r.Body).Decode(body); err != nil {
+		t.Fatalf("request body is not valid JSON: %v", err)
+	}
+}
+
+func TestLibreTranslateTranslateText(t *testing.T) {
+	tests := []struct {
+		name         string
+		target       language.Tag
+		wantTarget   string
+		detected     string
+		wantDetected language.Tag
+	}{
+		{"base language", language.French, "fr", "en", language.English},
+		{"traditional chinese", language.MustParse("zh-TW"), "zt", "zt", language.MustParse("zh-TW")},
+		{"simplified chinese", language.MustParse("zh-Hans"), "zh", "zh", language.MustParse("zh-CN")},
+		{"brazilian portuguese", language.MustParse("pt-BR"), "pb", "pb", language.MustParse("pt-BR")},
+		{"region falls back to base language", language.MustParse("de-AT"), "de", "de", language.German},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := newStandIn(t, "/translate", func(w http.ResponseWriter, r *http.Request) {
+				req := libreTranslateTranslateRequest{}
+				decodeBody(t, r, &req)
+
+				if req.Q != "hello" || req.Source != "auto" || req.Target != tt.wantTarget || req.APIKey != "secret" {
+					t.Errorf("request = %+v, want hello from auto to %s with the API key", req, tt.wantTarget)
+				}
+
+				json.NewEncoder(w).Encode(map[string]interface{}{
+					"translatedText":   "translated",
+					"detectedLanguage": map[string]interface{}{"confidence": 90, "language": tt.detected},
+				})
+			})
+
+			translation, err := NewLibreTranslate(server.URL+"/", "secret", nil).TranslateText(context.Background(), "hello", tt.target)
+			if err != nil {
+				t.Fatalf("TranslateText() error = %v", err)
+			}
+
+			want := googletranslatewrapper.Translation{
+				TranslatedText: "translated",
+				OriginalText:   "hello",
+				DetectedLang:   tt.wantDetected,
+				TargetLang:     tt.target,
+			}
+			if translation != want {
+				t.Errorf("TranslateText() = %+v, want %+v", translation, want)
+			}
+		})
+	}
+}
+
+func TestLibreTranslateDetectionsFromText(t *testing.T) {
+	server := newStandIn(t, "/detect", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode([]map[string]interface{}{
+			{"confidence": 95, "language": "zt"},
+			{"confidence": 40, "language": "ja"},
+		})
+	})
+
+	detections, err := NewLibreTranslate(server.URL, "", nil).DetectionsFromText(context.Background(), "你好")
+	if err != nil {
+		t.Fatalf("DetectionsFromText() error = %v", err)
+	}
+
+	want := []googletranslatewrapper.Detection{
+		{Confidence: 0.95, IsReliable: true, Language: language.MustParse("zh-TW")},
+		{Confidence: 0.4, IsReliable: false, Language: language.Japanese},
+	}
+	if len(detections) != len(want) {
+		t.Fatalf("DetectionsFromText() = %+v, want %+v", detections, want)
+	}
+	for i := range want {
+		if detections[i] != want[i] {
+			t.Errorf("DetectionsFromText()[%d] = %+v, want %+v", i, detections[i], want[i])
+		}
+	}
+}
+
+func TestLibreTranslateEmptyResponse(t *testing.T) {
+	server := newStandIn(t, "/translate", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{}`))
+	})
+
+	_, err := NewLibreTranslate(server.URL, "", nil).TranslateText(context.Background(), "hello", language.French)
+	if !errors.Is(err, errorhandlers.ErrProviderEmptyResponse) {
+		t.Errorf("TranslateText() error = %v, want %v", err, errorhandlers.ErrProviderEmptyResponse)
+	}
+}
+
+// statusTests are the error statuses of providers, with the error code and
+// failover class each one maps to
+var statusTests = []struct {
+	status    int
+	wantCode  error
+	wantClass string
+}{
+	{http.StatusUnauthorized, errorhandlers.ErrProviderAuthErrResponse, googletranslatewrapper.ErrorClassAuth},
+	{http.StatusForbidden, errorhandlers.ErrProviderAuthErrResponse, googletranslatewrapper.ErrorClassAuth},
+	{http.StatusTooManyRequests, errorhandlers.ErrProviderQuotaExceeded, googletranslatewrapper.ErrorClassRateLimited},
+	{456, errorhandlers.ErrProviderQuotaExceeded, googletranslatewrapper.ErrorClassRateLimited},
+	{http.StatusBadRequest, errorhandlers.ErrProviderTranslateErrResponse, googletranslatewrapper.ErrorClassInvalidRequest},
+	{http.StatusServiceUnavailable, errorhandlers.ErrProviderTranslateErrResponse, googletranslatewrapper.ErrorClassUnavailable},
+}
+
+func TestLibreTranslateErrorStatuses(t *testing.T) {
+	for _, tt := range statusTests {
+		t.Run(strconv.Itoa(tt.status), func(t *testing.T) {
+			server := newStandIn(t, "/translate", func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(tt.status)
+				w.Write([]byte(`{"error": "nope"}`))
+			})
+
+			_, err := NewLibreTranslate(server.URL, "", nil).TranslateText(context.Background(), "hello", language.French)
+			assertProviderError(t, err, tt.status, tt.wantCode, tt.wantClass, "nope")
+		})
+	}
+}
+
+func assertProviderError(t *testing.T, err error, status int, wantCode error, wantClass, wantMessage string) {
+	t.Helper()
+
+	if !errors.Is(err, wantCode) {
+		t.Errorf("error = %v, want code %v", err, wantCode)
+	}
+
+	if class := googletranslatewrapper.ClassifyError(err); class != wantClass {
+		t.Errorf("ClassifyError() = %q, want %q", class, wantClass)
+	}
+
+	httpErr := &HTTPError{}
+	if !errors.As(errorhandlers.UpstreamCause(err), &httpErr) {
+		t.Fatalf("upstream cause of %v is not an HTTPError", err)
+	}
+	if httpErr.StatusCode != status || httpErr.Message != wantMessage {
+		t.Errorf("HTTPError = %+v, want status %d with message %q", httpErr, status, wantMessage)
+	}
+}
@@ -0,0 +1,194 @@
+package translationproviders
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/weiyuan-lane/google-translate-api/internal/services/googletranslatewrapper"
+	"github.com/weiyuan-lane/google-translate-api/internal/utils/errorhandlers"
+	"github.com/weiyuan-lane/google-translate-api/internal/utils/requestinfo"
+	"github.com/weiyuan-lane/google-translate-api/internal/utils/tracing"
+	"golang.org/x/text/language"
+)
+
+// ProviderGoogle is served by the Google wrappers rather than a registered
+// provider, and is used when a request does not name one
+const ProviderGoogle = "google"
+
+const defaultTimeout = 30 * time.Second
+
+// Provider has the same translate and detect contract as
+// googletranslatewrapper.TranslateV2Wrapper
+type Provider interface {
+	Name() string
+	TranslateText(ctx context.Context, text string, targetLocale language.Tag) (googletranslatewrapper.Translation, error)
+	DetectionsFromText(ctx context.Context, text string) ([]googletranslatewrapper.Detection, error)
+}
+
+type Registry struct {
+	providers map[string]Provider
+}
+
+// HTTPError is the upstream cause of a failed provider call
+type HTTPError struct {
+	Provider   string
+	StatusCode int
+	Message    string
+}
+
+func NewRegistry(providers ...Provider) Registry {
+	registry := Registry{
+		providers: map[string]Provider{},
+	}
+
+	for _, provider := range providers {
+		registry.providers[provider.Name()] = provider
+	}
+
+	return registry
+}
+
+func (r Registry) Lookup(name string) (Provider, error) {
+	provider, ok := r.providers[strings.ToLower(name)]
+	if !ok {
+		return nil, errorhandlers.Wrap(
+			errorhandlers.ErrProviderNotConfigured,
+			fmt.Sprintf("Provider %q is not configured, expected one of %s", name, strings.Join(append([]string{ProviderGoogle}, r.Names()...), ", ")),
+		)
+	}
+
+	return provider, nil
+}
+
+func (r Registry) Names() []string {
+	names := []string{}
+	for name := range r.providers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	return names
+}
+
+func IsGoogle(name string) bool {
+	return name == "" || strings.EqualFold(name, ProviderGoogle)
+}
+
+func (e *HTTPError) Error() string {
+	return fmt.Sprintf("%s responded with status %d: %s", e.Provider, e.StatusCode, e.Message)
+}
+
+func (e *HTTPError) HTTPStatusCode() int {
+	return e.StatusCode
+}
+
+func newHTTPClient(httpClient *http.Client) *http.Client {
+	if httpClient != nil {
+		return httpClient
+	}
+
+	return &http.Client{Timeout: defaultTimeout}
+}
+
+// postJSON sends body to url and decodes a 2xx response into result. Other
+// responses are normalized into errorhandlers codes, with errCode used for
+// failures that are not about auth or quota.
+func postJSON(ctx context.Context, httpClient *http.Client, providerName, url string, header http.Header, body, result interface{}, errCode error) (err error) {
+	ctx, span := tracing.Start(ctx, "postJSON "+providerName, tracing.ProviderKey.String(providerName))
+	defer func() { tracing.End(span, err) }()
+
+	bodyBytes, err := json.Marshal(body)
+	if err != nil {
+		return errorhandlers.Wrap(errCode, fmt.Sprintf("%s request could not be encoded: %s", providerName, err.Error()))
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(bodyBytes))
+	if err != nil {
+		return errorhandlers.Wrap(errCode, fmt.Sprintf("%s request could not be created: %s", providerName, err.Error()))
+	}
+	req.Header = header.Clone()
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Accept", "application/json")
+	tracing.InjectHTTP(ctx, req.Header)
+
+	res, err := httpClient.Do(req)
+	if err != nil {
+		return errorhandlers.WrapUpstream(
+			errCode,
+			fmt.Sprintf("%s returned error %s", providerName, err.Error()),
+			err,
+		)
+	}
+	defer res.Body.Close()
+
+	resBytes, err := io.ReadAll(io.LimitReader(res.Body, 10<<20))
+	if err != nil {
+		return errorhandlers.WrapUpstream(
+			errCode,
+			fmt.Sprintf("%s response could not be read: %s", providerName, err.Error()),
+			err,
+		)
+	}
+
+	if res.StatusCode < 200 || res.StatusCode > 299 {
+		httpErr := &HTTPError{
+			Provider:   providerName,
+			StatusCode: res.StatusCode,
+			Message:    errorMessage(resBytes),
+		}
+
+		return errorhandlers.WrapUpstream(statusErrCode(res.StatusCode, errCode), httpErr.Error(), httpErr)
+	}
+
+	if err := json.Unmarshal(resBytes, result); err != nil {
+		return errorhandlers.Wrap(
+			errorhandlers.ErrProviderEmptyResponse,
+			fmt.Sprintf("%s response is not valid JSON: %s", providerName, err.Error()),
+		)
+	}
+	requestinfo.FromContext(ctx).SetBackend(providerName)
+
+	return nil
+}
+
+// DeepL answers 456 when the character quota is used up
+func statusErrCode(statusCode int, errCode error) error {
+	switch statusCode {
+	case http.StatusUnauthorized, http.StatusForbidden:
+		return errorhandlers.ErrProviderAuthErrResponse
+	case http.StatusTooManyRequests, 456:
+		return errorhandlers.ErrProviderQuotaExceeded
+	default:
+		return errCode
+	}
+}
+
+// LibreTranslate puts the error message in "error", DeepL in "message"
+func errorMessage(resBytes []byte) string {
+	errorBody := struct {
+		Error   string `json:"error"`
+		Message string `json:"message"`
+	}{}
+	if err := json.Unmarshal(resBytes, &errorBody); err == nil {
+		if errorBody.Error != "" {
+			return errorBody.Error
+		}
+		if errorBody.Message != "" {
+			return errorBody.Message
+		}
+	}
+
+	message := strings.TrimSpace(string(resBytes))
+	if len(message) > 200 {
+		message = message[:200]
+	}
+
+	return message
+}
@@ -0,0 +1,171 @@
+package usage
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"time"
+
+	loggerutils "github.com/weiyuan-lane/google-translate-api/internal/utils/logger"
+)
+
+// FileStore appends every entry as a JSON line to a file, and sums the file
+// back into memory on start. The file is then rewritten with one line per
+// hourly sum, so it only grows with the hours and keys in use.
+type FileStore struct {
+	memory *MemoryStore
+
+	mutex sync.Mutex
+	file  *os.File
+}
+
+// endOfTime is after every entry, to read back all of them
+var endOfTime = time.Date(9999, time.January, 1, 0, 0, 0, 0, time.UTC)
+
+func NewFileStore(path string, logger *loggerutils.Logger) (*FileStore, error) {
+	memory := NewMemoryStore()
+
+	if err := loadEntries(path, memory, logger); err != nil {
+		return nil, err
+	}
+
+	if err := compact(path, memory); err != nil {
+		return nil, err
+	}
+
+	file, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o644)
+	if err != nil {
+		return nil, fmt.Errorf("open usage file: %w", err)
+	}
+
+	return &FileStore{
+		memory: memory,
+		file:   file,
+	}, nil
+}
+
+// loadEntries sums the entries of path into memory. A last line that is not
+// a valid entry was torn by a crash mid-write, and is skipped.
+func loadEntries(path string, memory *MemoryStore, logger *loggerutils.Logger) error {
+	file, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("open usage file: %w", err)
+	}
+	defer file.Close()
+
+	var invalidErr error
+	invalidLine := 0
+
+	scanner := bufio.NewScanner(file)
+	for line := 1; scanner.Scan(); line++ {
+		if len(scanner.Bytes()) == 0 {
+			continue
+		}
+		if invalidErr != nil {
+			return fmt.Errorf("usage file %s line %d is not a valid entry: %w", path, invalidLine, invalidErr)
+		}
+
+		entry := Entry{}
+		if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil {
+			invalidErr, invalidLine = err, line
+			continue
+		}
+		memory.Add(context.Background(), entry)
+	}
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("read usage file: %w", err)
+	}
+
+	if invalidErr != nil {
+		logger.Error("Skipped torn last line of usage file", map[string]string{
+			"usage_file": path,
+			"line":       strconv.Itoa(invalidLine),
+			"error":      invalidErr.Error(),
+		})
+	}
+
+	return nil
+}
+
+// compact replaces the file at path with the entries of memory, through a
+// temporary file renamed over it, so a crash leaves either file whole
+func compact(path string, memory *MemoryStore) error {
+	entries, err := memory.Entries(context.Background(), time.Time{}, endOfTime)
+	if err != nil {
+		return err
+	}
+
+	tempFile, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return fmt.Errorf("compact usage file: %w", err)
+	}
+	defer os.Remove(tempFile.Name())
+
+	err = writeEntries(tempFile, entries)
+	if closeErr := tempFile.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Chmod(tempFile.Name(), 0o644)
+	}
+	if err == nil {
+		err = os.Rename(tempFile.Name(), path)
+	}
+	if err != nil {
+		return fmt.Errorf("compact usage file: %w", err)
+	}
+
+	return nil
+}
+
+func writeEntries(file *os.File, entries []Entry) error {
+	writer := bufio.NewWriter(file)
+	encoder := json.NewEncoder(writer)
+	for _, entry := range entries {
+		if err := encoder.Encode(entry); err != nil {
+			return err
+		}
+	}
+
+	if err := writer.Flush(); err != nil {
+		return err
+	}
+
+	return file.Sync()
+}
+
+func (s *FileStore) Add(ctx context.Context, entry Entry) error {
+	entryBytes, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if _, err := s.file.Write(append(entryBytes, '\n')); err != nil {
+		return fmt.Errorf("write usage file: %w", err)
+	}
+
+	return s.memory.Add(ctx, entry)
+}
+
+func (s *FileStore) Entries(ctx context.Context, from, to time.Time) ([]Entry, error) {
+	return s.memory.Entries(ctx, from, to)
+}
+
+func (s *FileStore) MonthlyCharacters(ctx context.Context, tenant string, month time.Time) (int64, error) {
+	return s.memory.MonthlyCharacters(ctx, tenant, month)
+}
+
+func (s *FileStore) Close() error {
+	return s.file.Close()
+}
@@ -0,0 +1,99 @@
+package usage
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	loggerutils "github.com/weiyuan-lane/google-translate-api/internal/utils/logger"
+)
+
+const testEntry = `{"hour":"2024-05-01T10:00:00Z","tenant":"acme","api":"v3","operation":"translate","requests":1,"characters":5}`
+
+func writeUsageFile(t *testing.T, lines ...string) string {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), "usage.jsonl")
+	if err := os.WriteFile(path, []byte(strings.Join(lines, "\n")), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	return path
+}
+
+func monthlyCharacters(t *testing.T, store *FileStore) int64 {
+	t.Helper()
+
+	characters, err := store.MonthlyCharacters(context.Background(), "acme", time.Date(2024, time.May, 1, 0, 0, 0, 0, time.UTC))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return characters
+}
+
+func TestFileStoreCompactsOnLoad(t *testing.T) {
+	path := writeUsageFile(t, testEntry, testEntry, testEntry+"\n")
+
+	store, err := NewFileStore(path, loggerutils.New("test", false))
+	if err != nil {
+		t.Fatalf("NewFileStore() error = %v", err)
+	}
+	defer store.Close()
+
+	if characters := monthlyCharacters(t, store); characters != 15 {
+		t.Errorf("MonthlyCharacters() = %d, want 15", characters)
+	}
+
+	content, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if lines := strings.Count(string(content), "\n"); lines != 1 {
+		t.Errorf("usage file has %d lines after load, want the hourly sum only:\n%s", lines, content)
+	}
+	if !strings.Contains(string(content), `"characters":15`) {
+		t.Errorf("usage file = %s, want the summed characters", content)
+	}
+}
+
+func TestFileStoreSkipsTornLastLine(t *testing.T) {
+	path := writeUsageFile(t, testEntry, testEntry[:40])
+
+	store, err := NewFileStore(path, loggerutils.New("test", false))
+	if err != nil {
+		t.Fatalf("NewFileStore() error = %v", err)
+	}
+
+	// Entries added after the torn line are read back whole
+	if err := store.Add(context.Background(), Entry{
+		Hour:       time.Date(2024, time.May, 1, 11, 0, 0, 0, time.UTC),
+		Tenant:     "acme",
+		Requests:   1,
+		Characters: 7,
+	}); err != nil {
+		t.Fatal(err)
+	}
+	store.Close()
+
+	reopened, err := NewFileStore(path, loggerutils.New("test", false))
+	if err != nil {
+		t.Fatalf("NewFileStore() after a restart error = %v", err)
+	}
+	defer reopened.Close()
+
+	if characters := monthlyCharacters(t, reopened); characters != 12 {
+		t.Errorf("MonthlyCharacters() = %d, want 12", characters)
+	}
+}
+
+func TestFileStoreRejectsInvalidLinesBeforeTheLast(t *testing.T) {
+	path := writeUsageFile(t, testEntry, "not json", testEntry)
+
+	if _, err := NewFileStore(path, loggerutils.New("test", false)); err == nil || !strings.Contains(err.Error(), "line 2") {
+		t.Errorf("NewFileStore() error = %v, want line 2 reported", err)
+	}
+}
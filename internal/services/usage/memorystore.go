@@ -0,0 +1,90 @@
+package usage
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+)
+
+type entryKey struct {
+	hour         time.Time
+	tenant       string
+	api          string
+	operation    string
+	model        string
+	sourceLocale string
+	targetLocale string
+}
+
+type monthKey struct {
+	tenant string
+	month  time.Time
+}
+
+// MemoryStore loses its entries on restart
+type MemoryStore struct {
+	mutex   sync.RWMutex
+	entries map[entryKey]*Entry
+	monthly map[monthKey]int64
+}
+
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{
+		entries: map[entryKey]*Entry{},
+		monthly: map[monthKey]int64{},
+	}
+}
+
+func (s *MemoryStore) Add(ctx context.Context, entry Entry) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	entry.Hour = entry.Hour.UTC().Truncate(time.Hour)
+	key := entryKey{
+		hour:         entry.Hour,
+		tenant:       entry.Tenant,
+		api:          entry.API,
+		operation:    entry.Operation,
+		model:        entry.Model,
+		sourceLocale: entry.SourceLocale,
+		targetLocale: entry.TargetLocale,
+	}
+
+	if stored, ok := s.entries[key]; ok {
+		stored.Requests += entry.Requests
+		stored.Characters += entry.Characters
+		stored.GlossaryCharacters += entry.GlossaryCharacters
+	} else {
+		s.entries[key] = &entry
+	}
+
+	s.monthly[monthKey{tenant: entry.Tenant, month: startOfMonth(entry.Hour)}] += entry.BillableCharacters()
+
+	return nil
+}
+
+func (s *MemoryStore) Entries(ctx context.Context, from, to time.Time) ([]Entry, error) {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	entries := []Entry{}
+	for _, entry := range s.entries {
+		if !entry.Hour.Before(from.UTC().Truncate(time.Hour)) && entry.Hour.Before(to) {
+			entries = append(entries, *entry)
+		}
+	}
+
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].Hour.Before(entries[j].Hour)
+	})
+
+	return entries, nil
+}
+
+func (s *MemoryStore) MonthlyCharacters(ctx context.Context, tenant string, month time.Time) (int64, error) {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	return s.monthly[monthKey{tenant: tenant, month: startOfMonth(month)}], nil
+}
@@ -0,0 +1,140 @@
+package usage
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/weiyuan-lane/google-translate-api/internal/utils/errorhandlers"
+	loggerutils "github.com/weiyuan-lane/google-translate-api/internal/utils/logger"
+)
+
+// Budget of billable characters per calendar month (UTC), 0 being unlimited.
+// Going over the soft budget is logged, once a month, while calls that
+// would go over the hard budget are refused.
+type Budget struct {
+	SoftCharacters int64 `json:"soft_characters"`
+	HardCharacters int64 `json:"hard_characters"`
+}
+
+// Meter records usage into a store, shared by the meters of every tenant
+type Meter struct {
+	store  Store
+	logger *loggerutils.Logger
+
+	mutex sync.Mutex
+	// Month each tenant was last warned about its soft budget
+	warnedMonths map[string]time.Time
+}
+
+// TenantMeter checks and records the calls of one tenant, "" for requests
+// without a tenant. A nil TenantMeter does neither.
+type TenantMeter struct {
+	meter  *Meter
+	tenant string
+	budget Budget
+}
+
+func NewMeter(store Store, logger *loggerutils.Logger) *Meter {
+	return &Meter{
+		store:        store,
+		logger:       logger,
+		warnedMonths: map[string]time.Time{},
+	}
+}
+
+func (m *Meter) Store() Store {
+	return m.store
+}
+
+func (m *Meter) ForTenant(tenant string, budget Budget) *TenantMeter {
+	return &TenantMeter{
+		meter:  m,
+		tenant: tenant,
+		budget: budget,
+	}
+}
+
+func (b Budget) IsSet() bool {
+	return b.SoftCharacters > 0 || b.HardCharacters > 0
+}
+
+// Check refuses a call of characters that would take the tenant over its
+// hard budget. Calls go through when the store cannot be read.
+//
+// Nothing is reserved until Record, so calls checked at the same time can
+// together go over the hard budget by up to the characters in flight.
+func (t *TenantMeter) Check(ctx context.Context, characters int64) error {
+	if t == nil || !t.budget.IsSet() {
+		return nil
+	}
+
+	now := time.Now()
+	used, err := t.meter.store.MonthlyCharacters(ctx, t.tenant, now)
+	if err != nil {
+		t.meter.logger.Error("Failed to read usage, budget not enforced", map[string]string{
+			"tenant": t.tenant,
+			"error":  err.Error(),
+		})
+		return nil
+	}
+
+	if t.budget.HardCharacters > 0 && used+characters > t.budget.HardCharacters {
+		return errorhandlers.Wrap(
+			errorhandlers.ErrUsageBudgetExceeded,
+			fmt.Sprintf("%s used %d of %d characters budgeted this month", t.name(), used, t.budget.HardCharacters),
+		)
+	}
+
+	if t.budget.SoftCharacters > 0 && used+characters > t.budget.SoftCharacters {
+		t.meter.warnOnce(t.tenant, now, map[string]string{
+			"tenant":       t.tenant,
+			"used":         strconv.FormatInt(used+characters, 10),
+			"soft_budget":  strconv.FormatInt(t.budget.SoftCharacters, 10),
+			"hard_budget":  strconv.FormatInt(t.budget.HardCharacters, 10),
+			"budget_month": startOfMonth(now).Format("2006-01"),
+		})
+	}
+
+	return nil
+}
+
+// Record stores the usage of a successful call. Failures are logged, as the
+// call was already served.
+func (t *TenantMeter) Record(ctx context.Context, record Record) {
+	if t == nil {
+		return
+	}
+
+	record.Tenant = t.tenant
+	if err := t.meter.store.Add(ctx, record.entry(time.Now())); err != nil {
+		t.meter.logger.Error("Failed to record usage", map[string]string{
+			"tenant":     t.tenant,
+			"characters": strconv.FormatInt(record.Characters+record.GlossaryCharacters, 10),
+			"error":      err.Error(),
+		})
+	}
+}
+
+func (t *TenantMeter) name() string {
+	if t.tenant == "" {
+		return "Deployment"
+	}
+
+	return fmt.Sprintf("Tenant %q", t.tenant)
+}
+
+func (m *Meter) warnOnce(tenant string, now time.Time, fields map[string]string) {
+	month := startOfMonth(now)
+
+	m.mutex.Lock()
+	warned := m.warnedMonths[tenant].Equal(month)
+	m.warnedMonths[tenant] = month
+	m.mutex.Unlock()
+
+	if !warned {
+		m.logger.Info("Soft usage budget exceeded", fields)
+	}
+}
@@ -0,0 +1,97 @@
+package usage
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/weiyuan-lane/google-translate-api/internal/utils/errorhandlers"
+)
+
+// PriceTable sets the price per million billable characters, eg.
+//
+//	{
+//	  "currency": "USD",
+//	  "per_million_characters": {
+//	    "v2": 20,
+//	    "v3": 20,
+//	    "v3:my-custom-model": 80,
+//	    "deepl": 25
+//	  }
+//	}
+//
+// Keys are a Google backend, optionally with a V3 model after ":", or a
+// provider name.
+type PriceTable struct {
+	Currency             string             `json:"currency"`
+	PerMillionCharacters map[string]float64 `json:"per_million_characters"`
+}
+
+type Price struct {
+	Key                  string
+	Currency             string
+	PerMillionCharacters float64
+}
+
+func LoadPriceTable(path string) (PriceTable, error) {
+	tableBytes, err := os.ReadFile(path)
+	if err != nil {
+		return PriceTable{}, errorhandlers.Wrap(
+			errorhandlers.ErrUsageInvalidPriceTable,
+			fmt.Sprintf("Price table could not be read: %s", err.Error()),
+		)
+	}
+
+	table := PriceTable{}
+	if err := json.Unmarshal(tableBytes, &table); err != nil {
+		return PriceTable{}, errorhandlers.Wrap(
+			errorhandlers.ErrUsageInvalidPriceTable,
+			fmt.Sprintf("Price table %s is not valid JSON: %s", path, err.Error()),
+		)
+	}
+
+	problems := []string{}
+	if table.Currency == "" {
+		problems = append(problems, "currency must be set")
+	}
+	for key, price := range table.PerMillionCharacters {
+		if price < 0 {
+			problems = append(problems, fmt.Sprintf("per_million_characters[%q] cannot be negative", key))
+		}
+	}
+
+	if len(problems) > 0 {
+		return PriceTable{}, errorhandlers.Wrap(
+			errorhandlers.ErrUsageInvalidPriceTable,
+			fmt.Sprintf("Price table %s is invalid: %s", path, strings.Join(problems, "; ")),
+		)
+	}
+
+	return table, nil
+}
+
+// Lookup finds the price of a backend or provider and model, falling back
+// from "<backend>:<model>" to "<backend>"
+func (p PriceTable) Lookup(backend, model string) (Price, bool) {
+	keys := []string{backend}
+	if model != "" {
+		keys = []string{backend + ":" + model, backend}
+	}
+
+	for _, key := range keys {
+		if perMillion, ok := p.PerMillionCharacters[key]; ok {
+			return Price{
+				Key:                  key,
+				Currency:             p.Currency,
+				PerMillionCharacters: perMillion,
+			}, true
+		}
+	}
+
+	return Price{}, false
+}
+
+func (p Price) Amount(billableCharacters int64) float64 {
+	return float64(billableCharacters) * p.PerMillionCharacters / 1_000_000
+}
@@ -0,0 +1,118 @@
+package usage
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Fields usage can be grouped by
+const (
+	GroupByTenant       = "tenant"
+	GroupByAPI          = "api"
+	GroupByOperation    = "operation"
+	GroupByModel        = "model"
+	GroupByLanguagePair = "language_pair"
+	GroupByDay          = "day"
+	GroupByMonth        = "month"
+)
+
+var GroupByFields = []string{
+	GroupByTenant,
+	GroupByAPI,
+	GroupByOperation,
+	GroupByModel,
+	GroupByLanguagePair,
+	GroupByDay,
+	GroupByMonth,
+}
+
+type Summary struct {
+	Group              map[string]string
+	Requests           int64
+	Characters         int64
+	GlossaryCharacters int64
+}
+
+func (s Summary) BillableCharacters() int64 {
+	return s.Characters + s.GlossaryCharacters
+}
+
+// Summarize sums entries by the values of groupBy, all entries into one
+// summary when groupBy is empty
+func Summarize(entries []Entry, groupBy []string) ([]Summary, error) {
+	for _, field := range groupBy {
+		if !containsString(GroupByFields, field) {
+			return nil, fmt.Errorf("cannot group by %q, expected any of %s", field, strings.Join(GroupByFields, ", "))
+		}
+	}
+
+	summaries := map[string]*Summary{}
+	keys := []string{}
+	for _, entry := range entries {
+		group := entryGroup(entry, groupBy)
+
+		keyParts := make([]string, 0, len(group))
+		for _, field := range groupBy {
+			if field == GroupByLanguagePair {
+				keyParts = append(keyParts, group["source_locale"], group["target_locale"])
+			} else {
+				keyParts = append(keyParts, group[field])
+			}
+		}
+		key := strings.Join(keyParts, "\x00")
+
+		summary, ok := summaries[key]
+		if !ok {
+			summary = &Summary{Group: group}
+			summaries[key] = summary
+			keys = append(keys, key)
+		}
+		summary.Requests += entry.Requests
+		summary.Characters += entry.Characters
+		summary.GlossaryCharacters += entry.GlossaryCharacters
+	}
+
+	sort.Strings(keys)
+	results := make([]Summary, len(keys))
+	for i, key := range keys {
+		results[i] = *summaries[key]
+	}
+
+	return results, nil
+}
+
+func entryGroup(entry Entry, groupBy []string) map[string]string {
+	group := map[string]string{}
+	for _, field := range groupBy {
+		switch field {
+		case GroupByTenant:
+			group[field] = entry.Tenant
+		case GroupByAPI:
+			group[field] = entry.API
+		case GroupByOperation:
+			group[field] = entry.Operation
+		case GroupByModel:
+			group[field] = entry.Model
+		case GroupByLanguagePair:
+			group["source_locale"] = entry.SourceLocale
+			group["target_locale"] = entry.TargetLocale
+		case GroupByDay:
+			group[field] = entry.Hour.Format("2006-01-02")
+		case GroupByMonth:
+			group[field] = entry.Hour.Format("2006-01")
+		}
+	}
+
+	return group
+}
+
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,80 @@
+package usage
+
+import (
+	"context"
+	"time"
+	"unicode/utf8"
+)
+
+// Operations metered
+const (
+	OperationTranslate = "translate"
+	OperationDetect    = "detect"
+)
+
+// Record is one billable call to Google translate
+type Record struct {
+	Tenant       string
+	API          string
+	Operation    string
+	Model        string
+	SourceLocale string
+	TargetLocale string
+	Characters   int64
+	// Translations with a glossary are billed again, as V3 returns the
+	// translation both with and without the glossary
+	GlossaryCharacters int64
+}
+
+// Entry sums the records of one hour (UTC) sharing the same attributes
+type Entry struct {
+	Hour               time.Time `json:"hour"`
+	Tenant             string    `json:"tenant"`
+	API                string    `json:"api"`
+	Operation          string    `json:"operation"`
+	Model              string    `json:"model"`
+	SourceLocale       string    `json:"source_locale"`
+	TargetLocale       string    `json:"target_locale"`
+	Requests           int64     `json:"requests"`
+	Characters         int64     `json:"characters"`
+	GlossaryCharacters int64     `json:"glossary_characters"`
+}
+
+// Store keeps usage entries. Adding an entry with the same hour and
+// attributes as a stored one sums them.
+type Store interface {
+	Add(ctx context.Context, entry Entry) error
+	// Entries of the hours from (inclusive) to to (exclusive)
+	Entries(ctx context.Context, from, to time.Time) ([]Entry, error)
+	// Billable characters of a tenant in the calendar month (UTC) of month
+	MonthlyCharacters(ctx context.Context, tenant string, month time.Time) (int64, error)
+}
+
+func (r Record) entry(now time.Time) Entry {
+	return Entry{
+		Hour:               now.UTC().Truncate(time.Hour),
+		Tenant:             r.Tenant,
+		API:                r.API,
+		Operation:          r.Operation,
+		Model:              r.Model,
+		SourceLocale:       r.SourceLocale,
+		TargetLocale:       r.TargetLocale,
+		Requests:           1,
+		Characters:         r.Characters,
+		GlossaryCharacters: r.GlossaryCharacters,
+	}
+}
+
+func (e Entry) BillableCharacters() int64 {
+	return e.Characters + e.GlossaryCharacters
+}
+
+// Characters counts text as Google bills it, by code point
+func Characters(text string) int64 {
+	return int64(utf8.RuneCountInString(text))
+}
+
+func startOfMonth(t time.Time) time.Time {
+	t = t.UTC()
+	return time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, time.UTC)
+}
@@ -0,0 +1,66 @@
+package http
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/weiyuan-lane/google-translate-api/internal/utils/requestinfo"
+)
+
+// statusRecorder keeps the status code and size of a response
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+	bytes  int
+}
+
+func (s *statusRecorder) WriteHeader(status int) {
+	s.status = status
+	s.ResponseWriter.WriteHeader(status)
+}
+
+func (s *statusRecorder) Write(b []byte) (int, error) {
+	n, err := s.ResponseWriter.Write(b)
+	s.bytes += n
+	return n, err
+}
+
+// makeAccessLogHandler gives each request an ID, taken from the
+// "X-Request-ID" header when the client sends a usable one and returned in
+// the same header, and logs one entry per request once it is served. It
+// wraps the router so that unmatched routes and CORS preflights are logged
+// too.
+func (h HttpServer) makeAccessLogHandler(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		info := &requestinfo.Info{ID: requestinfo.NewID(r.Header.Get(requestinfo.Header))}
+		ctx := requestinfo.WithInfo(r.Context(), info)
+		w.Header().Set(requestinfo.Header, info.ID)
+
+		recorder := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+		next.ServeHTTP(recorder, r.WithContext(ctx))
+
+		route := info.Route()
+		if route == "" {
+			route = r.URL.Path
+		}
+
+		fields := info.Fields()
+		fields["method"] = r.Method
+		fields["route"] = route
+		fields["status"] = strconv.Itoa(recorder.status)
+		fields["latency_ms"] = strconv.FormatInt(time.Since(start).Milliseconds(), 10)
+		fields["bytes"] = strconv.Itoa(recorder.bytes)
+
+		h.Logger.Info("Served request", fields)
+	})
+}
+
+// recordRoute keeps the route template for the access log
+func recordRoute(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requestinfo.FromContext(r.Context()).SetRoute(routeTemplate(r))
+		next.ServeHTTP(w, r)
+	})
+}
@@ -0,0 +1,103 @@
+package http
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/gorilla/mux"
+
+	"github.com/weiyuan-lane/google-translate-api/internal/services/auth"
+	"github.com/weiyuan-lane/google-translate-api/internal/utils/errorhandlers"
+)
+
+// Routes reachable without credentials
+var authExemptPaths = map[string]bool{
+	"/readiness": true,
+}
+
+// Scope needed per route, keyed by "<method> <path template>". Routes
+// missing here need the admin scope, which also grants every other scope.
+var routeScopes = map[string]string{
+	"POST /google-translate/v2/translate":    auth.ScopeTranslate,
+	"POST /google-translate/v3/translate":    auth.ScopeTranslate,
+	"POST /google-translate/translate":       auth.ScopeTranslate,
+	"POST /google-translate/v2/detect":       auth.ScopeDetect,
+	"POST /google-translate/v3/detect":       auth.ScopeDetect,
+	"POST /google-translate/detect":          auth.ScopeDetect,
+	"POST /google-translate/estimate":        auth.ScopeTranslate,
+	"GET /google-translate/v3/glossaries":    auth.ScopeGlossaryRead,
+	"POST /google-translate/v3/glossaries":   auth.ScopeGlossaryWrite,
+	"DELETE /google-translate/v3/glossaries": auth.ScopeGlossaryWrite,
+	"GET /routing/explain":                   auth.ScopeTranslate,
+}
+
+// makeAuthMiddleware checks the client certificate, API key or JWT of each
+// request, from either the TLS handshake, "Authorization: Bearer <credential>"
+// or "X-API-Key: <key>", against the scope of the matched route
+func (h HttpServer) makeAuthMiddleware() mux.MiddlewareFunc {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			pathTemplate := routeTemplate(r)
+
+			if authExemptPaths[pathTemplate] || r.Method == http.MethodOptions {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			principal, err := h.authenticate(r)
+			if err != nil {
+				if errors.Is(err, errorhandlers.ErrAuthMissingCredentials) {
+					w.Header().Set("WWW-Authenticate", `Bearer realm="api"`)
+				} else {
+					w.Header().Set("WWW-Authenticate", `Bearer realm="api", error="invalid_token"`)
+				}
+				errorhandlers.HandleHTTPError(h.Logger.ForContext(r.Context()), err, w)
+				return
+			}
+
+			scope, ok := routeScopes[r.Method+" "+pathTemplate]
+			if !ok {
+				scope = auth.ScopeAdmin
+			}
+
+			if !principal.HasScope(scope) && !principal.HasScope(auth.ScopeAdmin) {
+				errorhandlers.HandleHTTPError(h.Logger.ForContext(r.Context()), errorhandlers.Wrap(
+					errorhandlers.ErrAuthMissingScope,
+					fmt.Sprintf("Client %q does not have the %q scope", principal.ID, scope),
+				), w)
+				return
+			}
+
+			next.ServeHTTP(w, r.WithContext(auth.WithPrincipal(r.Context(), principal)))
+		})
+	}
+}
+
+func (h HttpServer) authenticate(r *http.Request) (auth.Principal, error) {
+	// A mapped client certificate is enough on its own, otherwise the headers
+	// are still checked
+	principal, certErr := h.Authenticator.AuthenticateClientCert(r.TLS)
+	if certErr == nil {
+		return principal, nil
+	}
+
+	authorization := r.Header.Get("Authorization")
+	if len(authorization) > len("Bearer ") && strings.EqualFold(authorization[:len("Bearer ")], "Bearer ") {
+		return h.Authenticator.Authenticate(r.Context(), strings.TrimSpace(authorization[len("Bearer "):]))
+	}
+
+	if key := strings.TrimSpace(r.Header.Get("X-API-Key")); key != "" {
+		return h.Authenticator.AuthenticateAPIKey(key)
+	}
+
+	if !errors.Is(certErr, errorhandlers.ErrAuthMissingCredentials) {
+		return auth.Principal{}, certErr
+	}
+
+	return auth.Principal{}, errorhandlers.Wrap(
+		errorhandlers.ErrAuthMissingCredentials,
+		"No credentials given in the \"Authorization\" or \"X-API-Key\" header",
+	)
+}
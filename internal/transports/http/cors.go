@@ -2,14 +2,24 @@ package http
 
 import (
 	nethttp "net/http"
+	"strings"
 
-	"github.com/gorilla/handlers"
+	"github.com/weiyuan-lane/google-translate-api/internal/utils/cors"
 )
 
+// Path prefixes of the routes in cors.AdminGroup
+var corsAdminPrefixes = []string{"/admin/", "/usage"}
+
 func (h HttpServer) makeCORSWrappedHTTPHandler(handler nethttp.Handler) nethttp.Handler {
-	corsHeaders := handlers.AllowedHeaders([]string{"x-requested-with", "origin"})
-	corsOrigins := handlers.AllowedOrigins([]string{"*"})
-	corsMethods := handlers.AllowedMethods([]string{"GET", "HEAD", "POST", "PUT", "OPTIONS"})
+	return h.CORSPolicies.Handler(handler, corsGroup)
+}
+
+func corsGroup(r *nethttp.Request) string {
+	for _, prefix := range corsAdminPrefixes {
+		if strings.HasPrefix(r.URL.Path, prefix) {
+			return cors.AdminGroup
+		}
+	}
 
-	return handlers.CORS(corsOrigins, corsHeaders, corsMethods)(handler)
+	return cors.DefaultGroup
 }
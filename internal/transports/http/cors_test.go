@@ -0,0 +1,57 @@
+package http
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/weiyuan-lane/google-translate-api/internal/utils/cors"
+)
+
+func TestAdminRoutesUseTheirOwnCORSPolicy(t *testing.T) {
+	h := HttpServer{CORSPolicies: cors.NewPolicies(map[string]cors.Policy{
+		cors.DefaultGroup: {
+			AllowedOrigins: []string{"https://app.example.com"},
+			AllowedMethods: []string{"GET", "POST"},
+		},
+		cors.AdminGroup: {
+			AllowedOrigins:   []string{"https://admin.example.com"},
+			AllowedMethods:   []string{"GET", "POST"},
+			AllowCredentials: true,
+		},
+	})}
+	handler := h.makeCORSWrappedHTTPHandler(http.NotFoundHandler())
+
+	tests := []struct {
+		path   string
+		origin string
+		want   string
+	}{
+		{"/google-translate/translate", "https://app.example.com", "https://app.example.com"},
+		{"/google-translate/translate", "https://admin.example.com", ""},
+		{"/admin/config/reload", "https://admin.example.com", "https://admin.example.com"},
+		{"/admin/config/reload", "https://app.example.com", ""},
+		{"/usage", "https://admin.example.com", "https://admin.example.com"},
+		{"/usage", "https://app.example.com", ""},
+	}
+
+	for _, tt := range tests {
+		r := httptest.NewRequest("OPTIONS", tt.path, nil)
+		r.Header.Set("Origin", tt.origin)
+		r.Header.Set("Access-Control-Request-Method", "POST")
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, r)
+
+		if allowOrigin := w.Header().Get("Access-Control-Allow-Origin"); allowOrigin != tt.want {
+			t.Errorf("%s from %s: Access-Control-Allow-Origin = %q, want %q", tt.path, tt.origin, allowOrigin, tt.want)
+		}
+
+		wantCredentials := ""
+		if tt.want == "https://admin.example.com" {
+			wantCredentials = "true"
+		}
+		if credentials := w.Header().Get("Access-Control-Allow-Credentials"); credentials != wantCredentials {
+			t.Errorf("%s from %s: Access-Control-Allow-Credentials = %q, want %q", tt.path, tt.origin, credentials, wantCredentials)
+		}
+	}
+}
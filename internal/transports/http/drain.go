@@ -0,0 +1,58 @@
+package http
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sync/atomic"
+	"time"
+)
+
+// inFlightCounter counts the requests being served, so a shutdown can tell
+// how many it is waiting on
+type inFlightCounter struct {
+	count atomic.Int64
+}
+
+func (c *inFlightCounter) wrap(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		c.count.Add(1)
+		defer c.count.Add(-1)
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+func (c *inFlightCounter) Count() int64 {
+	return c.count.Load()
+}
+
+// drain keeps serving for the shutdown delay after SIGTERM, while
+// /readiness already fails, so load balancers stop routing here before the
+// listener closes. It then waits up to the graceful shutdown time for the
+// requests in flight.
+func (h HttpServer) drain(server *http.Server, inFlight *inFlightCounter) {
+	if sigtermCalled.Load() && h.ShutdownDelaySeconds > 0 {
+		h.Logger.Info(fmt.Sprintf(
+			"Failing readiness for %d seconds before shutdown, with %d requests in flight",
+			h.ShutdownDelaySeconds,
+			inFlight.Count(),
+		))
+		time.Sleep(time.Duration(h.ShutdownDelaySeconds) * time.Second)
+	}
+
+	h.Logger.Info(fmt.Sprintf("Shutting down, with %d requests in flight", inFlight.Count()))
+	if err := h.shutdown(server); err != nil {
+		h.Logger.Info(fmt.Sprintf("Server Shutdown Failed:%+v, with %d requests in flight", err, inFlight.Count()))
+	} else {
+		h.Logger.Info("Graceful shutdown completed")
+	}
+}
+
+func (h HttpServer) shutdown(server *http.Server) error {
+	gracefulShutdownTime := time.Duration(h.GracefulShutdownSeconds) * time.Second
+	ctx, cancel := context.WithTimeout(context.Background(), gracefulShutdownTime)
+	defer cancel()
+
+	return server.Shutdown(ctx)
+}
@@ -0,0 +1,177 @@
+package http
+
+import (
+	"context"
+	"encoding/json"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+
+	"cloud.google.com/go/translate"
+	"google.golang.org/api/option"
+
+	"github.com/weiyuan-lane/google-translate-api/internal/services/googletranslatewrapper"
+	"github.com/weiyuan-lane/google-translate-api/internal/utils/cors"
+	loggerutils "github.com/weiyuan-lane/google-translate-api/internal/utils/logger"
+)
+
+var drainClient = &http.Client{
+	Timeout:   10 * time.Second,
+	Transport: &http.Transport{DisableKeepAlives: true},
+}
+
+func freePort(t *testing.T) string {
+	t.Helper()
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer listener.Close()
+
+	_, port, _ := net.SplitHostPort(listener.Addr().String())
+	return port
+}
+
+// newSlowV2Client reaches a stand-in for Google that signals each call on
+// started, and only answers once release is closed
+func newSlowV2Client(t *testing.T, started chan<- struct{}, release <-chan struct{}) *translate.Client {
+	t.Helper()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		started <- struct{}{}
+		<-release
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"data": map[string]interface{}{
+				"translations": []map[string]string{
+					{"translatedText": "bonjour", "detectedSourceLanguage": "en"},
+				},
+			},
+		})
+	}))
+	t.Cleanup(server.Close)
+
+	client, err := translate.NewClient(context.Background(),
+		option.WithEndpoint(server.URL+"/"),
+		option.WithAPIKey("key"),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { client.Close() })
+
+	return client
+}
+
+func statusOf(url string) (int, error) {
+	resp, err := drainClient.Get(url)
+	if err != nil {
+		return 0, err
+	}
+	resp.Body.Close()
+
+	return resp.StatusCode, nil
+}
+
+// waitFor polls until condition holds, failing the test after timeout
+func waitFor(t *testing.T, timeout time.Duration, what string, condition func() bool) {
+	t.Helper()
+
+	deadline := time.Now().Add(timeout)
+	for !condition() {
+		if time.Now().After(deadline) {
+			t.Fatalf("timed out waiting for %s", what)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestSIGTERMDrainsCoreBeforeLiveness(t *testing.T) {
+	t.Cleanup(func() { sigtermCalled.Store(false) })
+
+	started, release := make(chan struct{}, 1), make(chan struct{})
+	h := HttpServer{
+		Port:                     freePort(t),
+		LivelinessProbePort:      freePort(t),
+		Logger:                   loggerutils.New("test", false),
+		GracefulShutdownSeconds:  10,
+		ShutdownDelaySeconds:     1,
+		GoogleTranslateV2Wrapper: googletranslatewrapper.NewTranslateV2Wrapper(newSlowV2Client(t, started, release)),
+		CORSPolicies:             cors.NewPolicies(map[string]cors.Policy{cors.DefaultGroup: {}}),
+	}
+	coreURL := "http://127.0.0.1:" + h.Port
+	livenessURL := "http://127.0.0.1:" + h.LivelinessProbePort
+
+	stopped := make(chan struct{})
+	go func() {
+		h.ListenAndServe()
+		close(stopped)
+	}()
+
+	waitFor(t, 5*time.Second, "the core server to be ready", func() bool {
+		status, err := statusOf(coreURL + "/readiness")
+		return err == nil && status == http.StatusOK
+	})
+
+	inFlight := make(chan int, 1)
+	go func() {
+		resp, err := drainClient.Post(coreURL+"/google-translate/v2/translate", "application/json",
+			strings.NewReader(`{"text": "hello", "target_locale": "fr"}`))
+		if err != nil {
+			t.Errorf("in-flight request error = %v", err)
+			inFlight <- 0
+			return
+		}
+		resp.Body.Close()
+		inFlight <- resp.StatusCode
+	}()
+	<-started
+
+	if err := syscall.Kill(syscall.Getpid(), syscall.SIGTERM); err != nil {
+		t.Fatal(err)
+	}
+
+	// During the shutdown delay, readiness fails but requests are served
+	waitFor(t, time.Second, "readiness to fail", func() bool {
+		status, err := statusOf(coreURL + "/readiness")
+		return err == nil && status == http.StatusServiceUnavailable
+	})
+	if status, err := statusOf(coreURL + "/unknown"); err != nil || status != http.StatusNotFound {
+		t.Errorf("request during the shutdown delay = %d, %v, want it served", status, err)
+	}
+
+	// Then the core server stops taking requests, and drains the one in
+	// flight while liveness still answers
+	waitFor(t, 5*time.Second, "the core server to stop listening", func() bool {
+		_, err := statusOf(coreURL + "/readiness")
+		return err != nil
+	})
+	if status, err := statusOf(livenessURL); err != nil || status != http.StatusOK {
+		t.Errorf("liveness while draining = %d, %v, want 200", status, err)
+	}
+	select {
+	case <-stopped:
+		t.Fatal("server stopped with a request in flight")
+	default:
+	}
+
+	close(release)
+	if status := <-inFlight; status != http.StatusCreated {
+		t.Errorf("in-flight request status = %d, want 201", status)
+	}
+
+	select {
+	case <-stopped:
+	case <-time.After(5 * time.Second):
+		t.Fatal("server did not stop after draining")
+	}
+	if _, err := statusOf(livenessURL); err == nil {
+		t.Error("liveness still answers after shutdown")
+	}
+}
@@ -1,36 +1,84 @@
 package http
 
 import (
-	"context"
+	"crypto/tls"
 	"fmt"
 	"net/http"
-	"time"
 
 	"github.com/gorilla/mux"
 	"golang.org/x/net/http2"
 	"golang.org/x/net/http2/h2c"
 
+	"github.com/weiyuan-lane/google-translate-api/internal/services/auth"
+	"github.com/weiyuan-lane/google-translate-api/internal/services/configreload"
+	"github.com/weiyuan-lane/google-translate-api/internal/services/glossaryexplain"
+	"github.com/weiyuan-lane/google-translate-api/internal/services/glossarysync"
 	"github.com/weiyuan-lane/google-translate-api/internal/services/googletranslatewrapper"
+	"github.com/weiyuan-lane/google-translate-api/internal/services/health"
+	"github.com/weiyuan-lane/google-translate-api/internal/services/localglossary"
+	"github.com/weiyuan-lane/google-translate-api/internal/services/routing"
+	"github.com/weiyuan-lane/google-translate-api/internal/services/tenancy"
+	"github.com/weiyuan-lane/google-translate-api/internal/services/translationproviders"
+	"github.com/weiyuan-lane/google-translate-api/internal/services/usage"
+	admintransport "github.com/weiyuan-lane/google-translate-api/internal/transports/http/services/admin"
 	"github.com/weiyuan-lane/google-translate-api/internal/transports/http/services/googletranslate"
+	routingtransport "github.com/weiyuan-lane/google-translate-api/internal/transports/http/services/routing"
+	usagetransport "github.com/weiyuan-lane/google-translate-api/internal/transports/http/services/usage"
+	"github.com/weiyuan-lane/google-translate-api/internal/utils/cors"
 	loggerutils "github.com/weiyuan-lane/google-translate-api/internal/utils/logger"
+	"github.com/weiyuan-lane/google-translate-api/internal/utils/metrics"
+	"github.com/weiyuan-lane/google-translate-api/internal/utils/tlsconfig"
 )
 
 type HttpServer struct {
-	LivelinessProbePort      string
-	Port                     string
-	Logger                   *loggerutils.Logger
-	GracefulShutdownSeconds  int
+	LivelinessProbePort     string
+	Port                    string
+	Logger                  *loggerutils.Logger
+	GracefulShutdownSeconds int
+	// Readiness fails for this long after SIGTERM before the server stops
+	// taking requests
+	ShutdownDelaySeconds     int
 	EnableHTTP2              bool
 	GoogleTranslateV2Wrapper googletranslatewrapper.TranslateV2Wrapper
 	GoogleTranslateV3Wrapper googletranslatewrapper.TranslateV3Wrapper
+	LocalGlossary            *localglossary.Engine
+	GlossarySyncer           *glossarysync.Syncer
+	GlossaryManifest         string
+	GlossaryCatalog          glossaryexplain.Catalog
+	Providers                translationproviders.Registry
+	Router                   *routing.Router
+	// Requests are not authenticated unless API keys, JWTs or client
+	// certificates are set up
+	Authenticator auth.Authenticator
+	// Requests all use the wrappers above when nil
+	Tenants    *tenancy.Registry
+	UsageStore usage.Store
+	PriceTable usage.PriceTable
+	// Served from the liveness probe port under /metrics when set
+	Metrics *metrics.Metrics
+	// Readiness only fails on SIGTERM when nil
+	Readiness      *health.Checker
+	ConfigReloader *configreload.Reloader
+	// CORS policies by route group
+	CORSPolicies *cors.Policies
+	// The core server is plaintext when nil, with h2c if HTTP/2 is enabled.
+	// Probes are always plaintext.
+	TLS *tlsconfig.Reloader
 }
 
+// ListenAndServe blocks until SIGTERM or a server error, then drains the
+// core server before shutting down the liveness server, which has to keep
+// answering probes until then
 func (h HttpServer) ListenAndServe() {
-	h.initLivelinessHTTPServer()
+	livenessServer := h.initLivelinessHTTPServer()
 	h.initCoreHTTPServer()
+
+	if err := h.shutdown(livenessServer); err != nil {
+		h.Logger.Info(fmt.Sprintf("Liveness server Shutdown Failed:%+v", err))
+	}
 }
 
-func (h HttpServer) initLivelinessHTTPServer() {
+func (h HttpServer) initLivelinessHTTPServer() *http.Server {
 	address := ":" + h.LivelinessProbePort
 
 	livenessHTTPHandler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
@@ -39,13 +87,21 @@ func (h HttpServer) initLivelinessHTTPServer() {
 		w.Write([]byte("{}"))
 	})
 
+	probeMux := http.NewServeMux()
+	probeMux.Handle("/", livenessHTTPHandler)
+	if h.Metrics != nil {
+		probeMux.Handle("/metrics", h.Metrics.Handler())
+	}
+
+	server := &http.Server{
+		Addr:    address,
+		Handler: probeMux,
+	}
 	go func() {
-		server := &http.Server{
-			Addr:    address,
-			Handler: livenessHTTPHandler,
-		}
 		server.ListenAndServe()
 	}()
+
+	return server
 }
 
 func (h HttpServer) initCoreHTTPServer() {
@@ -54,26 +110,28 @@ func (h HttpServer) initCoreHTTPServer() {
 	h.initSigtermListener(errs)
 	h.registerReadinessRoute(router)
 	h.registerServices(router)
-	handler := h.makeCORSWrappedHTTPHandler(router)
+	inFlight := &inFlightCounter{}
+	handler := inFlight.wrap(h.makeAccessLogHandler(h.makeCORSWrappedHTTPHandler(router)))
 	address := ":" + h.Port
 	server := h.makeHttpServerFrom(address, handler)
 
 	go func() {
-		errs <- server.ListenAndServe()
+		if h.TLS != nil {
+			// The certificate comes from the TLS config
+			errs <- server.ListenAndServeTLS("", "")
+		} else {
+			errs <- server.ListenAndServe()
+		}
 	}()
 
-	h.Logger.Info("Serving from port " + h.Port)
-	h.Logger.Info((<-errs).Error())
-
-	gracefulShutdownTime := time.Duration(h.GracefulShutdownSeconds) * time.Second
-	ctx, cancel := context.WithTimeout(context.Background(), gracefulShutdownTime)
-	defer cancel()
-
-	if err := server.Shutdown(ctx); err != nil {
-		h.Logger.Info(fmt.Sprintf("Server Shutdown Failed:%+v", err))
+	if h.TLS != nil {
+		h.Logger.Info("Serving TLS from port " + h.Port)
 	} else {
-		h.Logger.Info("Graceful shutdown completed")
+		h.Logger.Info("Serving from port " + h.Port)
 	}
+	h.Logger.Info((<-errs).Error())
+
+	h.drain(server, inFlight)
 }
 
 func (h HttpServer) registerServices(router *mux.Router) {
@@ -81,18 +139,51 @@ func (h HttpServer) registerServices(router *mux.Router) {
 		Logger:             h.Logger,
 		TranslateV2Wrapper: h.GoogleTranslateV2Wrapper,
 		TranslateV3Wrapper: h.GoogleTranslateV3Wrapper,
+		LocalGlossary:      h.LocalGlossary,
+		GlossarySyncer:     h.GlossarySyncer,
+		GlossaryManifest:   h.GlossaryManifest,
+		GlossaryCatalog:    h.GlossaryCatalog,
+		Providers:          h.Providers,
+		Router:             h.Router,
+		Tenants:            h.Tenants,
+		PriceTable:         h.PriceTable,
+	}
+	routingSvc := routingtransport.RoutingService{
+		Logger: h.Logger,
+		Router: h.Router,
+	}
+	usageSvc := usagetransport.UsageService{
+		Logger: h.Logger,
+		Store:  h.UsageStore,
+	}
+	adminSvc := admintransport.AdminService{
+		Logger:         h.Logger,
+		ConfigReloader: h.ConfigReloader,
 	}
 
 	h.registerRoutes(
 		router,
 		googleTranslateSvc,
+		routingSvc,
+		usageSvc,
+		adminSvc,
 	)
 }
 
 func (h HttpServer) makeHttpServerFrom(address string, handler http.Handler) *http.Server {
 	var server *http.Server
 
-	if h.EnableHTTP2 {
+	if h.TLS != nil {
+		// HTTP/2 is negotiated over TLS, so h2c is not needed
+		server = &http.Server{
+			Addr:      address,
+			Handler:   handler,
+			TLSConfig: h.TLS.TLSConfig(),
+		}
+		if !h.EnableHTTP2 {
+			server.TLSNextProto = map[string]func(*http.Server, *tls.Conn, http.Handler){}
+		}
+	} else if h.EnableHTTP2 {
 		h2s := &http2.Server{}
 		server = &http.Server{
 			Addr:    address,
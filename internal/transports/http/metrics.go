@@ -0,0 +1,34 @@
+package http
+
+import (
+	"net/http"
+
+	"github.com/gorilla/mux"
+)
+
+// makeMetricsMiddleware counts and times requests by route template, so
+// path parameters do not each get their own series
+func (h HttpServer) makeMetricsMiddleware() mux.MiddlewareFunc {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			done := h.Metrics.StartRequest()
+			recorder := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+
+			next.ServeHTTP(recorder, r)
+
+			done(routeTemplate(r), r.Method, recorder.status)
+		})
+	}
+}
+
+// routeTemplate is the path template of the matched route, eg.
+// "/google-translate/v3/glossaries", or the path when no route matched
+func routeTemplate(r *http.Request) string {
+	if route := mux.CurrentRoute(r); route != nil {
+		if template, err := route.GetPathTemplate(); err == nil {
+			return template
+		}
+	}
+
+	return r.URL.Path
+}
@@ -5,42 +5,70 @@ import (
 	"net/http"
 	"os"
 	"os/signal"
+	"sync/atomic"
 	"syscall"
+	"time"
 
 	"github.com/gorilla/mux"
+
+	"github.com/weiyuan-lane/google-translate-api/internal/services/health"
+	"github.com/weiyuan-lane/google-translate-api/internal/types/httpresponses"
+	httputils "github.com/weiyuan-lane/google-translate-api/internal/utils/http"
 )
 
-var sigtermCalled = false
+var sigtermCalled atomic.Bool
 
+// initSigtermListener subscribes before returning, so a SIGTERM sent once
+// the server is up is never missed
 func (h HttpServer) initSigtermListener(errs chan error) {
-	go func() {
-		c := make(chan os.Signal)
-		signal.Notify(c, syscall.SIGINT, syscall.SIGTERM)
+	c := make(chan os.Signal, 1)
+	signal.Notify(c, syscall.SIGINT, syscall.SIGTERM)
 
+	go func() {
 		err := <-c
-		sigtermCalled = true
+		sigtermCalled.Store(true)
 		errs <- fmt.Errorf("%s", err)
 	}()
 }
 
 func (h HttpServer) registerReadinessRoute(rtr *mux.Router) {
-	handler := http.HandlerFunc(makeReadinessHTTPHandler())
+	handler := http.HandlerFunc(makeReadinessHTTPHandler(h.Readiness))
 	rtr.Methods("GET").
 		Path("/readiness").
 		Handler(handler)
 }
 
-func makeReadinessHTTPHandler() http.HandlerFunc {
+// makeReadinessHTTPHandler answers from the cached results of checker, so
+// probes never wait on Google. Without a checker, only SIGTERM fails it.
+func makeReadinessHTTPHandler(checker *health.Checker) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
-		w.Header().Set("Content-Type", "application/json")
+		response := httpresponses.ReadinessResponse{Ready: !sigtermCalled.Load()}
 
-		if sigtermCalled {
-			w.WriteHeader(503)
-			w.Write([]byte("{\"ready\": false}"))
-			return
+		if checker != nil {
+			response.Ready = response.Ready && checker.Ready()
+
+			for _, result := range checker.Results() {
+				check := httpresponses.ReadinessCheck{
+					Name:      result.Name,
+					Critical:  result.Critical,
+					Status:    result.Status,
+					Error:     result.Error,
+					LatencyMS: result.Latency.Milliseconds(),
+				}
+				if !result.CheckedAt.IsZero() {
+					check.CheckedAt = result.CheckedAt.UTC().Format(time.RFC3339)
+				}
+				response.Checks = append(response.Checks, check)
+			}
+		}
+
+		statusCode := http.StatusOK
+		if !response.Ready {
+			statusCode = http.StatusServiceUnavailable
 		}
 
-		w.WriteHeader(200)
-		w.Write([]byte("{\"ready\": true}"))
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(statusCode)
+		httputils.EncodeJSONResponse(w, response)
 	}
 }
@@ -5,12 +5,18 @@ import (
 
 	"github.com/NYTimes/gziphandler"
 	"github.com/gorilla/mux"
+	"github.com/weiyuan-lane/google-translate-api/internal/transports/http/services/admin"
 	"github.com/weiyuan-lane/google-translate-api/internal/transports/http/services/googletranslate"
+	"github.com/weiyuan-lane/google-translate-api/internal/transports/http/services/routing"
+	"github.com/weiyuan-lane/google-translate-api/internal/transports/http/services/usage"
 )
 
 func (h HttpServer) registerRoutes(
 	rtr *mux.Router,
 	googleTranslateService googletranslate.GoogleTranslateService,
+	routingService routing.RoutingService,
+	usageService usage.UsageService,
+	adminService admin.AdminService,
 ) {
 
 	rtr.Methods("POST").Path("/google-translate/v2/translate").Handler(googleTranslateService.GoogleTranslateV2TranslateHandler())
@@ -21,12 +27,23 @@ func (h HttpServer) registerRoutes(
 
 	rtr.Methods("POST").Path("/google-translate/translate").Handler(googleTranslateService.GoogleTranslateTranslateHandler())
 	rtr.Methods("POST").Path("/google-translate/detect").Handler(googleTranslateService.GoogleTranslateDetectHandler())
+	rtr.Methods("POST").Path("/google-translate/estimate").Handler(googleTranslateService.GoogleTranslateEstimateHandler())
 
 	rtr.Methods("GET").Path("/google-translate/v3/glossaries").Handler(googleTranslateService.GoogleTranslateListGlossaryHandler())
 	rtr.Methods("POST").Path("/google-translate/v3/glossaries").Handler(googleTranslateService.GoogleTranslateCreateGlossaryHandler())
 	rtr.Methods("DELETE").Path("/google-translate/v3/glossaries").Handler(googleTranslateService.GoogleTranslateDeleteGlossaryHandler())
+	rtr.Methods("POST").Path("/google-translate/v3/glossaries/sync").Handler(googleTranslateService.GoogleTranslateSyncGlossariesHandler())
 
-	registerMiddlewares(rtr)
+	rtr.Methods("GET").Path("/routing/explain").Handler(routingService.ExplainHandler())
+
+	rtr.Methods("GET").Path("/usage").Handler(usageService.UsageHandler())
+
+	if adminService.ConfigReloader != nil {
+		rtr.Methods("GET").Path("/admin/config").Handler(adminService.ConfigHandler())
+		rtr.Methods("POST").Path("/admin/config/reload").Handler(adminService.ConfigReloadHandler())
+	}
+
+	h.registerMiddlewares(rtr)
 	registerFallbackRoute(rtr)
 }
 
@@ -38,7 +55,18 @@ func registerFallbackRoute(rtr *mux.Router) {
 	})
 }
 
-func registerMiddlewares(rtr *mux.Router) {
+func (h HttpServer) registerMiddlewares(rtr *mux.Router) {
+	rtr.Use(recordRoute)
+	rtr.Use(h.makeTracingMiddleware())
+
+	if h.Metrics != nil {
+		rtr.Use(h.makeMetricsMiddleware())
+	}
+
+	if h.Authenticator.IsEnabled() {
+		rtr.Use(h.makeAuthMiddleware())
+	}
+
 	rtr.Use(
 		gziphandler.GzipHandler,
 	)
@@ -0,0 +1,84 @@
+package admin
+
+import (
+	"net/http"
+
+	"github.com/weiyuan-lane/google-translate-api/internal/services/configreload"
+	"github.com/weiyuan-lane/google-translate-api/internal/types/httpresponses"
+	"github.com/weiyuan-lane/google-translate-api/internal/utils/config"
+	"github.com/weiyuan-lane/google-translate-api/internal/utils/errorhandlers"
+	httputils "github.com/weiyuan-lane/google-translate-api/internal/utils/http"
+	loggerutils "github.com/weiyuan-lane/google-translate-api/internal/utils/logger"
+)
+
+type AdminService struct {
+	Logger         *loggerutils.Logger
+	ConfigReloader *configreload.Reloader
+}
+
+// ConfigHandler lists the settings in effect, with secrets masked
+func (s AdminService) ConfigHandler() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		logger := s.Logger.ForContext(r.Context())
+		current := s.ConfigReloader.Current()
+
+		settings := []httpresponses.ConfigSetting{}
+		for _, setting := range current.Settings() {
+			settings = append(settings, httpresponses.ConfigSetting{
+				Name:       setting.Name,
+				Value:      setting.Value,
+				Source:     setting.Source,
+				Reloadable: config.IsReloadable(setting.Name),
+			})
+		}
+
+		w.Header().Set("Content-Type", "application/json; charset=utf-8")
+		w.WriteHeader(http.StatusOK)
+		wrappedErr := httputils.EncodeJSONResponse(w, httpresponses.ConfigResponse{
+			File:     current.File(),
+			Settings: settings,
+		})
+		if wrappedErr != nil {
+			errorhandlers.HandleHTTPError(logger, wrappedErr, w)
+			return
+		}
+	}
+}
+
+// ConfigReloadHandler reloads the config as on SIGHUP, and lists what
+// changed
+func (s AdminService) ConfigReloadHandler() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		logger := s.Logger.ForContext(r.Context())
+
+		result, wrappedErr := s.ConfigReloader.Reload()
+		if wrappedErr != nil {
+			errorhandlers.HandleHTTPError(logger, wrappedErr, w)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json; charset=utf-8")
+		w.WriteHeader(http.StatusOK)
+		wrappedErr = httputils.EncodeJSONResponse(w, httpresponses.ConfigReloadResponse{
+			Applied:  toResponseChanges(result.Applied),
+			Rejected: toResponseChanges(result.Rejected),
+		})
+		if wrappedErr != nil {
+			errorhandlers.HandleHTTPError(logger, wrappedErr, w)
+			return
+		}
+	}
+}
+
+func toResponseChanges(changes []config.Change) []httpresponses.ConfigChange {
+	resChanges := make([]httpresponses.ConfigChange, len(changes))
+	for i, change := range changes {
+		resChanges[i] = httpresponses.ConfigChange{
+			Name: change.Name,
+			From: change.From,
+			To:   change.To,
+		}
+	}
+
+	return resChanges
+}
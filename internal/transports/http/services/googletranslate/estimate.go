@@ -0,0 +1,182 @@
+package googletranslate
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/weiyuan-lane/google-translate-api/internal/services/googletranslatewrapper"
+	"github.com/weiyuan-lane/google-translate-api/internal/services/translationproviders"
+	"github.com/weiyuan-lane/google-translate-api/internal/services/usage"
+	"github.com/weiyuan-lane/google-translate-api/internal/types/httprequests"
+	"github.com/weiyuan-lane/google-translate-api/internal/types/httpresponses"
+	"github.com/weiyuan-lane/google-translate-api/internal/utils/errorhandlers"
+	httputils "github.com/weiyuan-lane/google-translate-api/internal/utils/http"
+)
+
+// estimate is where a translate request would go, without sending it
+type estimate struct {
+	provider string
+	backend  string
+	model    string
+	glossary string
+	rule     string
+}
+
+// GoogleTranslateEstimateHandler counts the billable characters of a
+// translate request body, and prices them with the price table, without
+// calling any provider. Bodies with a glossary or model are estimated as V3
+// translations, the others as unified translations. Translations take a
+// single text, sent in one call without chunking, so there is one segment
+// and one upstream call.
+func (g GoogleTranslateService) GoogleTranslateEstimateHandler() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		g, tenantErr := g.forTenant(r)
+		if tenantErr != nil {
+			errorhandlers.HandleHTTPError(g.Logger, tenantErr, w)
+			return
+		}
+
+		requestBody := httprequests.GoogleTranslateTranslateRequestBody{}
+
+		// Decode http body logic
+		wrappedErr := httputils.DecodeJSONBody(r, &requestBody)
+		if wrappedErr != nil {
+			g.Logger.Info(g.Logger.RedactUserContent(wrappedErr.Error()))
+			errorhandlers.HandleHTTPError(g.Logger, wrappedErr, w)
+			return
+		}
+
+		if requestBody.Text == "" {
+			wrappedErr := errorhandlers.Wrap(
+				errorhandlers.ErrTranslateEndpointMissingTextBodyParam,
+				"\"text\" field in body is empty",
+			)
+			errorhandlers.HandleHTTPError(g.Logger, wrappedErr, w)
+			return
+		}
+
+		if requestBody.TargetLocale == "" {
+			wrappedErr := errorhandlers.Wrap(
+				errorhandlers.ErrTranslateEndpointMissingTextBodyParam,
+				"\"target_locale\" field in body is empty",
+			)
+			errorhandlers.HandleHTTPError(g.Logger, wrappedErr, w)
+			return
+		}
+
+		if wrappedErr := g.checkTenantLanguages(requestBody.SourceLocale, requestBody.TargetLocale); wrappedErr != nil {
+			errorhandlers.HandleHTTPError(g.Logger, wrappedErr, w)
+			return
+		}
+
+		// Google sees the text with local glossary terms protected
+		protectedText, wrappedErr := g.protectLocalGlossaryTerms(requestBody)
+		if wrappedErr != nil {
+			errorhandlers.HandleHTTPError(g.Logger, wrappedErr, w)
+			return
+		}
+
+		estimated := g.estimateRoute(requestBody)
+		if !translationproviders.IsGoogle(estimated.provider) {
+			if _, wrappedErr := g.Providers.Lookup(estimated.provider); wrappedErr != nil {
+				errorhandlers.HandleHTTPError(g.Logger, wrappedErr, w)
+				return
+			}
+		}
+
+		characters, glossaryCharacters := usage.Characters(protectedText.Text), int64(0)
+		if estimated.glossary != "" {
+			glossaryCharacters = characters
+		}
+
+		response := httpresponses.GoogleTranslateEstimateResponse{
+			Provider:           estimated.provider,
+			Backend:            estimated.backend,
+			Model:              estimated.model,
+			Glossary:           estimated.glossary,
+			Rule:               estimated.rule,
+			Segments:           1,
+			UpstreamCalls:      1,
+			Characters:         characters,
+			GlossaryCharacters: glossaryCharacters,
+			BillableCharacters: characters + glossaryCharacters,
+			CacheHitRate:       0,
+		}
+
+		priceKey := estimated.provider
+		if translationproviders.IsGoogle(estimated.provider) {
+			priceKey = estimated.backend
+		}
+		if price, ok := g.PriceTable.Lookup(priceKey, estimated.model); ok {
+			response.EstimatedPrice = &httpresponses.GoogleTranslateEstimatedPrice{
+				Amount:               price.Amount(response.BillableCharacters),
+				Currency:             price.Currency,
+				PriceKey:             price.Key,
+				PerMillionCharacters: price.PerMillionCharacters,
+			}
+		}
+
+		// Encoding for http response
+		w.Header().Set("Content-Type", "application/json; charset=utf-8")
+		w.WriteHeader(http.StatusOK)
+		wrappedErr = httputils.EncodeJSONResponse(w, response)
+		if wrappedErr != nil {
+			errorhandlers.HandleHTTPError(g.Logger, wrappedErr, w)
+			return
+		}
+	}
+}
+
+// estimateRoute follows the same choices as the V3 translate handler and
+// translateWithRoute
+func (g GoogleTranslateService) estimateRoute(requestBody httprequests.GoogleTranslateTranslateRequestBody) estimate {
+	glossaryID := requestBody.Glossary.ID
+	if glossaryID == "" {
+		glossaryID = g.tenantDefaultGlossary(requestBody.SourceLocale)
+	}
+	if requestBody.Glossary.ID != "" || requestBody.Model != "" {
+		return estimate{
+			provider: translationproviders.ProviderGoogle,
+			backend:  googletranslatewrapper.BackendV3,
+			model:    requestBody.Model,
+			glossary: glossaryID,
+		}
+	}
+
+	if requestBody.Provider != "" && !translationproviders.IsGoogle(requestBody.Provider) {
+		return estimate{provider: strings.ToLower(requestBody.Provider)}
+	}
+
+	useV3API := requestBody.UseV3API
+	if g.Router != nil && requestBody.Provider == "" {
+		decision := g.Router.Match(requestBody.SourceLocale, requestBody.TargetLocale, g.tenantID())
+		if decision.Matched {
+			rule := decision.Rule
+			switch {
+			case !decision.IsGoogle():
+				return estimate{provider: rule.Provider, rule: rule.Name}
+			case rule.Model != "" || rule.Glossary != "":
+				return estimate{
+					provider: translationproviders.ProviderGoogle,
+					backend:  googletranslatewrapper.BackendV3,
+					model:    rule.Model,
+					glossary: rule.Glossary,
+					rule:     rule.Name,
+				}
+			case rule.Backend != "":
+				useV3API = decision.UsesV3()
+			}
+
+			return estimate{
+				provider: translationproviders.ProviderGoogle,
+				backend:  g.TranslateV2Wrapper.PrimaryBackend(useV3API),
+				rule:     rule.Name,
+			}
+		}
+	}
+
+	return estimate{
+		provider: translationproviders.ProviderGoogle,
+		backend:  g.TranslateV2Wrapper.PrimaryBackend(useV3API),
+	}
+}
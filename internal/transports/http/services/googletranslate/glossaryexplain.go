@@ -0,0 +1,36 @@
+package googletranslate
+
+import (
+	"github.com/weiyuan-lane/google-translate-api/internal/types/httpresponses"
+)
+
+func (g GoogleTranslateService) makeGlossaryExplanationResponse(glossaryID, input, translatedText, glossaryTranslatedText string) *httpresponses.GoogleTranslateGlossaryExplanation {
+	explanation := g.GlossaryCatalog.Explain(glossaryID, input, translatedText, glossaryTranslatedText)
+
+	diff := make([]httpresponses.GoogleTranslateDiffChunk, len(explanation.Diff))
+	for i, chunk := range explanation.Diff {
+		diff[i] = httpresponses.GoogleTranslateDiffChunk{
+			Op:   chunk.Op,
+			Text: chunk.Text,
+		}
+	}
+
+	terms := make([]httpresponses.GoogleTranslateGlossaryTermCoverage, len(explanation.TermCoverage))
+	for i, coverage := range explanation.TermCoverage {
+		terms[i] = httpresponses.GoogleTranslateGlossaryTermCoverage{
+			SourceTerm:     coverage.SourceTerm,
+			TargetTerm:     coverage.TargetTerm,
+			FoundInInput:   coverage.FoundInInput,
+			TargetInOutput: coverage.TargetInOutput,
+		}
+	}
+
+	return &httpresponses.GoogleTranslateGlossaryExplanation{
+		EntriesKnown:    explanation.EntriesKnown,
+		GlossaryChanged: explanation.GlossaryChanged,
+		TermsFound:      explanation.TermsFound,
+		TermsSatisfied:  explanation.TermsSatisfied,
+		Diff:            diff,
+		Terms:           terms,
+	}
+}
@@ -2,34 +2,61 @@ package googletranslate
 
 import (
 	"context"
+	"fmt"
 	"net/http"
 
 	"golang.org/x/text/language"
 
+	"github.com/weiyuan-lane/google-translate-api/internal/services/glossaryexplain"
+	"github.com/weiyuan-lane/google-translate-api/internal/services/glossarysync"
 	"github.com/weiyuan-lane/google-translate-api/internal/services/googletranslatewrapper"
+	"github.com/weiyuan-lane/google-translate-api/internal/services/localglossary"
+	"github.com/weiyuan-lane/google-translate-api/internal/services/routing"
+	"github.com/weiyuan-lane/google-translate-api/internal/services/tenancy"
+	"github.com/weiyuan-lane/google-translate-api/internal/services/translationproviders"
+	"github.com/weiyuan-lane/google-translate-api/internal/services/usage"
 	"github.com/weiyuan-lane/google-translate-api/internal/types/httprequests"
 	"github.com/weiyuan-lane/google-translate-api/internal/types/httpresponses"
 	"github.com/weiyuan-lane/google-translate-api/internal/utils/errorhandlers"
 	httputils "github.com/weiyuan-lane/google-translate-api/internal/utils/http"
 	loggerutils "github.com/weiyuan-lane/google-translate-api/internal/utils/logger"
+	"github.com/weiyuan-lane/google-translate-api/internal/utils/textdiff"
+	"github.com/weiyuan-lane/google-translate-api/internal/utils/tracing"
 )
 
 type GoogleTranslateService struct {
 	Logger             *loggerutils.Logger
 	TranslateV2Wrapper googletranslatewrapper.TranslateV2Wrapper
 	TranslateV3Wrapper googletranslatewrapper.TranslateV3Wrapper
+	LocalGlossary      *localglossary.Engine
+	GlossarySyncer     *glossarysync.Syncer
+	GlossaryManifest   string
+	GlossaryCatalog    glossaryexplain.Catalog
+	Providers          translationproviders.Registry
+	Router             *routing.Router
+	Tenants            *tenancy.Registry
+	PriceTable         usage.PriceTable
+
+	// Set on the copy of the service serving a tenant's request
+	tenant *tenancy.Tenant
 }
 
 func (g GoogleTranslateService) GoogleTranslateV2TranslateHandler() http.HandlerFunc {
 
 	return func(w http.ResponseWriter, r *http.Request) {
-		ctx := context.Background()
+		ctx := httputils.DetachedContext(r)
+
+		g, tenantErr := g.forTenant(r)
+		if tenantErr != nil {
+			errorhandlers.HandleHTTPError(g.Logger, tenantErr, w)
+			return
+		}
 		requestBody := httprequests.GoogleTranslateTranslateRequestBody{}
 
 		// Decode http body logic
 		wrappedErr := httputils.DecodeJSONBody(r, &requestBody)
 		if wrappedErr != nil {
-			g.Logger.Info(wrappedErr.Error())
+			g.Logger.Info(g.Logger.RedactUserContent(wrappedErr.Error()))
 			errorhandlers.HandleHTTPError(g.Logger, wrappedErr, w)
 			return
 		}
@@ -52,7 +79,12 @@ func (g GoogleTranslateService) GoogleTranslateV2TranslateHandler() http.Handler
 			return
 		}
 
-		localeTag, err := language.Parse(requestBody.TargetLocale)
+		if wrappedErr := g.checkTenantLanguages(requestBody.SourceLocale, requestBody.TargetLocale); wrappedErr != nil {
+			errorhandlers.HandleHTTPError(g.Logger, wrappedErr, w)
+			return
+		}
+
+		localeTag, err := parseLocale(ctx, requestBody.TargetLocale)
 		if err != nil {
 			wrappedErr := errorhandlers.Wrap(
 				errorhandlers.ErrTranslateEndpointMissingTextBodyParam,
@@ -62,25 +94,34 @@ func (g GoogleTranslateService) GoogleTranslateV2TranslateHandler() http.Handler
 			return
 		}
 
+		protectedText, wrappedErr := g.protectLocalGlossaryTerms(requestBody)
+		if wrappedErr != nil {
+			errorhandlers.HandleHTTPError(g.Logger, wrappedErr, w)
+			return
+		}
+
 		// Main service handler
-		translation, wrappedErr := g.TranslateV2Wrapper.TranslateText(ctx, requestBody.Text, localeTag)
+		translation, wrappedErr := g.TranslateV2Wrapper.TranslateText(ctx, protectedText.Text, localeTag)
 		if wrappedErr != nil {
 			errorhandlers.HandleHTTPError(g.Logger, wrappedErr, w)
 			return
 		}
 
+		translatedText, appliedTerms := protectedText.Restore(translation.TranslatedText)
+
 		// Encoding for http response
 		w.Header().Set("Content-Type", "application/json; charset=utf-8")
 		w.WriteHeader(http.StatusCreated)
 		wrappedErr = httputils.EncodeJSONResponse(w, httpresponses.GoogleTranslateTranslatedResponse{
 			OriginalContent: httpresponses.GoogleTranslateOriginalContent{
-				Text:           translation.OriginalText,
+				Text:           requestBody.Text,
 				DetectedLocale: translation.DetectedLang.String(),
 			},
 			TranslatedContent: httpresponses.GoogleTranslateTranslatedContent{
-				Text:   translation.TranslatedText,
+				Text:   translatedText,
 				Locale: translation.TargetLang.String(),
 			},
+			LocalGlossaryTerms: makeAppliedTermsResponse(appliedTerms),
 		})
 		if wrappedErr != nil {
 			errorhandlers.HandleHTTPError(g.Logger, wrappedErr, w)
@@ -92,13 +133,19 @@ func (g GoogleTranslateService) GoogleTranslateV2TranslateHandler() http.Handler
 func (g GoogleTranslateService) GoogleTranslateV2DetectHandler() http.HandlerFunc {
 
 	return func(w http.ResponseWriter, r *http.Request) {
-		ctx := context.Background()
+		ctx := httputils.DetachedContext(r)
+
+		g, tenantErr := g.forTenant(r)
+		if tenantErr != nil {
+			errorhandlers.HandleHTTPError(g.Logger, tenantErr, w)
+			return
+		}
 		requestBody := httprequests.GoogleTranslateDetectRequestBody{}
 
 		// Decode http body logic
 		wrappedErr := httputils.DecodeJSONBody(r, &requestBody)
 		if wrappedErr != nil {
-			g.Logger.Info(wrappedErr.Error())
+			g.Logger.Info(g.Logger.RedactUserContent(wrappedErr.Error()))
 			errorhandlers.HandleHTTPError(g.Logger, wrappedErr, w)
 			return
 		}
@@ -143,13 +190,19 @@ func (g GoogleTranslateService) GoogleTranslateV2DetectHandler() http.HandlerFun
 func (g GoogleTranslateService) GoogleTranslateV3TranslateHandler() http.HandlerFunc {
 
 	return func(w http.ResponseWriter, r *http.Request) {
-		ctx := context.Background()
+		ctx := httputils.DetachedContext(r)
+
+		g, tenantErr := g.forTenant(r)
+		if tenantErr != nil {
+			errorhandlers.HandleHTTPError(g.Logger, tenantErr, w)
+			return
+		}
 		requestBody := httprequests.GoogleTranslateTranslateRequestBody{}
 
 		// Decode http body logic
 		wrappedErr := httputils.DecodeJSONBody(r, &requestBody)
 		if wrappedErr != nil {
-			g.Logger.Info(wrappedErr.Error())
+			g.Logger.Info(g.Logger.RedactUserContent(wrappedErr.Error()))
 			errorhandlers.HandleHTTPError(g.Logger, wrappedErr, w)
 			return
 		}
@@ -172,10 +225,35 @@ func (g GoogleTranslateService) GoogleTranslateV3TranslateHandler() http.Handler
 			return
 		}
 
+		if wrappedErr := g.checkTenantLanguages(requestBody.SourceLocale, requestBody.TargetLocale); wrappedErr != nil {
+			errorhandlers.HandleHTTPError(g.Logger, wrappedErr, w)
+			return
+		}
+
+		if requestBody.ExplainGlossary && requestBody.Glossary.ID == "" {
+			wrappedErr := errorhandlers.Wrap(
+				errorhandlers.ErrTranslateEndpointInvalidExplainParam,
+				"\"explain_glossary\" needs the \"glossary.id\" field in body",
+			)
+			errorhandlers.HandleHTTPError(g.Logger, wrappedErr, w)
+			return
+		}
+
+		if requestBody.ExplainGlossary && len(textdiff.Tokens(requestBody.Text)) > textdiff.MaxTokens {
+			wrappedErr := errorhandlers.Wrap(
+				errorhandlers.ErrTranslateEndpointInvalidExplainParam,
+				fmt.Sprintf("\"explain_glossary\" is limited to texts of %d words, spaces and punctuation marks", textdiff.MaxTokens),
+			)
+			errorhandlers.HandleHTTPError(g.Logger, wrappedErr, w)
+			return
+		}
+
 		var glossaryIDPtr *string
 		if requestBody.Glossary.ID != "" {
 			glossaryID := requestBody.Glossary.ID
 			glossaryIDPtr = &glossaryID
+		} else if defaultGlossaryID := g.tenantDefaultGlossary(requestBody.SourceLocale); defaultGlossaryID != "" {
+			glossaryIDPtr = &defaultGlossaryID
 		} else {
 			glossaryIDPtr = nil
 		}
@@ -188,32 +266,58 @@ func (g GoogleTranslateService) GoogleTranslateV3TranslateHandler() http.Handler
 			sourceLangPtr = nil
 		}
 
+		var modelPtr *string
+		if requestBody.Model != "" {
+			model := requestBody.Model
+			modelPtr = &model
+		} else {
+			modelPtr = nil
+		}
+
+		protectedText, wrappedErr := g.protectLocalGlossaryTerms(requestBody)
+		if wrappedErr != nil {
+			errorhandlers.HandleHTTPError(g.Logger, wrappedErr, w)
+			return
+		}
+
 		// Main service handler
-		translation, wrappedErr := g.TranslateV3Wrapper.TranslateText(ctx, requestBody.Text, requestBody.TargetLocale, sourceLangPtr, glossaryIDPtr)
+		translation, wrappedErr := g.TranslateV3Wrapper.TranslateText(ctx, protectedText.Text, requestBody.TargetLocale, sourceLangPtr, glossaryIDPtr, modelPtr)
 		if wrappedErr != nil {
 			errorhandlers.HandleHTTPError(g.Logger, wrappedErr, w)
 			return
 		}
 
+		translatedText, appliedTerms := protectedText.Restore(translation.TranslatedText)
+
 		// Encoding for http response
 		w.Header().Set("Content-Type", "application/json; charset=utf-8")
 		w.WriteHeader(http.StatusCreated)
 		translatedResponse := httpresponses.GoogleTranslateTranslatedResponse{
 			OriginalContent: httpresponses.GoogleTranslateOriginalContent{
-				Text:           translation.OriginalText,
+				Text:           requestBody.Text,
 				DetectedLocale: translation.DetectedLang,
 			},
 			TranslatedContent: httpresponses.GoogleTranslateTranslatedContent{
-				Text:   translation.TranslatedText,
+				Text:   translatedText,
 				Locale: translation.TargetLang,
 			},
+			LocalGlossaryTerms: makeAppliedTermsResponse(appliedTerms),
 		}
-		if translation.GlossaryTranslatedText != "" {
+		glossaryTranslatedText, _ := protectedText.Restore(translation.GlossaryTranslatedText)
+		if glossaryTranslatedText != "" {
 			translatedResponse.GlossaryTranslatedContent = &httpresponses.GoogleTranslateTranslatedContent{
-				Text:   translation.GlossaryTranslatedText,
+				Text:   glossaryTranslatedText,
 				Locale: translation.TargetLang,
 			}
 		}
+		if requestBody.ExplainGlossary {
+			translatedResponse.GlossaryExplanation = g.makeGlossaryExplanationResponse(
+				requestBody.Glossary.ID,
+				requestBody.Text,
+				translatedText,
+				glossaryTranslatedText,
+			)
+		}
 
 		wrappedErr = httputils.EncodeJSONResponse(w, translatedResponse)
 		if wrappedErr != nil {
@@ -226,13 +330,19 @@ func (g GoogleTranslateService) GoogleTranslateV3TranslateHandler() http.Handler
 func (g GoogleTranslateService) GoogleTranslateV3DetectHandler() http.HandlerFunc {
 
 	return func(w http.ResponseWriter, r *http.Request) {
-		ctx := context.Background()
+		ctx := httputils.DetachedContext(r)
+
+		g, tenantErr := g.forTenant(r)
+		if tenantErr != nil {
+			errorhandlers.HandleHTTPError(g.Logger, tenantErr, w)
+			return
+		}
 		requestBody := httprequests.GoogleTranslateDetectRequestBody{}
 
 		// Decode http body logic
 		wrappedErr := httputils.DecodeJSONBody(r, &requestBody)
 		if wrappedErr != nil {
-			g.Logger.Info(wrappedErr.Error())
+			g.Logger.Info(g.Logger.RedactUserContent(wrappedErr.Error()))
 			errorhandlers.HandleHTTPError(g.Logger, wrappedErr, w)
 			return
 		}
@@ -277,13 +387,19 @@ func (g GoogleTranslateService) GoogleTranslateV3DetectHandler() http.HandlerFun
 func (g GoogleTranslateService) GoogleTranslateDetectHandler() http.HandlerFunc {
 
 	return func(w http.ResponseWriter, r *http.Request) {
-		ctx := context.Background()
+		ctx := httputils.DetachedContext(r)
+
+		g, tenantErr := g.forTenant(r)
+		if tenantErr != nil {
+			errorhandlers.HandleHTTPError(g.Logger, tenantErr, w)
+			return
+		}
 		requestBody := httprequests.GoogleTranslateDetectRequestBody{}
 
 		// Decode http body logic
 		wrappedErr := httputils.DecodeJSONBody(r, &requestBody)
 		if wrappedErr != nil {
-			g.Logger.Info(wrappedErr.Error())
+			g.Logger.Info(g.Logger.RedactUserContent(wrappedErr.Error()))
 			errorhandlers.HandleHTTPError(g.Logger, wrappedErr, w)
 			return
 		}
@@ -298,8 +414,9 @@ func (g GoogleTranslateService) GoogleTranslateDetectHandler() http.HandlerFunc
 		}
 
 		// Main service handler
-		detections, wrappedErr := g.TranslateV2Wrapper.DetectionsFromTextWithV3API(
+		detections, served, wrappedErr := g.detectionsFromProvider(
 			ctx,
+			requestBody.Provider,
 			requestBody.Text,
 			requestBody.UseV3API,
 		)
@@ -321,6 +438,7 @@ func (g GoogleTranslateService) GoogleTranslateDetectHandler() http.HandlerFunc
 		w.WriteHeader(http.StatusCreated)
 		wrappedErr = httputils.EncodeJSONResponse(w, httpresponses.GoogleTranslateDetectedResponse{
 			DetectedLocales: resDetections,
+			ServedBy:        makeServedByResponse(served),
 		})
 		if wrappedErr != nil {
 			errorhandlers.HandleHTTPError(g.Logger, wrappedErr, w)
@@ -332,13 +450,19 @@ func (g GoogleTranslateService) GoogleTranslateDetectHandler() http.HandlerFunc
 func (g GoogleTranslateService) GoogleTranslateTranslateHandler() http.HandlerFunc {
 
 	return func(w http.ResponseWriter, r *http.Request) {
-		ctx := context.Background()
+		ctx := httputils.DetachedContext(r)
+
+		g, tenantErr := g.forTenant(r)
+		if tenantErr != nil {
+			errorhandlers.HandleHTTPError(g.Logger, tenantErr, w)
+			return
+		}
 		requestBody := httprequests.GoogleTranslateTranslateRequestBody{}
 
 		// Decode http body logic
 		wrappedErr := httputils.DecodeJSONBody(r, &requestBody)
 		if wrappedErr != nil {
-			g.Logger.Info(wrappedErr.Error())
+			g.Logger.Info(g.Logger.RedactUserContent(wrappedErr.Error()))
 			errorhandlers.HandleHTTPError(g.Logger, wrappedErr, w)
 			return
 		}
@@ -361,7 +485,12 @@ func (g GoogleTranslateService) GoogleTranslateTranslateHandler() http.HandlerFu
 			return
 		}
 
-		localeTag, err := language.Parse(requestBody.TargetLocale)
+		if wrappedErr := g.checkTenantLanguages(requestBody.SourceLocale, requestBody.TargetLocale); wrappedErr != nil {
+			errorhandlers.HandleHTTPError(g.Logger, wrappedErr, w)
+			return
+		}
+
+		localeTag, err := parseLocale(ctx, requestBody.TargetLocale)
 		if err != nil {
 			wrappedErr := errorhandlers.Wrap(
 				errorhandlers.ErrTranslateEndpointMissingTextBodyParam,
@@ -371,30 +500,40 @@ func (g GoogleTranslateService) GoogleTranslateTranslateHandler() http.HandlerFu
 			return
 		}
 
+		protectedText, wrappedErr := g.protectLocalGlossaryTerms(requestBody)
+		if wrappedErr != nil {
+			errorhandlers.HandleHTTPError(g.Logger, wrappedErr, w)
+			return
+		}
+
 		// Main service handler
-		translation, wrappedErr := g.TranslateV2Wrapper.TranslateTextWithV3API(
+		translation, served, wrappedErr := g.translateWithRoute(
 			ctx,
-			requestBody.Text,
+			requestBody,
+			protectedText.Text,
 			localeTag,
-			requestBody.UseV3API,
 		)
 		if wrappedErr != nil {
 			errorhandlers.HandleHTTPError(g.Logger, wrappedErr, w)
 			return
 		}
 
+		translatedText, appliedTerms := protectedText.Restore(translation.TranslatedText)
+
 		// Encoding for http response
 		w.Header().Set("Content-Type", "application/json; charset=utf-8")
 		w.WriteHeader(http.StatusCreated)
 		wrappedErr = httputils.EncodeJSONResponse(w, httpresponses.GoogleTranslateTranslatedResponse{
 			OriginalContent: httpresponses.GoogleTranslateOriginalContent{
-				Text:           translation.OriginalText,
+				Text:           requestBody.Text,
 				DetectedLocale: translation.DetectedLang.String(),
 			},
 			TranslatedContent: httpresponses.GoogleTranslateTranslatedContent{
-				Text:   translation.TranslatedText,
+				Text:   translatedText,
 				Locale: translation.TargetLang.String(),
 			},
+			LocalGlossaryTerms: makeAppliedTermsResponse(appliedTerms),
+			ServedBy:           makeServedByResponse(served),
 		})
 		if wrappedErr != nil {
 			errorhandlers.HandleHTTPError(g.Logger, wrappedErr, w)
@@ -405,13 +544,19 @@ func (g GoogleTranslateService) GoogleTranslateTranslateHandler() http.HandlerFu
 
 func (g GoogleTranslateService) GoogleTranslateCreateGlossaryHandler() http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
-		ctx := context.Background()
+		ctx := httputils.DetachedContext(r)
+
+		g, tenantErr := g.forTenant(r)
+		if tenantErr != nil {
+			errorhandlers.HandleHTTPError(g.Logger, tenantErr, w)
+			return
+		}
 		requestBody := httprequests.GoogleTranslateCreateGlossaryBody{}
 
 		// Decode http body logic
 		wrappedErr := httputils.DecodeJSONBody(r, &requestBody)
 		if wrappedErr != nil {
-			g.Logger.Info(wrappedErr.Error())
+			g.Logger.Info(g.Logger.RedactUserContent(wrappedErr.Error()))
 			errorhandlers.HandleHTTPError(g.Logger, wrappedErr, w)
 			return
 		}
@@ -456,13 +601,19 @@ func (g GoogleTranslateService) GoogleTranslateCreateGlossaryHandler() http.Hand
 
 func (g GoogleTranslateService) GoogleTranslateDeleteGlossaryHandler() http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
-		ctx := context.Background()
+		ctx := httputils.DetachedContext(r)
+
+		g, tenantErr := g.forTenant(r)
+		if tenantErr != nil {
+			errorhandlers.HandleHTTPError(g.Logger, tenantErr, w)
+			return
+		}
 		requestBody := httprequests.GoogleTranslateDeleteGlossaryBody{}
 
 		// Decode http body logic
 		wrappedErr := httputils.DecodeJSONBody(r, &requestBody)
 		if wrappedErr != nil {
-			g.Logger.Info(wrappedErr.Error())
+			g.Logger.Info(g.Logger.RedactUserContent(wrappedErr.Error()))
 			errorhandlers.HandleHTTPError(g.Logger, wrappedErr, w)
 			return
 		}
@@ -495,7 +646,13 @@ func (g GoogleTranslateService) GoogleTranslateDeleteGlossaryHandler() http.Hand
 
 func (g GoogleTranslateService) GoogleTranslateListGlossaryHandler() http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
-		ctx := context.Background()
+		ctx := httputils.DetachedContext(r)
+
+		g, tenantErr := g.forTenant(r)
+		if tenantErr != nil {
+			errorhandlers.HandleHTTPError(g.Logger, tenantErr, w)
+			return
+		}
 
 		// Main service handler
 		glossaries, wrappedErr := g.TranslateV3Wrapper.ListGlossaries(ctx)
@@ -524,3 +681,111 @@ func (g GoogleTranslateService) GoogleTranslateListGlossaryHandler() http.Handle
 		}
 	}
 }
+
+func (g GoogleTranslateService) GoogleTranslateSyncGlossariesHandler() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := httputils.DetachedContext(r)
+		requestBody := httprequests.GoogleTranslateSyncGlossariesBody{}
+
+		// Decode http body logic
+		wrappedErr := httputils.DecodeJSONBody(r, &requestBody)
+		if wrappedErr != nil {
+			g.Logger.Info(g.Logger.RedactUserContent(wrappedErr.Error()))
+			errorhandlers.HandleHTTPError(g.Logger, wrappedErr, w)
+			return
+		}
+
+		if g.GlossarySyncer == nil || g.GlossaryManifest == "" {
+			wrappedErr := errorhandlers.Wrap(
+				errorhandlers.ErrGlossarySyncNotConfigured,
+				"Glossary sync requested but no glossary manifest is configured",
+			)
+			errorhandlers.HandleHTTPError(g.Logger, wrappedErr, w)
+			return
+		}
+
+		manifest, wrappedErr := glossarysync.LoadManifest(g.GlossaryManifest)
+		if wrappedErr != nil {
+			errorhandlers.HandleHTTPError(g.Logger, wrappedErr, w)
+			return
+		}
+
+		// Main service handler
+		plan, wrappedErr := g.GlossarySyncer.Sync(ctx, manifest, requestBody.DryRun)
+		if !requestBody.DryRun {
+			g.logAppliedGlossarySync(plan)
+		}
+
+		// On failure, the plan holds the items applied before it
+		if wrappedErr != nil {
+			if requestBody.DryRun {
+				errorhandlers.HandleHTTPError(g.Logger, wrappedErr, w)
+				return
+			}
+
+			errorhandlers.HandleHTTPErrorWithDetails(g.Logger, wrappedErr, w, httpresponses.GoogleTranslateSyncGlossariesFailure{
+				Applied: makeGlossarySyncItems(plan),
+			})
+			return
+		}
+
+		planItems := makeGlossarySyncItems(plan)
+
+		// Encoding for http response
+		w.Header().Set("Content-Type", "application/json; charset=utf-8")
+		w.WriteHeader(http.StatusOK)
+		wrappedErr = httputils.EncodeJSONResponse(w, httpresponses.GoogleTranslateSyncGlossariesResponse{
+			DryRun: requestBody.DryRun,
+			Plan:   planItems,
+		})
+		if wrappedErr != nil {
+			errorhandlers.HandleHTTPError(g.Logger, wrappedErr, w)
+			return
+		}
+	}
+}
+
+func (g GoogleTranslateService) logAppliedGlossarySync(plan glossarysync.Plan) {
+	for _, item := range plan.Items {
+		if item.Action != glossarysync.ActionUnchanged {
+			g.Logger.Info("Glossary sync applied", map[string]string{
+				"action":     item.Action,
+				"glossary":   item.Name,
+				"gcs_source": item.GCSSource,
+			})
+		}
+	}
+}
+
+func makeGlossarySyncItems(plan glossarysync.Plan) []httpresponses.GoogleTranslateGlossarySyncItem {
+	items := make([]httpresponses.GoogleTranslateGlossarySyncItem, len(plan.Items))
+	for i, item := range plan.Items {
+		items[i] = httpresponses.GoogleTranslateGlossarySyncItem{
+			Action:           item.Action,
+			ID:               item.Name,
+			File:             item.File,
+			GCSSource:        item.GCSSource,
+			CurrentGCSSource: item.CurrentGCSSource,
+		}
+	}
+
+	return items
+}
+
+func makeServedByResponse(served googletranslatewrapper.Served) *httpresponses.GoogleTranslateServedBy {
+	return &httpresponses.GoogleTranslateServedBy{
+		Backend:        served.Backend,
+		FailedOverFrom: served.FailedOverFrom,
+		FailoverReason: served.FailoverReason,
+		Rule:           served.Rule,
+	}
+}
+
+// parseLocale is traced on its own, apart from the call to Google
+func parseLocale(ctx context.Context, locale string) (language.Tag, error) {
+	_, span := tracing.Start(ctx, "parse locale", tracing.TargetLocaleKey.String(locale))
+	localeTag, err := language.Parse(locale)
+	tracing.End(span, err)
+
+	return localeTag, err
+}
@@ -0,0 +1,40 @@
+package googletranslate
+
+import (
+	"github.com/weiyuan-lane/google-translate-api/internal/services/localglossary"
+	"github.com/weiyuan-lane/google-translate-api/internal/types/httprequests"
+	"github.com/weiyuan-lane/google-translate-api/internal/types/httpresponses"
+	"github.com/weiyuan-lane/google-translate-api/internal/utils/errorhandlers"
+)
+
+func (g GoogleTranslateService) protectLocalGlossaryTerms(requestBody httprequests.GoogleTranslateTranslateRequestBody) (localglossary.ProtectedText, error) {
+	if !requestBody.LocalGlossary {
+		return localglossary.ProtectedText{Text: requestBody.Text}, nil
+	}
+
+	if g.LocalGlossary == nil {
+		return localglossary.ProtectedText{}, errorhandlers.Wrap(
+			errorhandlers.ErrLocalGlossaryNotConfigured,
+			"\"local_glossary\" requested but no local glossary directory is configured",
+		)
+	}
+
+	return g.LocalGlossary.Protect(
+		requestBody.Text,
+		requestBody.SourceLocale,
+		requestBody.TargetLocale,
+	), nil
+}
+
+func makeAppliedTermsResponse(appliedTerms []localglossary.AppliedTerm) []httpresponses.GoogleTranslateAppliedTerm {
+	results := make([]httpresponses.GoogleTranslateAppliedTerm, len(appliedTerms))
+	for i, appliedTerm := range appliedTerms {
+		results[i] = httpresponses.GoogleTranslateAppliedTerm{
+			SourceTerm: appliedTerm.SourceTerm,
+			TargetTerm: appliedTerm.TargetTerm,
+			Count:      appliedTerm.Count,
+		}
+	}
+
+	return results
+}
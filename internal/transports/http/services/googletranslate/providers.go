@@ -0,0 +1,40 @@
+package googletranslate
+
+import (
+	"context"
+
+	"golang.org/x/text/language"
+
+	"github.com/weiyuan-lane/google-translate-api/internal/services/googletranslatewrapper"
+	"github.com/weiyuan-lane/google-translate-api/internal/services/translationproviders"
+)
+
+// translateWithProvider keeps requests without a provider on the Google
+// backends, where the "v3" flag and failover policy apply
+func (g GoogleTranslateService) translateWithProvider(ctx context.Context, providerName, text string, targetLocale language.Tag, useV3API bool) (googletranslatewrapper.Translation, googletranslatewrapper.Served, error) {
+	if translationproviders.IsGoogle(providerName) {
+		return g.TranslateV2Wrapper.TranslateTextWithV3API(ctx, text, targetLocale, useV3API)
+	}
+
+	provider, err := g.Providers.Lookup(providerName)
+	if err != nil {
+		return googletranslatewrapper.Translation{}, googletranslatewrapper.Served{}, err
+	}
+
+	translation, err := provider.TranslateText(ctx, text, targetLocale)
+	return translation, googletranslatewrapper.Served{Backend: provider.Name()}, err
+}
+
+func (g GoogleTranslateService) detectionsFromProvider(ctx context.Context, providerName, text string, useV3API bool) ([]googletranslatewrapper.Detection, googletranslatewrapper.Served, error) {
+	if translationproviders.IsGoogle(providerName) {
+		return g.TranslateV2Wrapper.DetectionsFromTextWithV3API(ctx, text, useV3API)
+	}
+
+	provider, err := g.Providers.Lookup(providerName)
+	if err != nil {
+		return []googletranslatewrapper.Detection{}, googletranslatewrapper.Served{}, err
+	}
+
+	detections, err := provider.DetectionsFromText(ctx, text)
+	return detections, googletranslatewrapper.Served{Backend: provider.Name()}, err
+}
@@ -0,0 +1,61 @@
+package googletranslate
+
+import (
+	"context"
+	"strings"
+
+	"golang.org/x/text/language"
+
+	"github.com/weiyuan-lane/google-translate-api/internal/services/googletranslatewrapper"
+	"github.com/weiyuan-lane/google-translate-api/internal/types/httprequests"
+)
+
+// translateWithRoute lets the routing rules pick the provider, backend,
+// model and glossary, unless the request names a provider itself
+func (g GoogleTranslateService) translateWithRoute(ctx context.Context, requestBody httprequests.GoogleTranslateTranslateRequestBody, text string, targetLocale language.Tag) (googletranslatewrapper.Translation, googletranslatewrapper.Served, error) {
+	if g.Router == nil || requestBody.Provider != "" {
+		return g.translateWithProvider(ctx, requestBody.Provider, text, targetLocale, requestBody.UseV3API)
+	}
+
+	decision := g.Router.Match(requestBody.SourceLocale, requestBody.TargetLocale, g.tenantID())
+	if !decision.Matched {
+		return g.translateWithProvider(ctx, "", text, targetLocale, requestBody.UseV3API)
+	}
+
+	rule := decision.Rule
+	var translation googletranslatewrapper.Translation
+	var served googletranslatewrapper.Served
+	var err error
+
+	switch {
+	case !decision.IsGoogle():
+		translation, served, err = g.translateWithProvider(ctx, rule.Provider, text, targetLocale, false)
+	case rule.Model != "" || rule.Glossary != "":
+		// Glossaries need a source locale, which an exact source pattern
+		// provides when the request does not
+		sourceLocale := requestBody.SourceLocale
+		if sourceLocale == "" && !strings.Contains(rule.Source, "*") {
+			sourceLocale = rule.Source
+		}
+
+		translation, served, err = g.TranslateV2Wrapper.TranslateTextWithV3Options(
+			ctx,
+			text,
+			targetLocale,
+			googletranslatewrapper.V3Options{
+				SourceLocale: sourceLocale,
+				GlossaryID:   rule.Glossary,
+				Model:        rule.Model,
+			},
+		)
+	default:
+		useV3API := requestBody.UseV3API
+		if rule.Backend != "" {
+			useV3API = decision.UsesV3()
+		}
+		translation, served, err = g.translateWithProvider(ctx, "", text, targetLocale, useV3API)
+	}
+
+	served.Rule = rule.Name
+	return translation, served, err
+}
@@ -0,0 +1,106 @@
+package googletranslate
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/weiyuan-lane/google-translate-api/internal/services/auth"
+	"github.com/weiyuan-lane/google-translate-api/internal/utils/errorhandlers"
+	"github.com/weiyuan-lane/google-translate-api/internal/utils/requestinfo"
+)
+
+const tenantHeader = "X-Tenant-ID"
+
+// forTenant returns a copy of the service using the wrappers of the tenant
+// of the request. When requests are authenticated, the tenant is the one of
+// the principal, and only admins may pick another with the "X-Tenant-ID"
+// header. Requests without a tenant keep the wrappers of the deployment.
+// The copy logs with the trace of the request either way.
+func (g GoogleTranslateService) forTenant(r *http.Request) (GoogleTranslateService, error) {
+	g.Logger = g.Logger.ForContext(r.Context())
+
+	if g.Tenants == nil {
+		return g, nil
+	}
+
+	tenantID := strings.TrimSpace(r.Header.Get(tenantHeader))
+	if principal, ok := auth.PrincipalFromContext(r.Context()); ok {
+		switch {
+		case principal.HasScope(auth.ScopeAdmin):
+			if tenantID == "" {
+				tenantID = principal.Tenant
+			}
+		case tenantID != "" && tenantID != principal.Tenant:
+			if principal.Tenant == "" {
+				return g, errorhandlers.Wrap(
+					errorhandlers.ErrTenantForbidden,
+					fmt.Sprintf("Client %q has no tenant and cannot act for tenant %q", principal.ID, tenantID),
+				)
+			}
+
+			return g, errorhandlers.Wrap(
+				errorhandlers.ErrTenantForbidden,
+				fmt.Sprintf("Client %q of tenant %q cannot act for tenant %q", principal.ID, principal.Tenant, tenantID),
+			)
+		default:
+			tenantID = principal.Tenant
+		}
+	}
+
+	if tenantID == "" {
+		return g, nil
+	}
+
+	tenant, err := g.Tenants.Lookup(tenantID)
+	if err != nil {
+		return g, err
+	}
+
+	backends, err := g.Tenants.Backends(tenant)
+	if err != nil {
+		return g, err
+	}
+
+	g.TranslateV2Wrapper = backends.V2
+	g.TranslateV3Wrapper = backends.V3
+	g.tenant = &tenant
+	requestinfo.FromContext(r.Context()).SetTenant(tenant.ID)
+
+	return g, nil
+}
+
+func (g GoogleTranslateService) tenantID() string {
+	if g.tenant == nil {
+		return ""
+	}
+
+	return g.tenant.ID
+}
+
+func (g GoogleTranslateService) checkTenantLanguages(locales ...string) error {
+	if g.tenant == nil {
+		return nil
+	}
+
+	for _, locale := range locales {
+		if locale != "" && !g.tenant.AllowsLanguage(locale) {
+			return errorhandlers.Wrap(
+				errorhandlers.ErrTenantLanguageNotAllowed,
+				fmt.Sprintf("Tenant %q is not allowed to translate %q, only %s", g.tenant.ID, locale, strings.Join(g.tenant.AllowedLanguages, ", ")),
+			)
+		}
+	}
+
+	return nil
+}
+
+// Glossaries need a source locale, so the default glossary of the tenant
+// only applies to requests giving one
+func (g GoogleTranslateService) tenantDefaultGlossary(sourceLocale string) string {
+	if g.tenant == nil || sourceLocale == "" {
+		return ""
+	}
+
+	return g.tenant.DefaultGlossary
+}
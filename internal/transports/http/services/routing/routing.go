@@ -0,0 +1,75 @@
+package routing
+
+import (
+	"net/http"
+	"time"
+
+	routingservice "github.com/weiyuan-lane/google-translate-api/internal/services/routing"
+	"github.com/weiyuan-lane/google-translate-api/internal/types/httpresponses"
+	"github.com/weiyuan-lane/google-translate-api/internal/utils/errorhandlers"
+	httputils "github.com/weiyuan-lane/google-translate-api/internal/utils/http"
+	loggerutils "github.com/weiyuan-lane/google-translate-api/internal/utils/logger"
+)
+
+type RoutingService struct {
+	Logger *loggerutils.Logger
+	Router *routingservice.Router
+}
+
+// ExplainHandler shows the rule that /google-translate/translate would apply
+// to a language pair
+func (s RoutingService) ExplainHandler() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		logger := s.Logger.ForContext(r.Context())
+
+		if s.Router == nil {
+			wrappedErr := errorhandlers.Wrap(
+				errorhandlers.ErrRoutingNotConfigured,
+				"No routing rules file is configured",
+			)
+			errorhandlers.HandleHTTPError(logger, wrappedErr, w)
+			return
+		}
+
+		query := r.URL.Query()
+		if query.Get("target") == "" {
+			wrappedErr := errorhandlers.Wrap(
+				errorhandlers.ErrRoutingExplainMissingTargetParam,
+				"\"target\" query param is empty",
+			)
+			errorhandlers.HandleHTTPError(logger, wrappedErr, w)
+			return
+		}
+
+		decision := s.Router.Match(query.Get("source"), query.Get("target"), query.Get("tenant"))
+		response := httpresponses.RoutingExplainResponse{
+			Matched:       decision.Matched,
+			RulesFile:     s.Router.Path(),
+			RulesCount:    s.Router.Len(),
+			RulesLoadedAt: s.Router.LoadedAt().UTC().Format(time.RFC3339),
+		}
+
+		if decision.Matched {
+			ruleIndex := decision.RuleIndex
+			response.RuleIndex = &ruleIndex
+			response.Rule = &httpresponses.RoutingRule{
+				Name:     decision.Rule.Name,
+				Source:   decision.Rule.Source,
+				Target:   decision.Rule.Target,
+				Tenant:   decision.Rule.Tenant,
+				Provider: decision.Rule.Provider,
+				Backend:  decision.Rule.Backend,
+				Model:    decision.Rule.Model,
+				Glossary: decision.Rule.Glossary,
+			}
+		}
+
+		w.Header().Set("Content-Type", "application/json; charset=utf-8")
+		w.WriteHeader(http.StatusOK)
+		wrappedErr := httputils.EncodeJSONResponse(w, response)
+		if wrappedErr != nil {
+			errorhandlers.HandleHTTPError(logger, wrappedErr, w)
+			return
+		}
+	}
+}
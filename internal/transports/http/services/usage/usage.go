@@ -0,0 +1,118 @@
+package usage
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	usageservice "github.com/weiyuan-lane/google-translate-api/internal/services/usage"
+	"github.com/weiyuan-lane/google-translate-api/internal/types/httpresponses"
+	"github.com/weiyuan-lane/google-translate-api/internal/utils/errorhandlers"
+	httputils "github.com/weiyuan-lane/google-translate-api/internal/utils/http"
+	loggerutils "github.com/weiyuan-lane/google-translate-api/internal/utils/logger"
+)
+
+type UsageService struct {
+	Logger *loggerutils.Logger
+	Store  usageservice.Store
+}
+
+// UsageHandler sums the billable characters between the "from" (inclusive)
+// and "to" (exclusive) query params, as dates or RFC 3339 times, grouped by
+// the comma separated fields of "group_by". It defaults to the current
+// month, grouped by tenant.
+func (s UsageService) UsageHandler() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := httputils.DetachedContext(r)
+		logger := s.Logger.ForContext(ctx)
+		query := r.URL.Query()
+
+		now := time.Now().UTC()
+		from, wrappedErr := parseUsageTime(query.Get("from"), "from", time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC))
+		if wrappedErr != nil {
+			errorhandlers.HandleHTTPError(logger, wrappedErr, w)
+			return
+		}
+
+		to, wrappedErr := parseUsageTime(query.Get("to"), "to", now)
+		if wrappedErr != nil {
+			errorhandlers.HandleHTTPError(logger, wrappedErr, w)
+			return
+		}
+
+		groupBy := []string{usageservice.GroupByTenant}
+		if query.Has("group_by") {
+			groupBy = []string{}
+			for _, field := range strings.Split(query.Get("group_by"), ",") {
+				if field = strings.TrimSpace(field); field != "" {
+					groupBy = append(groupBy, field)
+				}
+			}
+		}
+
+		entries, err := s.Store.Entries(ctx, from, to)
+		if err != nil {
+			wrappedErr := errorhandlers.Wrap(
+				errorhandlers.ErrUsageStoreFailed,
+				fmt.Sprintf("Usage could not be read: %s", err.Error()),
+			)
+			errorhandlers.HandleHTTPError(logger, wrappedErr, w)
+			return
+		}
+
+		summaries, err := usageservice.Summarize(entries, groupBy)
+		if err != nil {
+			wrappedErr := errorhandlers.Wrap(
+				errorhandlers.ErrUsageInvalidQuery,
+				fmt.Sprintf("\"group_by\" query param is invalid: %s", err.Error()),
+			)
+			errorhandlers.HandleHTTPError(logger, wrappedErr, w)
+			return
+		}
+
+		resSummaries := make([]httpresponses.UsageSummary, len(summaries))
+		for i, summary := range summaries {
+			resSummaries[i] = httpresponses.UsageSummary{
+				Group:              summary.Group,
+				Requests:           summary.Requests,
+				Characters:         summary.Characters,
+				GlossaryCharacters: summary.GlossaryCharacters,
+				BillableCharacters: summary.BillableCharacters(),
+			}
+		}
+
+		w.Header().Set("Content-Type", "application/json; charset=utf-8")
+		w.WriteHeader(http.StatusOK)
+		wrappedErr = httputils.EncodeJSONResponse(w, httpresponses.UsageResponse{
+			From:    from.Format(time.RFC3339),
+			To:      to.Format(time.RFC3339),
+			GroupBy: groupBy,
+			Usage:   resSummaries,
+		})
+		if wrappedErr != nil {
+			errorhandlers.HandleHTTPError(logger, wrappedErr, w)
+			return
+		}
+	}
+}
+
+func parseUsageTime(value, param string, defaultTime time.Time) (time.Time, error) {
+	if value == "" {
+		return defaultTime, nil
+	}
+
+	if parsed, err := time.Parse("2006-01-02", value); err == nil {
+		return parsed, nil
+	}
+
+	parsed, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return time.Time{}, errorhandlers.Wrap(
+			errorhandlers.ErrUsageInvalidQuery,
+			fmt.Sprintf("%q query param must be a date (2006-01-02) or an RFC 3339 time", param),
+		)
+	}
+
+	return parsed.UTC(), nil
+}
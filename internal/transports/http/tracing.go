@@ -0,0 +1,46 @@
+package http
+
+import (
+	"net/http"
+
+	"github.com/gorilla/mux"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	semconv "go.opentelemetry.io/otel/semconv/v1.21.0"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/weiyuan-lane/google-translate-api/internal/utils/requestinfo"
+	"github.com/weiyuan-lane/google-translate-api/internal/utils/tracing"
+)
+
+const requestIDKey = attribute.Key("http.request_id")
+
+// makeTracingMiddleware starts a server span per request, continuing the
+// trace of a "traceparent" header
+func (h HttpServer) makeTracingMiddleware() mux.MiddlewareFunc {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			route := routeTemplate(r)
+			ctx := tracing.ExtractHTTP(r.Context(), r.Header)
+			ctx, span := tracing.Tracer().Start(
+				ctx,
+				r.Method+" "+route,
+				trace.WithSpanKind(trace.SpanKindServer),
+				trace.WithAttributes(
+					semconv.HTTPMethod(r.Method),
+					semconv.HTTPRoute(route),
+					requestIDKey.String(requestinfo.IDFromContext(r.Context())),
+				),
+			)
+			defer span.End()
+
+			recorder := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+			next.ServeHTTP(recorder, r.WithContext(ctx))
+
+			span.SetAttributes(semconv.HTTPStatusCode(recorder.status))
+			if recorder.status >= http.StatusInternalServerError {
+				span.SetStatus(codes.Error, http.StatusText(recorder.status))
+			}
+		})
+	}
+}
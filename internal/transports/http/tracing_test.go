@@ -0,0 +1,134 @@
+package http
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gorilla/mux"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	semconv "go.opentelemetry.io/otel/semconv/v1.21.0"
+	"go.opentelemetry.io/otel/trace"
+
+	loggerutils "github.com/weiyuan-lane/google-translate-api/internal/utils/logger"
+	"github.com/weiyuan-lane/google-translate-api/internal/utils/tracing"
+)
+
+const (
+	incomingTraceID = "4bf92f3577b34da6a3ce929d0e0e4736"
+	incomingSpanID  = "00f067aa0ba902b7"
+)
+
+// recordSpans makes the global tracer provider record every span, for the
+// rest of the test
+func recordSpans(t *testing.T) *tracetest.SpanRecorder {
+	t.Helper()
+
+	recorder := tracetest.NewSpanRecorder()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+	t.Cleanup(func() { otel.SetTracerProvider(trace.NewNoopTracerProvider()) })
+
+	return recorder
+}
+
+// serveTraced serves r from a translate route that starts a span of its
+// own, and fails with status
+func serveTraced(t *testing.T, r *http.Request, status int) {
+	t.Helper()
+
+	h := HttpServer{Logger: loggerutils.New("test", false)}
+	router := mux.NewRouter()
+	router.Methods("POST").Path("/google-translate/translate").HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, span := tracing.Start(r.Context(), "TranslateV2Wrapper.TranslateTextWithV3API")
+		span.End()
+
+		w.WriteHeader(status)
+	})
+	router.Use(h.makeTracingMiddleware())
+
+	h.makeAccessLogHandler(router).ServeHTTP(httptest.NewRecorder(), r)
+}
+
+func spansByName(recorder *tracetest.SpanRecorder) map[string]sdktrace.ReadOnlySpan {
+	spans := map[string]sdktrace.ReadOnlySpan{}
+	for _, span := range recorder.Ended() {
+		spans[span.Name()] = span
+	}
+
+	return spans
+}
+
+func TestTracingMiddlewareContinuesIncomingTrace(t *testing.T) {
+	recorder := recordSpans(t)
+
+	r := httptest.NewRequest("POST", "/google-translate/translate", nil)
+	r.Header.Set("traceparent", "00-"+incomingTraceID+"-"+incomingSpanID+"-01")
+	r.Header.Set("X-Request-ID", "request-1")
+	serveTraced(t, r, http.StatusBadGateway)
+
+	spans := spansByName(recorder)
+	server, ok := spans["POST /google-translate/translate"]
+	if !ok {
+		t.Fatalf("spans = %v, want a server span named after the route", spans)
+	}
+
+	if server.SpanKind() != trace.SpanKindServer {
+		t.Errorf("span kind = %v, want %v", server.SpanKind(), trace.SpanKindServer)
+	}
+	if traceID := server.SpanContext().TraceID().String(); traceID != incomingTraceID {
+		t.Errorf("trace ID = %s, want the incoming %s", traceID, incomingTraceID)
+	}
+	if parent := server.Parent(); !parent.IsRemote() || parent.SpanID().String() != incomingSpanID {
+		t.Errorf("parent = %v, want the remote span %s", parent.SpanID(), incomingSpanID)
+	}
+	if server.Status().Code != codes.Error {
+		t.Errorf("span status = %v, want %v for a 502", server.Status().Code, codes.Error)
+	}
+
+	want := map[attribute.Key]attribute.Value{
+		semconv.HTTPMethodKey:     attribute.StringValue("POST"),
+		semconv.HTTPRouteKey:      attribute.StringValue("/google-translate/translate"),
+		semconv.HTTPStatusCodeKey: attribute.IntValue(http.StatusBadGateway),
+		requestIDKey:              attribute.StringValue("request-1"),
+	}
+	got := map[attribute.Key]attribute.Value{}
+	for _, attribute := range server.Attributes() {
+		got[attribute.Key] = attribute.Value
+	}
+	for key, value := range want {
+		if got[key] != value {
+			t.Errorf("attribute %s = %v, want %v", key, got[key].Emit(), value.Emit())
+		}
+	}
+
+	wrapper, ok := spans["TranslateV2Wrapper.TranslateTextWithV3API"]
+	if !ok {
+		t.Fatalf("spans = %v, want the span started by the handler", spans)
+	}
+	if wrapper.Parent().SpanID() != server.SpanContext().SpanID() {
+		t.Errorf("handler span parent = %v, want the server span %v", wrapper.Parent().SpanID(), server.SpanContext().SpanID())
+	}
+}
+
+func TestTracingMiddlewareStartsNewTrace(t *testing.T) {
+	recorder := recordSpans(t)
+
+	serveTraced(t, httptest.NewRequest("POST", "/google-translate/translate", nil), http.StatusOK)
+
+	server, ok := spansByName(recorder)["POST /google-translate/translate"]
+	if !ok {
+		t.Fatal("no server span was ended")
+	}
+	if server.Parent().IsValid() {
+		t.Errorf("parent = %v, want a root span without a traceparent", server.Parent().SpanID())
+	}
+	if server.Status().Code == codes.Error {
+		t.Errorf("span status = %v, want no error for a 200", server.Status().Code)
+	}
+}
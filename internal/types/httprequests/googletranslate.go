@@ -5,14 +5,19 @@ type GoogleTranslateTranslateRequestBody struct {
 	TargetLocale string `json:"target_locale"`
 	UseV3API     bool   `json:"v3"`
 	SourceLocale string `json:"source_locale"`
+	Model        string `json:"model"`
 	Glossary     struct {
 		ID string `json:"id"`
 	} `json:"glossary"`
+	LocalGlossary   bool   `json:"local_glossary"`
+	ExplainGlossary bool   `json:"explain_glossary"`
+	Provider        string `json:"provider"`
 }
 
 type GoogleTranslateDetectRequestBody struct {
 	Text     string `json:"text"`
 	UseV3API bool   `json:"v3"`
+	Provider string `json:"provider"`
 }
 
 type GoogleTranslateCreateGlossaryBody struct {
@@ -25,3 +30,7 @@ type GoogleTranslateCreateGlossaryBody struct {
 type GoogleTranslateDeleteGlossaryBody struct {
 	ID string `json:"id"`
 }
+
+type GoogleTranslateSyncGlossariesBody struct {
+	DryRun bool `json:"dry_run"`
+}
@@ -0,0 +1,25 @@
+package httpresponses
+
+type ConfigSetting struct {
+	Name       string `json:"name"`
+	Value      string `json:"value"`
+	Source     string `json:"source"`
+	Reloadable bool   `json:"reloadable"`
+}
+
+type ConfigResponse struct {
+	File     string          `json:"file,omitempty"`
+	Settings []ConfigSetting `json:"settings"`
+}
+
+type ConfigChange struct {
+	Name string `json:"name"`
+	From string `json:"from"`
+	To   string `json:"to"`
+}
+
+type ConfigReloadResponse struct {
+	Applied []ConfigChange `json:"applied"`
+	// Settings only read at startup, which keep their values until a restart
+	Rejected []ConfigChange `json:"rejected"`
+}
@@ -7,4 +7,8 @@ type ErrorCode struct {
 
 type ErrorResponse struct {
 	ErrorCode ErrorCode `json:"error_code"`
+	RequestID string    `json:"request_id,omitempty"`
+	// What the request got done before failing, for the few that can fail
+	// partway
+	Details interface{} `json:"details,omitempty"`
 }
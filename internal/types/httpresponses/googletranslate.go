@@ -11,9 +11,46 @@ type GoogleTranslateTranslatedContent struct {
 }
 
 type GoogleTranslateTranslatedResponse struct {
-	OriginalContent           GoogleTranslateOriginalContent    `json:"original"`
-	TranslatedContent         GoogleTranslateTranslatedContent  `json:"translated"`
-	GlossaryTranslatedContent *GoogleTranslateTranslatedContent `json:"glossary_translated,omitempty"`
+	OriginalContent           GoogleTranslateOriginalContent      `json:"original"`
+	TranslatedContent         GoogleTranslateTranslatedContent    `json:"translated"`
+	GlossaryTranslatedContent *GoogleTranslateTranslatedContent   `json:"glossary_translated,omitempty"`
+	LocalGlossaryTerms        []GoogleTranslateAppliedTerm        `json:"local_glossary_terms,omitempty"`
+	GlossaryExplanation       *GoogleTranslateGlossaryExplanation `json:"glossary_explanation,omitempty"`
+	ServedBy                  *GoogleTranslateServedBy            `json:"served_by,omitempty"`
+}
+
+type GoogleTranslateServedBy struct {
+	Backend        string `json:"backend"`
+	FailedOverFrom string `json:"failed_over_from,omitempty"`
+	FailoverReason string `json:"failover_reason,omitempty"`
+	Rule           string `json:"rule,omitempty"`
+}
+
+type GoogleTranslateAppliedTerm struct {
+	SourceTerm string `json:"source_term"`
+	TargetTerm string `json:"target_term"`
+	Count      int    `json:"count"`
+}
+
+type GoogleTranslateDiffChunk struct {
+	Op   string `json:"op"`
+	Text string `json:"text"`
+}
+
+type GoogleTranslateGlossaryTermCoverage struct {
+	SourceTerm     string `json:"source_term"`
+	TargetTerm     string `json:"target_term"`
+	FoundInInput   bool   `json:"found_in_input"`
+	TargetInOutput bool   `json:"target_in_output"`
+}
+
+type GoogleTranslateGlossaryExplanation struct {
+	EntriesKnown    bool                                  `json:"entries_known"`
+	GlossaryChanged bool                                  `json:"glossary_changed"`
+	TermsFound      int                                   `json:"terms_found"`
+	TermsSatisfied  int                                   `json:"terms_satisfied"`
+	Diff            []GoogleTranslateDiffChunk            `json:"diff"`
+	Terms           []GoogleTranslateGlossaryTermCoverage `json:"terms"`
 }
 
 type GoogleTranslateDetectedLocale struct {
@@ -23,6 +60,7 @@ type GoogleTranslateDetectedLocale struct {
 
 type GoogleTranslateDetectedResponse struct {
 	DetectedLocales []GoogleTranslateDetectedLocale `json:"results"`
+	ServedBy        *GoogleTranslateServedBy        `json:"served_by,omitempty"`
 }
 
 type GoogleTranslateGlossary struct {
@@ -33,3 +71,45 @@ type GoogleTranslateGlossary struct {
 type GoogleTranslateListGlossariesResponse struct {
 	Glossaries []GoogleTranslateGlossary `json:"glossaries"`
 }
+
+type GoogleTranslateGlossarySyncItem struct {
+	Action           string `json:"action"`
+	ID               string `json:"id"`
+	File             string `json:"file,omitempty"`
+	GCSSource        string `json:"gcs_source,omitempty"`
+	CurrentGCSSource string `json:"current_gcs_source,omitempty"`
+}
+
+type GoogleTranslateSyncGlossariesResponse struct {
+	DryRun bool                              `json:"dry_run"`
+	Plan   []GoogleTranslateGlossarySyncItem `json:"plan"`
+}
+
+// GoogleTranslateSyncGlossariesFailure is the details of a failed sync
+type GoogleTranslateSyncGlossariesFailure struct {
+	Applied []GoogleTranslateGlossarySyncItem `json:"applied"`
+}
+
+type GoogleTranslateEstimatedPrice struct {
+	Amount               float64 `json:"amount"`
+	Currency             string  `json:"currency"`
+	PriceKey             string  `json:"price_key"`
+	PerMillionCharacters float64 `json:"per_million_characters"`
+}
+
+// GoogleTranslateEstimateResponse has a CacheHitRate of 0, as there is no
+// translation cache or memory to hit
+type GoogleTranslateEstimateResponse struct {
+	Provider           string                         `json:"provider"`
+	Backend            string                         `json:"backend,omitempty"`
+	Model              string                         `json:"model,omitempty"`
+	Glossary           string                         `json:"glossary,omitempty"`
+	Rule               string                         `json:"rule,omitempty"`
+	Segments           int                            `json:"segments"`
+	UpstreamCalls      int                            `json:"upstream_calls"`
+	Characters         int64                          `json:"characters"`
+	GlossaryCharacters int64                          `json:"glossary_characters"`
+	BillableCharacters int64                          `json:"billable_characters"`
+	CacheHitRate       float64                        `json:"cache_hit_rate"`
+	EstimatedPrice     *GoogleTranslateEstimatedPrice `json:"estimated_price"`
+}
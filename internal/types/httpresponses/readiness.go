@@ -0,0 +1,15 @@
+package httpresponses
+
+type ReadinessCheck struct {
+	Name      string `json:"name"`
+	Critical  bool   `json:"critical"`
+	Status    string `json:"status"`
+	Error     string `json:"error,omitempty"`
+	CheckedAt string `json:"checked_at,omitempty"`
+	LatencyMS int64  `json:"latency_ms"`
+}
+
+type ReadinessResponse struct {
+	Ready  bool             `json:"ready"`
+	Checks []ReadinessCheck `json:"checks,omitempty"`
+}
@@ -0,0 +1,21 @@
+package httpresponses
+
+type RoutingRule struct {
+	Name     string `json:"name"`
+	Source   string `json:"source"`
+	Target   string `json:"target"`
+	Tenant   string `json:"tenant,omitempty"`
+	Provider string `json:"provider"`
+	Backend  string `json:"backend,omitempty"`
+	Model    string `json:"model,omitempty"`
+	Glossary string `json:"glossary,omitempty"`
+}
+
+type RoutingExplainResponse struct {
+	Matched       bool         `json:"matched"`
+	RuleIndex     *int         `json:"rule_index,omitempty"`
+	Rule          *RoutingRule `json:"rule,omitempty"`
+	RulesFile     string       `json:"rules_file"`
+	RulesCount    int          `json:"rules_count"`
+	RulesLoadedAt string       `json:"rules_loaded_at"`
+}
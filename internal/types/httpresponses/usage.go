@@ -0,0 +1,16 @@
+package httpresponses
+
+type UsageSummary struct {
+	Group              map[string]string `json:"group"`
+	Requests           int64             `json:"requests"`
+	Characters         int64             `json:"characters"`
+	GlossaryCharacters int64             `json:"glossary_characters"`
+	BillableCharacters int64             `json:"billable_characters"`
+}
+
+type UsageResponse struct {
+	From    string         `json:"from"`
+	To      string         `json:"to"`
+	GroupBy []string       `json:"group_by"`
+	Usage   []UsageSummary `json:"usage"`
+}
@@ -1,61 +1,368 @@
 package config
 
 import (
-	"os"
-	"strconv"
+	"strings"
 
 	_ "github.com/joho/godotenv/autoload" // Automatically load ".env" file in root
 )
 
 type AppConfig struct {
-	LivenessPort                int
-	Port                        int
-	AppName                     string
-	GracefulShutdownSeconds     int
-	EnableHTTP2                 bool
-	IsDevEnv                    bool
-	GoogleTranslateV2APIKey     string
-	GoogleTranslateV3ProjectKey string
+	LivenessPort                      int
+	Port                              int
+	AppName                           string
+	GracefulShutdownSeconds           int
+	ShutdownDelaySeconds              int
+	EnableHTTP2                       bool
+	IsDevEnv                          bool
+	GoogleTranslateV2Enabled          bool
+	GoogleTranslateV3Enabled          bool
+	GoogleTranslateV2APIKey           string
+	GoogleTranslateV2CredentialsFile  string
+	GoogleTranslateV2CredentialsJSON  string
+	GoogleTranslateV2Impersonate      string
+	GoogleTranslateV2Endpoint         string
+	GoogleTranslateV2Insecure         bool
+	GoogleTranslateV3CredentialsFile  string
+	GoogleTranslateV3CredentialsJSON  string
+	GoogleTranslateV3Impersonate      string
+	GoogleTranslateV3Endpoint         string
+	GoogleTranslateV3Insecure         bool
+	GoogleTranslateV3ProjectID        string
+	GoogleTranslateV3RegionalLocation string
+	GoogleTranslateV3RegionalEndpoint string
+	LocalGlossaryDir                  string
+	LocalGlossaryCaseSensitive        bool
+	LocalGlossaryWholeWord            bool
+	LocalGlossaryLongestMatch         bool
+	GlossarySyncManifest              string
+	FailoverEnabled                   bool
+	FailoverPrimary                   string
+	FailoverSecondary                 string
+	FailoverOn                        []string
+	LibreTranslateURL                 string
+	LibreTranslateAPIKey              string
+	DeepLURL                          string
+	DeepLAPIKey                       string
+	RoutingRulesFile                  string
+	RoutingRulesReloadSeconds         int
+	GoogleTranslateV2RateLimits       RateLimits
+	GoogleTranslateV3RateLimits       RateLimits
+	APIKeysFile                       string
+	APIKeys                           string
+	JWTJWKSURL                        string
+	JWTJWKSFile                       string
+	JWTJWKSRefreshSeconds             int
+	JWTIssuer                         string
+	JWTAudience                       string
+	JWTScopeClaim                     string
+	JWTTenantClaim                    string
+	JWTScopeMapping                   string
+	TenantsFile                       string
+	UsageFile                         string
+	UsageMonthlySoftCharacters        int
+	UsageMonthlyHardCharacters        int
+	PriceTableFile                    string
+	MetricsHTTPBuckets                []float64
+	MetricsUpstreamBuckets            []float64
+	TracingOTLPEndpoint               string
+	TracingOTLPInsecure               bool
+	TracingSampleRatio                float64
+	LogRedaction                      string
+	ReadinessCheckIntervalSeconds     int
+	ReadinessCheckTimeoutSeconds      int
+	ReadinessCriticalChecks           []string
+	ConfigReloadSeconds               int
+	CORS                              CORSPolicy
+	CORSAdmin                         CORSPolicy
+	TLSCertFile                       string
+	TLSKeyFile                        string
+	TLSMinVersion                     string
+	TLSClientCAFile                   string
+	TLSClientCertRequired             bool
+	TLSClientPrincipalsFile           string
+	TLSReloadSeconds                  int
+
+	file     string
+	settings []Setting
 }
 
-func ApplicationConfig() AppConfig {
-	livenessPort := envVarAtoi("LIVENESS_PROBE_PORT")
-	port := envVarAtoi("PORT")
-	appName := envVarAsStr("APP_NAME")
-	gracefulShutdownSeconds := envVarAtoi("GRACEFUL_SHUTDOWN_SECONDS")
-	enableHTTP2 := envVarAsBool("ENABLE_HTTP2")
-	isDevEnv := envVarAsBool("DEVELOPMENT_MODE")
-	googleTranslateV2APIKey := envVarAsStr("GOOGLE_TRANSLATE_V2_API_KEY")
-	googleTranslateV3ProjectKey := envVarAsStr("GOOGLE_TRANSLATE_V3_PROJECT_KEY")
-
-	return AppConfig{
-		LivenessPort:                livenessPort,
-		Port:                        port,
-		AppName:                     appName,
-		GracefulShutdownSeconds:     gracefulShutdownSeconds,
-		EnableHTTP2:                 enableHTTP2,
-		IsDevEnv:                    isDevEnv,
-		GoogleTranslateV2APIKey:     googleTranslateV2APIKey,
-		GoogleTranslateV3ProjectKey: googleTranslateV3ProjectKey,
-	}
+// CORSPolicy of a route group, see cors.Policy
+type CORSPolicy struct {
+	AllowedOrigins   []string
+	AllowedHeaders   []string
+	AllowedMethods   []string
+	ExposedHeaders   []string
+	AllowCredentials bool
+	MaxAgeSeconds    int
 }
 
-func envVarAtoi(envName string) int {
-	valueStr := os.Getenv(envName)
-	value, err := strconv.Atoi(valueStr)
-	if err != nil {
-		panic(err)
+// RateLimits of 0 are unlimited
+type RateLimits struct {
+	RequestsPerMinute   int
+	CharactersPerMinute int
+	MaxConcurrent       int
+	MaxWaitMillis       int
+}
+
+// Load reads the config file, YAML or JSON, when file is set, with env vars
+// taking precedence over it. Every invalid setting is reported in a
+// ValidationError.
+func Load(file string) (AppConfig, error) {
+	l := newLoader(file)
+
+	livenessPort := l.portOr("LIVENESS_PROBE_PORT", defaultLivenessPort)
+	port := l.portOr("PORT", defaultPort)
+	appName := l.strOr("APP_NAME", defaultAppName)
+	gracefulShutdownSeconds := l.atoiOr("GRACEFUL_SHUTDOWN_SECONDS", defaultGracefulShutdownSeconds)
+	shutdownDelaySeconds := l.atoiOr("SHUTDOWN_DELAY_SECONDS", 0)
+	enableHTTP2 := l.boolOr("ENABLE_HTTP2", false)
+	isDevEnv := l.boolOr("DEVELOPMENT_MODE", false)
+	googleTranslateV2Enabled := l.boolOr("GOOGLE_TRANSLATE_V2_ENABLED", true)
+	googleTranslateV3Enabled := l.boolOr("GOOGLE_TRANSLATE_V3_ENABLED", true)
+	googleTranslateV2APIKey := l.str("GOOGLE_TRANSLATE_V2_API_KEY")
+	googleTranslateV2CredentialsFile := l.str("GOOGLE_TRANSLATE_V2_CREDENTIALS_FILE")
+	googleTranslateV2CredentialsJSON := l.str("GOOGLE_TRANSLATE_V2_CREDENTIALS_JSON")
+	googleTranslateV2Impersonate := l.str("GOOGLE_TRANSLATE_V2_IMPERSONATE_SERVICE_ACCOUNT")
+	googleTranslateV2Endpoint := l.str("GOOGLE_TRANSLATE_V2_ENDPOINT")
+	googleTranslateV2Insecure := l.boolOr("GOOGLE_TRANSLATE_V2_INSECURE", false)
+	googleTranslateV3CredentialsFile := l.str("GOOGLE_TRANSLATE_V3_CREDENTIALS_FILE")
+	googleTranslateV3CredentialsJSON := l.str("GOOGLE_TRANSLATE_V3_CREDENTIALS_JSON")
+	googleTranslateV3Impersonate := l.str("GOOGLE_TRANSLATE_V3_IMPERSONATE_SERVICE_ACCOUNT")
+	googleTranslateV3Endpoint := l.str("GOOGLE_TRANSLATE_V3_ENDPOINT")
+	googleTranslateV3Insecure := l.boolOr("GOOGLE_TRANSLATE_V3_INSECURE", false)
+	googleTranslateV3ProjectID := l.str("GOOGLE_TRANSLATE_V3_PROJECT_ID")
+	googleTranslateV3RegionalLocation := l.strOr("GOOGLE_TRANSLATE_V3_REGIONAL_LOCATION", defaultV3RegionalLocation)
+	googleTranslateV3RegionalEndpoint := l.str("GOOGLE_TRANSLATE_V3_REGIONAL_ENDPOINT")
+
+	// Deprecated "projects/<id>/locations/<location>" key, kept for existing
+	// .env files
+	if legacyProjectKey := l.str("GOOGLE_TRANSLATE_V3_PROJECT_KEY"); googleTranslateV3ProjectID == "" && legacyProjectKey != "" {
+		projectID, location := parseV3ProjectKey(legacyProjectKey)
+		googleTranslateV3ProjectID = projectID
+		if location != "" && location != "global" && !l.isSet("GOOGLE_TRANSLATE_V3_REGIONAL_LOCATION") {
+			googleTranslateV3RegionalLocation = location
+		}
+	}
+	localGlossaryDir := l.str("LOCAL_GLOSSARY_DIR")
+	localGlossaryCaseSensitive := l.boolOr("LOCAL_GLOSSARY_CASE_SENSITIVE", false)
+	localGlossaryWholeWord := l.boolOr("LOCAL_GLOSSARY_WHOLE_WORD", true)
+	localGlossaryLongestMatch := l.boolOr("LOCAL_GLOSSARY_LONGEST_MATCH_FIRST", true)
+	glossarySyncManifest := l.str("GLOSSARY_SYNC_MANIFEST")
+	failoverEnabled := l.boolOr("FAILOVER_ENABLED", false)
+	failoverPrimary := l.str("FAILOVER_PRIMARY")
+	failoverSecondary := l.str("FAILOVER_SECONDARY")
+	failoverOn := l.listOr("FAILOVER_ON", defaultFailoverOn)
+	libreTranslateURL := l.str("LIBRETRANSLATE_URL")
+	libreTranslateAPIKey := l.str("LIBRETRANSLATE_API_KEY")
+	deepLURL := l.str("DEEPL_URL")
+	deepLAPIKey := l.str("DEEPL_API_KEY")
+	routingRulesFile := l.str("ROUTING_RULES_FILE")
+	routingRulesReloadSeconds := l.atoiOr("ROUTING_RULES_RELOAD_SECONDS", defaultRoutingRulesReloadSeconds)
+	googleTranslateV2RateLimits := l.rateLimits("GOOGLE_TRANSLATE_V2")
+	googleTranslateV3RateLimits := l.rateLimits("GOOGLE_TRANSLATE_V3")
+	apiKeysFile := l.str("API_KEYS_FILE")
+	apiKeys := l.str("API_KEYS")
+	jwtJWKSURL := l.str("JWT_JWKS_URL")
+	jwtJWKSFile := l.str("JWT_JWKS_FILE")
+	jwtJWKSRefreshSeconds := l.atoiOr("JWT_JWKS_REFRESH_SECONDS", defaultJWKSRefreshSeconds)
+	jwtIssuer := l.str("JWT_ISSUER")
+	jwtAudience := l.str("JWT_AUDIENCE")
+	jwtScopeClaim := l.strOr("JWT_SCOPE_CLAIM", "scope")
+	jwtTenantClaim := l.strOr("JWT_TENANT_CLAIM", "tenant")
+	jwtScopeMapping := l.str("JWT_SCOPE_MAPPING")
+	tenantsFile := l.str("TENANTS_FILE")
+	usageFile := l.str("USAGE_FILE")
+	usageMonthlySoftCharacters := l.atoiOr("USAGE_MONTHLY_SOFT_CHARACTERS", 0)
+	usageMonthlyHardCharacters := l.atoiOr("USAGE_MONTHLY_HARD_CHARACTERS", 0)
+	priceTableFile := l.str("PRICE_TABLE_FILE")
+	metricsHTTPBuckets := l.floatListOr("METRICS_HTTP_BUCKETS", nil)
+	metricsUpstreamBuckets := l.floatListOr("METRICS_UPSTREAM_BUCKETS", nil)
+	tracingOTLPEndpoint := l.str("TRACING_OTLP_ENDPOINT")
+	tracingOTLPInsecure := l.boolOr("TRACING_OTLP_INSECURE", false)
+	tracingSampleRatio := l.ratioOr("TRACING_SAMPLE_RATIO", 1)
+	logRedaction := l.str("LOG_REDACTION")
+	readinessCheckIntervalSeconds := l.atoiOr("READINESS_CHECK_INTERVAL_SECONDS", defaultReadinessCheckIntervalSeconds)
+	readinessCheckTimeoutSeconds := l.atoiOr("READINESS_CHECK_TIMEOUT_SECONDS", defaultReadinessCheckTimeoutSeconds)
+	readinessCriticalChecks := l.listOr("READINESS_CRITICAL_CHECKS", nil)
+	configReloadSeconds := l.atoiOr("CONFIG_RELOAD_SECONDS", defaultConfigReloadSeconds)
+	cors := l.corsPolicy("CORS", defaultCORSPolicy)
+	// Admin routes follow the policy of the other routes unless set apart
+	corsAdmin := l.corsPolicy("CORS_ADMIN", cors)
+	tlsCertFile := l.str("TLS_CERT_FILE")
+	tlsKeyFile := l.str("TLS_KEY_FILE")
+	tlsMinVersion := l.strOr("TLS_MIN_VERSION", defaultTLSMinVersion)
+	tlsClientCAFile := l.str("TLS_CLIENT_CA_FILE")
+	tlsClientCertRequired := l.boolOr("TLS_CLIENT_CERT_REQUIRED", false)
+	tlsClientPrincipalsFile := l.str("TLS_CLIENT_PRINCIPALS_FILE")
+	tlsReloadSeconds := l.atoiOr("TLS_RELOAD_SECONDS", defaultTLSReloadSeconds)
+
+	if googleTranslateV3Enabled && googleTranslateV3ProjectID == "" {
+		_, field, source := l.lookup("GOOGLE_TRANSLATE_V3_PROJECT_ID")
+		l.problem("GOOGLE_TRANSLATE_V3_PROJECT_ID", field, source, "", "must be set while GOOGLE_TRANSLATE_V3_ENABLED is true")
+	}
+
+	if readinessCheckIntervalSeconds > 0 && readinessCheckTimeoutSeconds < 1 {
+		valueStr, field, source := l.lookup("READINESS_CHECK_TIMEOUT_SECONDS")
+		l.problem("READINESS_CHECK_TIMEOUT_SECONDS", field, source, valueStr, "must be at least 1 while checks run")
+	}
+
+	if tlsCertFile != "" && tlsKeyFile == "" {
+		_, field, source := l.lookup("TLS_KEY_FILE")
+		l.problem("TLS_KEY_FILE", field, source, "", "must be set along with TLS_CERT_FILE")
+	} else if tlsKeyFile != "" && tlsCertFile == "" {
+		_, field, source := l.lookup("TLS_CERT_FILE")
+		l.problem("TLS_CERT_FILE", field, source, "", "must be set along with TLS_KEY_FILE")
+	}
+
+	if tlsMinVersion != "1.2" && tlsMinVersion != "1.3" {
+		_, field, source := l.lookup("TLS_MIN_VERSION")
+		l.problem("TLS_MIN_VERSION", field, source, tlsMinVersion, "must be 1.2 or 1.3")
+	}
+
+	// Client certificates are verified against the CA bundle, and only over TLS
+	clientCertDependencies := []struct {
+		name     string
+		isSet    bool
+		needs    string
+		hasNeeds bool
+	}{
+		{"TLS_CLIENT_CA_FILE", tlsClientCAFile != "", "TLS_CERT_FILE", tlsCertFile != ""},
+		{"TLS_CLIENT_CERT_REQUIRED", tlsClientCertRequired, "TLS_CLIENT_CA_FILE", tlsClientCAFile != ""},
+		{"TLS_CLIENT_PRINCIPALS_FILE", tlsClientPrincipalsFile != "", "TLS_CLIENT_CA_FILE", tlsClientCAFile != ""},
+	}
+	for _, dependency := range clientCertDependencies {
+		if dependency.isSet && !dependency.hasNeeds {
+			valueStr, field, source := l.lookup(dependency.name)
+			l.problem(dependency.name, field, source, valueStr, "needs "+dependency.needs+" to be set")
+		}
+	}
+
+	appConfig := AppConfig{
+		LivenessPort:                      livenessPort,
+		Port:                              port,
+		AppName:                           appName,
+		GracefulShutdownSeconds:           gracefulShutdownSeconds,
+		ShutdownDelaySeconds:              shutdownDelaySeconds,
+		EnableHTTP2:                       enableHTTP2,
+		IsDevEnv:                          isDevEnv,
+		GoogleTranslateV2Enabled:          googleTranslateV2Enabled,
+		GoogleTranslateV3Enabled:          googleTranslateV3Enabled,
+		GoogleTranslateV2APIKey:           googleTranslateV2APIKey,
+		GoogleTranslateV2CredentialsFile:  googleTranslateV2CredentialsFile,
+		GoogleTranslateV2CredentialsJSON:  googleTranslateV2CredentialsJSON,
+		GoogleTranslateV2Impersonate:      googleTranslateV2Impersonate,
+		GoogleTranslateV2Endpoint:         googleTranslateV2Endpoint,
+		GoogleTranslateV2Insecure:         googleTranslateV2Insecure,
+		GoogleTranslateV3CredentialsFile:  googleTranslateV3CredentialsFile,
+		GoogleTranslateV3CredentialsJSON:  googleTranslateV3CredentialsJSON,
+		GoogleTranslateV3Impersonate:      googleTranslateV3Impersonate,
+		GoogleTranslateV3Endpoint:         googleTranslateV3Endpoint,
+		GoogleTranslateV3Insecure:         googleTranslateV3Insecure,
+		GoogleTranslateV3ProjectID:        googleTranslateV3ProjectID,
+		GoogleTranslateV3RegionalLocation: googleTranslateV3RegionalLocation,
+		GoogleTranslateV3RegionalEndpoint: googleTranslateV3RegionalEndpoint,
+		LocalGlossaryDir:                  localGlossaryDir,
+		LocalGlossaryCaseSensitive:        localGlossaryCaseSensitive,
+		LocalGlossaryWholeWord:            localGlossaryWholeWord,
+		LocalGlossaryLongestMatch:         localGlossaryLongestMatch,
+		GlossarySyncManifest:              glossarySyncManifest,
+		FailoverEnabled:                   failoverEnabled,
+		FailoverPrimary:                   failoverPrimary,
+		FailoverSecondary:                 failoverSecondary,
+		FailoverOn:                        failoverOn,
+		LibreTranslateURL:                 libreTranslateURL,
+		LibreTranslateAPIKey:              libreTranslateAPIKey,
+		DeepLURL:                          deepLURL,
+		DeepLAPIKey:                       deepLAPIKey,
+		RoutingRulesFile:                  routingRulesFile,
+		RoutingRulesReloadSeconds:         routingRulesReloadSeconds,
+		GoogleTranslateV2RateLimits:       googleTranslateV2RateLimits,
+		GoogleTranslateV3RateLimits:       googleTranslateV3RateLimits,
+		APIKeysFile:                       apiKeysFile,
+		APIKeys:                           apiKeys,
+		JWTJWKSURL:                        jwtJWKSURL,
+		JWTJWKSFile:                       jwtJWKSFile,
+		JWTJWKSRefreshSeconds:             jwtJWKSRefreshSeconds,
+		JWTIssuer:                         jwtIssuer,
+		JWTAudience:                       jwtAudience,
+		JWTScopeClaim:                     jwtScopeClaim,
+		JWTTenantClaim:                    jwtTenantClaim,
+		JWTScopeMapping:                   jwtScopeMapping,
+		TenantsFile:                       tenantsFile,
+		UsageFile:                         usageFile,
+		UsageMonthlySoftCharacters:        usageMonthlySoftCharacters,
+		UsageMonthlyHardCharacters:        usageMonthlyHardCharacters,
+		PriceTableFile:                    priceTableFile,
+		MetricsHTTPBuckets:                metricsHTTPBuckets,
+		MetricsUpstreamBuckets:            metricsUpstreamBuckets,
+		TracingOTLPEndpoint:               tracingOTLPEndpoint,
+		TracingOTLPInsecure:               tracingOTLPInsecure,
+		TracingSampleRatio:                tracingSampleRatio,
+		LogRedaction:                      logRedaction,
+		ReadinessCheckIntervalSeconds:     readinessCheckIntervalSeconds,
+		ReadinessCheckTimeoutSeconds:      readinessCheckTimeoutSeconds,
+		ReadinessCriticalChecks:           readinessCriticalChecks,
+		ConfigReloadSeconds:               configReloadSeconds,
+		CORS:                              cors,
+		CORSAdmin:                         corsAdmin,
+		TLSCertFile:                       tlsCertFile,
+		TLSKeyFile:                        tlsKeyFile,
+		TLSMinVersion:                     tlsMinVersion,
+		TLSClientCAFile:                   tlsClientCAFile,
+		TLSClientCertRequired:             tlsClientCertRequired,
+		TLSClientPrincipalsFile:           tlsClientPrincipalsFile,
+		TLSReloadSeconds:                  tlsReloadSeconds,
+		file:                              file,
+		settings:                          l.settings,
 	}
 
-	return value
+	return appConfig, l.err()
 }
 
-func envVarAsBool(envName string) bool {
-	valueStr := os.Getenv(envName)
-	return valueStr == "true"
+const defaultAppName = "google-translate-api"
+
+const defaultPort = 8080
+
+const defaultLivenessPort = 8081
+
+const defaultGracefulShutdownSeconds = 30
+
+const defaultV3RegionalLocation = "us-central1"
+
+const defaultRoutingRulesReloadSeconds = 10
+
+const defaultRateLimitMaxWaitMillis = 5000
+
+const defaultJWKSRefreshSeconds = 3600
+
+const defaultReadinessCheckIntervalSeconds = 30
+
+const defaultReadinessCheckTimeoutSeconds = 5
+
+const defaultConfigReloadSeconds = 10
+
+const defaultTLSMinVersion = "1.2"
+
+const defaultTLSReloadSeconds = 60
+
+var defaultCORSPolicy = CORSPolicy{
+	AllowedOrigins: []string{"*"},
+	AllowedHeaders: []string{"x-requested-with", "origin", "content-type", "authorization", "x-api-key", "x-tenant-id", "x-request-id"},
+	AllowedMethods: []string{"GET", "HEAD", "POST", "PUT", "DELETE", "OPTIONS"},
+	ExposedHeaders: []string{"X-Request-ID"},
 }
 
-func envVarAsStr(envName string) string {
-	valueStr := os.Getenv(envName)
-	return valueStr
+var defaultFailoverOn = []string{"unavailable", "timeout", "rate_limited"}
+
+func parseV3ProjectKey(projectKey string) (string, string) {
+	parts := strings.Split(strings.Trim(projectKey, "/"), "/")
+	if len(parts) >= 2 && parts[0] == "projects" {
+		if len(parts) >= 4 && parts[2] == "locations" {
+			return parts[1], parts[3]
+		}
+
+		return parts[1], ""
+	}
+
+	return projectKey, ""
 }
@@ -0,0 +1,40 @@
+package config
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestLoadRequiresV3ProjectID(t *testing.T) {
+	tests := []struct {
+		name    string
+		env     map[string]string
+		wantErr bool
+	}{
+		{"project ID", map[string]string{"GOOGLE_TRANSLATE_V3_PROJECT_ID": "project"}, false},
+		{"legacy project key", map[string]string{"GOOGLE_TRANSLATE_V3_PROJECT_KEY": "projects/project/locations/global"}, false},
+		{"V3 disabled", map[string]string{"GOOGLE_TRANSLATE_V3_ENABLED": "false"}, false},
+		{"no project", map[string]string{}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for _, name := range []string{"GOOGLE_TRANSLATE_V3_PROJECT_ID", "GOOGLE_TRANSLATE_V3_PROJECT_KEY", "GOOGLE_TRANSLATE_V3_ENABLED"} {
+				t.Setenv(name, tt.env[name])
+			}
+
+			_, err := Load("")
+			if !tt.wantErr {
+				if err != nil {
+					t.Errorf("Load() error = %v", err)
+				}
+				return
+			}
+
+			validationErr := ValidationError{}
+			if !errors.As(err, &validationErr) || len(validationErr.Problems) != 1 || validationErr.Problems[0].Field != "GOOGLE_TRANSLATE_V3_PROJECT_ID" {
+				t.Errorf("Load() error = %v, want a problem with GOOGLE_TRANSLATE_V3_PROJECT_ID", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,68 @@
+package config
+
+import (
+	"time"
+
+	"github.com/weiyuan-lane/google-translate-api/internal/services/usage"
+	"github.com/weiyuan-lane/google-translate-api/internal/utils/cors"
+	"github.com/weiyuan-lane/google-translate-api/internal/utils/googletranslate"
+	"github.com/weiyuan-lane/google-translate-api/internal/utils/ratelimit"
+)
+
+func (a AppConfig) GoogleTranslateV2ClientConfig() googletranslate.ClientConfig {
+	return googletranslate.ClientConfig{
+		EnvPrefix:                 "GOOGLE_TRANSLATE_V2",
+		APIKey:                    a.GoogleTranslateV2APIKey,
+		CredentialsFile:           a.GoogleTranslateV2CredentialsFile,
+		CredentialsJSON:           a.GoogleTranslateV2CredentialsJSON,
+		ImpersonateServiceAccount: a.GoogleTranslateV2Impersonate,
+		Endpoint:                  a.GoogleTranslateV2Endpoint,
+		Insecure:                  a.GoogleTranslateV2Insecure,
+	}
+}
+
+func (a AppConfig) GoogleTranslateV3ClientConfig() googletranslate.ClientConfig {
+	return googletranslate.ClientConfig{
+		EnvPrefix:                 "GOOGLE_TRANSLATE_V3",
+		CredentialsFile:           a.GoogleTranslateV3CredentialsFile,
+		CredentialsJSON:           a.GoogleTranslateV3CredentialsJSON,
+		ImpersonateServiceAccount: a.GoogleTranslateV3Impersonate,
+		Endpoint:                  a.GoogleTranslateV3Endpoint,
+		Insecure:                  a.GoogleTranslateV3Insecure,
+	}
+}
+
+func (a AppConfig) UsageBudget() usage.Budget {
+	return usage.Budget{
+		SoftCharacters: int64(a.UsageMonthlySoftCharacters),
+		HardCharacters: int64(a.UsageMonthlyHardCharacters),
+	}
+}
+
+func (r RateLimits) Limits() ratelimit.Limits {
+	return ratelimit.Limits{
+		RequestsPerMinute:   r.RequestsPerMinute,
+		CharactersPerMinute: r.CharactersPerMinute,
+		MaxConcurrent:       r.MaxConcurrent,
+		MaxWait:             time.Duration(r.MaxWaitMillis) * time.Millisecond,
+	}
+}
+
+func (c CORSPolicy) Policy() cors.Policy {
+	return cors.Policy{
+		AllowedOrigins:   c.AllowedOrigins,
+		AllowedHeaders:   c.AllowedHeaders,
+		AllowedMethods:   c.AllowedMethods,
+		ExposedHeaders:   c.ExposedHeaders,
+		AllowCredentials: c.AllowCredentials,
+		MaxAgeSeconds:    c.MaxAgeSeconds,
+	}
+}
+
+// CORSPolicies by route group
+func (r Reloadable) CORSPolicies() map[string]cors.Policy {
+	return map[string]cors.Policy{
+		cors.DefaultGroup: r.CORS.Policy(),
+		cors.AdminGroup:   r.CORSAdmin.Policy(),
+	}
+}
@@ -0,0 +1,332 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Problem is one invalid setting, at the path it was given as: the key path
+// in the config file or the env var name
+type Problem struct {
+	Field   string
+	Source  string
+	Message string
+}
+
+func (p Problem) String() string {
+	if p.Field == "" {
+		return fmt.Sprintf("%s: %s", p.Source, p.Message)
+	}
+
+	return fmt.Sprintf("%s (%s): %s", p.Field, p.Source, p.Message)
+}
+
+// ValidationError holds every problem of a config, so they can all be fixed
+// at once
+type ValidationError struct {
+	Problems []Problem
+}
+
+func (e ValidationError) Error() string {
+	lines := make([]string, len(e.Problems))
+	for i, problem := range e.Problems {
+		lines[i] = "  " + problem.String()
+	}
+
+	if len(lines) == 1 {
+		return "problem in config:\n" + lines[0]
+	}
+
+	return fmt.Sprintf("%d problems in config:\n%s", len(e.Problems), strings.Join(lines, "\n"))
+}
+
+// Setting is the effective value of a setting, and where it came from
+type Setting struct {
+	Name   string
+	Value  string
+	Source string
+	Quoted bool
+}
+
+// Sources of settings, besides the config file name
+const (
+	SourceEnv     = "env"
+	SourceDefault = "default"
+)
+
+type fileValue struct {
+	path  string
+	value string
+}
+
+// loader reads settings from env vars, falling back on the config file and
+// then on defaults. Invalid values are kept as problems and replaced by the
+// default, so that all problems are reported together.
+type loader struct {
+	fileName string
+	file     map[string]fileValue
+	settings []Setting
+	problems []Problem
+}
+
+func newLoader(file string) *loader {
+	l := &loader{file: map[string]fileValue{}}
+	if file == "" {
+		return l
+	}
+
+	l.fileName = filepath.Base(file)
+	contents, err := os.ReadFile(file)
+	if err != nil {
+		l.problems = append(l.problems, Problem{Source: file, Message: err.Error()})
+		return l
+	}
+
+	// YAML is a superset of JSON, so this reads both
+	values := map[string]interface{}{}
+	if err := yaml.Unmarshal(contents, &values); err != nil {
+		l.problems = append(l.problems, Problem{Source: l.fileName, Message: err.Error()})
+		return l
+	}
+	l.flatten("", "", values)
+
+	return l
+}
+
+// flatten keys settings by their env var name, with nested keys joined by
+// "_", eg. "google_translate_v2: {api_key: ...}" is GOOGLE_TRANSLATE_V2_API_KEY
+func (l *loader) flatten(namePrefix, pathPrefix string, values map[string]interface{}) {
+	for key, value := range values {
+		name := strings.ToUpper(namePrefix + key)
+		path := pathPrefix + key
+
+		switch typed := value.(type) {
+		case map[string]interface{}:
+			l.flatten(name+"_", path+".", typed)
+		case []interface{}:
+			items := make([]string, len(typed))
+			for i, item := range typed {
+				items[i] = fmt.Sprint(item)
+			}
+			l.file[name] = fileValue{path: path, value: strings.Join(items, ",")}
+		case nil:
+			l.file[name] = fileValue{path: path}
+		default:
+			l.file[name] = fileValue{path: path, value: fmt.Sprint(typed)}
+		}
+	}
+}
+
+// lookup returns the value of name and the path it was set at, or "" when
+// it is not set
+func (l *loader) lookup(name string) (value, field, source string) {
+	if value := strings.TrimSpace(os.Getenv(name)); value != "" {
+		return value, name, SourceEnv
+	}
+
+	if fileValue, ok := l.file[name]; ok && strings.TrimSpace(fileValue.value) != "" {
+		return strings.TrimSpace(fileValue.value), fileValue.path, l.fileName
+	}
+
+	return "", name, SourceDefault
+}
+
+func (l *loader) isSet(name string) bool {
+	_, _, source := l.lookup(name)
+	return source != SourceDefault
+}
+
+func (l *loader) record(name, value, source string, quoted bool) {
+	l.settings = append(l.settings, Setting{Name: name, Value: value, Source: source, Quoted: quoted})
+}
+
+func (l *loader) problem(name, field, source, value, message string) {
+	if value != "" {
+		if IsSecret(name) {
+			message += ", got a value that is masked"
+		} else {
+			message += fmt.Sprintf(", got %q", value)
+		}
+	}
+	l.problems = append(l.problems, Problem{Field: field, Source: source, Message: message})
+}
+
+// err reports the problems, including keys of the config file that are not
+// settings
+func (l *loader) err() error {
+	known := map[string]bool{}
+	for _, setting := range l.settings {
+		known[setting.Name] = true
+	}
+
+	unknown := []Problem{}
+	for name, fileValue := range l.file {
+		if !known[name] {
+			unknown = append(unknown, Problem{Field: fileValue.path, Source: l.fileName, Message: "is not a setting"})
+		}
+	}
+	sort.Slice(unknown, func(i, j int) bool {
+		return unknown[i].Field < unknown[j].Field
+	})
+
+	problems := append(l.problems, unknown...)
+	if len(problems) == 0 {
+		return nil
+	}
+
+	return ValidationError{Problems: problems}
+}
+
+func (l *loader) str(name string) string {
+	return l.strOr(name, "")
+}
+
+func (l *loader) strOr(name, defaultValue string) string {
+	value, _, source := l.lookup(name)
+	if source == SourceDefault {
+		value = defaultValue
+	}
+
+	l.record(name, value, source, true)
+	return value
+}
+
+func (l *loader) boolOr(name string, defaultValue bool) bool {
+	valueStr, field, source := l.lookup(name)
+	value := defaultValue
+	if source != SourceDefault {
+		parsed, err := strconv.ParseBool(valueStr)
+		if err != nil {
+			l.problem(name, field, source, valueStr, "must be true or false")
+		} else {
+			value = parsed
+		}
+	}
+
+	l.record(name, strconv.FormatBool(value), source, false)
+	return value
+}
+
+// atoiOr reads an int of at least 0, as all counts, sizes and durations are
+func (l *loader) atoiOr(name string, defaultValue int) int {
+	return l.intInRange(name, defaultValue, 0, -1, "must be a whole number of at least 0")
+}
+
+func (l *loader) portOr(name string, defaultValue int) int {
+	return l.intInRange(name, defaultValue, 1, 65535, "must be a port from 1 to 65535")
+}
+
+// intInRange has no upper bound when max is negative
+func (l *loader) intInRange(name string, defaultValue, min, max int, message string) int {
+	valueStr, field, source := l.lookup(name)
+	value := defaultValue
+	if source != SourceDefault {
+		parsed, err := strconv.Atoi(valueStr)
+		if err != nil || parsed < min || (max >= 0 && parsed > max) {
+			l.problem(name, field, source, valueStr, message)
+		} else {
+			value = parsed
+		}
+	}
+
+	l.record(name, strconv.Itoa(value), source, false)
+	return value
+}
+
+// Comma separated values, eg. "a, b,c", or a list in the config file
+func (l *loader) listOr(name string, defaultValue []string) []string {
+	valueStr, _, source := l.lookup(name)
+	values := defaultValue
+	if source != SourceDefault {
+		values = []string{}
+		for _, value := range strings.Split(valueStr, ",") {
+			if value = strings.TrimSpace(value); value != "" {
+				values = append(values, value)
+			}
+		}
+	}
+
+	l.record(name, strings.Join(values, ","), source, true)
+	return values
+}
+
+func (l *loader) ratioOr(name string, defaultValue float64) float64 {
+	valueStr, field, source := l.lookup(name)
+	value := defaultValue
+	if source != SourceDefault {
+		parsed, err := strconv.ParseFloat(valueStr, 64)
+		if err != nil || parsed < 0 || parsed > 1 {
+			l.problem(name, field, source, valueStr, "must be a number from 0 to 1")
+		} else {
+			value = parsed
+		}
+	}
+
+	l.record(name, strconv.FormatFloat(value, 'g', -1, 64), source, false)
+	return value
+}
+
+func (l *loader) floatListOr(name string, defaultValue []float64) []float64 {
+	valueStr, field, source := l.lookup(name)
+	values := defaultValue
+	if source != SourceDefault {
+		values = []float64{}
+		for _, itemStr := range strings.Split(valueStr, ",") {
+			if itemStr = strings.TrimSpace(itemStr); itemStr == "" {
+				continue
+			}
+
+			value, err := strconv.ParseFloat(itemStr, 64)
+			if err != nil {
+				l.problem(name, field, source, valueStr, "must be comma separated numbers")
+				values = defaultValue
+				break
+			}
+			values = append(values, value)
+		}
+	}
+
+	items := make([]string, len(values))
+	for i, value := range values {
+		items[i] = strconv.FormatFloat(value, 'g', -1, 64)
+	}
+	l.record(name, strings.Join(items, ","), source, true)
+	return values
+}
+
+func (l *loader) rateLimits(prefix string) RateLimits {
+	return RateLimits{
+		RequestsPerMinute:   l.atoiOr(prefix+"_REQUESTS_PER_MINUTE", 0),
+		CharactersPerMinute: l.atoiOr(prefix+"_CHARACTERS_PER_MINUTE", 0),
+		MaxConcurrent:       l.atoiOr(prefix+"_MAX_CONCURRENT", 0),
+		MaxWaitMillis:       l.atoiOr(prefix+"_RATE_LIMIT_MAX_WAIT_MS", defaultRateLimitMaxWaitMillis),
+	}
+}
+
+func (l *loader) corsPolicy(prefix string, defaults CORSPolicy) CORSPolicy {
+	return CORSPolicy{
+		AllowedOrigins:   l.listOr(prefix+"_ALLOWED_ORIGINS", defaults.AllowedOrigins),
+		AllowedHeaders:   l.listOr(prefix+"_ALLOWED_HEADERS", defaults.AllowedHeaders),
+		AllowedMethods:   l.listOr(prefix+"_ALLOWED_METHODS", defaults.AllowedMethods),
+		ExposedHeaders:   l.listOr(prefix+"_EXPOSED_HEADERS", defaults.ExposedHeaders),
+		AllowCredentials: l.boolOr(prefix+"_ALLOW_CREDENTIALS", defaults.AllowCredentials),
+		MaxAgeSeconds:    l.atoiOr(prefix+"_MAX_AGE_SECONDS", defaults.MaxAgeSeconds),
+	}
+}
+
+// IsSecret is true of settings whose values are masked when printed
+func IsSecret(name string) bool {
+	for _, suffix := range []string{"_API_KEY", "API_KEYS", "_CREDENTIALS_JSON"} {
+		if strings.HasSuffix(name, suffix) {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,29 @@
+package config
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+const maskedValue = "********"
+
+// Print writes the effective settings as a config file, with where each
+// came from and secrets masked
+func (a AppConfig) Print(w io.Writer) {
+	fmt.Fprintln(w, "# Effective config, secrets are masked")
+	for _, setting := range a.Settings() {
+		value := setting.Value
+		if setting.Quoted {
+			value = strconv.Quote(value)
+		}
+
+		fmt.Fprintf(w, "%s: %s # %s\n", strings.ToLower(setting.Name), value, setting.Source)
+	}
+}
+
+// File is the config file the settings were read from, if any
+func (a AppConfig) File() string {
+	return a.file
+}
@@ -0,0 +1,132 @@
+package config
+
+// reloadableSettings can change while the server runs, the others are only
+// read at startup
+var reloadableSettings = map[string]bool{
+	"GOOGLE_TRANSLATE_V2_REQUESTS_PER_MINUTE":    true,
+	"GOOGLE_TRANSLATE_V2_CHARACTERS_PER_MINUTE":  true,
+	"GOOGLE_TRANSLATE_V2_MAX_CONCURRENT":         true,
+	"GOOGLE_TRANSLATE_V2_RATE_LIMIT_MAX_WAIT_MS": true,
+	"GOOGLE_TRANSLATE_V3_REQUESTS_PER_MINUTE":    true,
+	"GOOGLE_TRANSLATE_V3_CHARACTERS_PER_MINUTE":  true,
+	"GOOGLE_TRANSLATE_V3_MAX_CONCURRENT":         true,
+	"GOOGLE_TRANSLATE_V3_RATE_LIMIT_MAX_WAIT_MS": true,
+	"API_KEYS_FILE":                true,
+	"API_KEYS":                     true,
+	"CORS_ALLOWED_ORIGINS":         true,
+	"CORS_ALLOWED_HEADERS":         true,
+	"CORS_ALLOWED_METHODS":         true,
+	"CORS_EXPOSED_HEADERS":         true,
+	"CORS_ALLOW_CREDENTIALS":       true,
+	"CORS_MAX_AGE_SECONDS":         true,
+	"CORS_ADMIN_ALLOWED_ORIGINS":   true,
+	"CORS_ADMIN_ALLOWED_HEADERS":   true,
+	"CORS_ADMIN_ALLOWED_METHODS":   true,
+	"CORS_ADMIN_EXPOSED_HEADERS":   true,
+	"CORS_ADMIN_ALLOW_CREDENTIALS": true,
+	"CORS_ADMIN_MAX_AGE_SECONDS":   true,
+}
+
+func IsReloadable(name string) bool {
+	return reloadableSettings[name]
+}
+
+// Reloadable is the part of the config that can change without a restart
+type Reloadable struct {
+	GoogleTranslateV2RateLimits RateLimits
+	GoogleTranslateV3RateLimits RateLimits
+	APIKeysFile                 string
+	APIKeys                     string
+	CORS                        CORSPolicy
+	CORSAdmin                   CORSPolicy
+}
+
+func (a AppConfig) Reloadable() Reloadable {
+	return Reloadable{
+		GoogleTranslateV2RateLimits: a.GoogleTranslateV2RateLimits,
+		GoogleTranslateV3RateLimits: a.GoogleTranslateV3RateLimits,
+		APIKeysFile:                 a.APIKeysFile,
+		APIKeys:                     a.APIKeys,
+		CORS:                        a.CORS,
+		CORSAdmin:                   a.CORSAdmin,
+	}
+}
+
+// WithReloaded returns a with the reloadable settings of next, and its own
+// values of the others
+func (a AppConfig) WithReloaded(next AppConfig) AppConfig {
+	reloadable := next.Reloadable()
+	a.GoogleTranslateV2RateLimits = reloadable.GoogleTranslateV2RateLimits
+	a.GoogleTranslateV3RateLimits = reloadable.GoogleTranslateV3RateLimits
+	a.APIKeysFile = reloadable.APIKeysFile
+	a.APIKeys = reloadable.APIKeys
+	a.CORS = reloadable.CORS
+	a.CORSAdmin = reloadable.CORSAdmin
+
+	nextSettings := map[string]Setting{}
+	for _, setting := range next.settings {
+		nextSettings[setting.Name] = setting
+	}
+
+	settings := make([]Setting, len(a.settings))
+	for i, setting := range a.settings {
+		if nextSetting, ok := nextSettings[setting.Name]; ok && IsReloadable(setting.Name) {
+			setting = nextSetting
+		}
+		settings[i] = setting
+	}
+	a.settings = settings
+
+	return a
+}
+
+// Settings are in the order they are read, with secrets masked
+func (a AppConfig) Settings() []Setting {
+	settings := make([]Setting, len(a.settings))
+	for i, setting := range a.settings {
+		settings[i] = setting.masked()
+	}
+
+	return settings
+}
+
+// Change of a setting, with secrets masked
+type Change struct {
+	Name       string
+	From       string
+	To         string
+	Reloadable bool
+}
+
+// Diff lists the settings whose values differ between from and to
+func Diff(from, to AppConfig) []Change {
+	fromSettings := map[string]Setting{}
+	for _, setting := range from.settings {
+		fromSettings[setting.Name] = setting
+	}
+
+	changes := []Change{}
+	for _, setting := range to.settings {
+		previous := fromSettings[setting.Name]
+		if previous.Value == setting.Value {
+			continue
+		}
+
+		changes = append(changes, Change{
+			Name:       setting.Name,
+			From:       previous.masked().Value,
+			To:         setting.masked().Value,
+			Reloadable: IsReloadable(setting.Name),
+		})
+	}
+
+	return changes
+}
+
+func (s Setting) masked() Setting {
+	if IsSecret(s.Name) && s.Value != "" {
+		s.Value = maskedValue
+	}
+
+	return s
+}
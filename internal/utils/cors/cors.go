@@ -0,0 +1,166 @@
+package cors
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync/atomic"
+
+	"github.com/gorilla/handlers"
+)
+
+// Route groups that can have a policy of their own, routes outside of
+// AdminGroup use DefaultGroup
+const (
+	DefaultGroup = "default"
+	AdminGroup   = "admin"
+)
+
+// Policy of cross-origin requests. Origins are "*", or a scheme and host
+// whose host may start with "*." to match any subdomain, eg.
+// "https://*.example.com".
+type Policy struct {
+	AllowedOrigins   []string
+	AllowedHeaders   []string
+	AllowedMethods   []string
+	ExposedHeaders   []string
+	AllowCredentials bool
+	// Preflights are not cached by browsers when 0
+	MaxAgeSeconds int
+}
+
+func (p Policy) Validate() error {
+	for _, origin := range p.AllowedOrigins {
+		if origin == "*" {
+			if p.AllowCredentials {
+				return fmt.Errorf("origin \"*\" cannot be allowed with credentials, list the origins instead")
+			}
+			continue
+		}
+
+		parsed, err := url.Parse(origin)
+		if err != nil || parsed.Scheme == "" || parsed.Host == "" || (parsed.Path != "" && parsed.Path != "/") {
+			return fmt.Errorf("origin %q must be \"*\" or a scheme and host, eg. \"https://*.example.com\"", origin)
+		}
+		if strings.Contains(strings.TrimPrefix(parsed.Host, "*."), "*") {
+			return fmt.Errorf("origin %q can only have \"*.\" at the start of its host", origin)
+		}
+	}
+
+	// Browsers cap the max age at 10 minutes or less anyway
+	if p.MaxAgeSeconds < 0 || p.MaxAgeSeconds > 600 {
+		return fmt.Errorf("max age must be from 0 to 600 seconds, got %d", p.MaxAgeSeconds)
+	}
+
+	return nil
+}
+
+func (p Policy) allowsAnyOrigin() bool {
+	for _, origin := range p.AllowedOrigins {
+		if origin == "*" {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (p Policy) AllowsOrigin(origin string) bool {
+	if p.allowsAnyOrigin() {
+		return true
+	}
+
+	for _, allowed := range p.AllowedOrigins {
+		if originMatches(strings.TrimSuffix(allowed, "/"), origin) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func originMatches(pattern, origin string) bool {
+	if strings.EqualFold(pattern, origin) {
+		return true
+	}
+
+	scheme, host, ok := strings.Cut(pattern, "://*.")
+	if !ok {
+		return false
+	}
+
+	originScheme, originHost, ok := strings.Cut(origin, "://")
+	return ok &&
+		strings.EqualFold(scheme, originScheme) &&
+		strings.HasSuffix(strings.ToLower(originHost), "."+strings.ToLower(host))
+}
+
+func (p Policy) options() []handlers.CORSOption {
+	options := []handlers.CORSOption{
+		handlers.AllowedHeaders(p.AllowedHeaders),
+		handlers.AllowedMethods(p.AllowedMethods),
+		handlers.ExposedHeaders(p.ExposedHeaders),
+		handlers.MaxAge(p.MaxAgeSeconds),
+	}
+
+	if p.allowsAnyOrigin() {
+		options = append(options, handlers.AllowedOrigins([]string{"*"}))
+	} else {
+		options = append(options, handlers.AllowedOriginValidator(p.AllowsOrigin))
+	}
+
+	if p.AllowCredentials {
+		options = append(options, handlers.AllowCredentials())
+	}
+
+	return options
+}
+
+// Policies holds the policy of each route group, which can be swapped while
+// serving
+type Policies struct {
+	options atomic.Pointer[map[string]policyOptions]
+}
+
+type policyOptions struct {
+	policy  Policy
+	options []handlers.CORSOption
+}
+
+// NewPolicies needs a policy for DefaultGroup, which the other groups fall
+// back on
+func NewPolicies(byGroup map[string]Policy) *Policies {
+	policies := &Policies{}
+	policies.Set(byGroup)
+
+	return policies
+}
+
+func (p *Policies) Set(byGroup map[string]Policy) {
+	options := map[string]policyOptions{}
+	for group, policy := range byGroup {
+		options[group] = policyOptions{policy: policy, options: policy.options()}
+	}
+
+	p.options.Store(&options)
+}
+
+// Handler applies the policy of the route group of each request, as told
+// by groupOf
+func (p *Policies) Handler(next http.Handler, groupOf func(r *http.Request) string) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		options := *p.options.Load()
+		policyOptions, ok := options[groupOf(r)]
+		if !ok {
+			policyOptions = options[DefaultGroup]
+		}
+
+		// Responses differ by origin unless every origin is allowed
+		if !policyOptions.policy.allowsAnyOrigin() {
+			w.Header().Add("Vary", "Origin")
+		}
+
+		handlers.CORS(policyOptions.options...)(next).ServeHTTP(w, r)
+	})
+}
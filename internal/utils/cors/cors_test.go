@@ -0,0 +1,204 @@
+package cors
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+var testPolicy = Policy{
+	AllowedOrigins:   []string{"https://app.example.com", "https://*.example.org"},
+	AllowedHeaders:   []string{"content-type", "authorization", "x-request-id"},
+	AllowedMethods:   []string{"GET", "POST", "DELETE", "OPTIONS"},
+	ExposedHeaders:   []string{"X-Request-ID"},
+	AllowCredentials: true,
+	MaxAgeSeconds:    600,
+}
+
+// serve sends r through a handler with policies for the default group,
+// reporting whether the request reached the routes
+func serve(policies *Policies, r *http.Request) (*httptest.ResponseRecorder, bool) {
+	reached := false
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		reached = true
+	})
+
+	w := httptest.NewRecorder()
+	policies.Handler(next, func(r *http.Request) string { return DefaultGroup }).ServeHTTP(w, r)
+
+	return w, reached
+}
+
+func preflight(origin, method, headers string) *http.Request {
+	r := httptest.NewRequest("OPTIONS", "/google-translate/translate", nil)
+	r.Header.Set("Origin", origin)
+	r.Header.Set("Access-Control-Request-Method", method)
+	if headers != "" {
+		r.Header.Set("Access-Control-Request-Headers", headers)
+	}
+
+	return r
+}
+
+func TestPreflight(t *testing.T) {
+	policies := NewPolicies(map[string]Policy{DefaultGroup: testPolicy})
+
+	tests := []struct {
+		name        string
+		origin      string
+		wantAllowed bool
+	}{
+		{"exact origin", "https://app.example.com", true},
+		{"exact origin in another case", "https://APP.example.com", true},
+		{"wildcard subdomain", "https://eu.example.org", true},
+		{"nested wildcard subdomain", "https://api.eu.example.org", true},
+		{"wildcard parent domain", "https://example.org", false},
+		{"wildcard with another scheme", "http://eu.example.org", false},
+		{"suffix of another domain", "https://evilexample.org", false},
+		{"other origin", "https://evil.com", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w, reached := serve(policies, preflight(tt.origin, "POST", "Content-Type, Authorization"))
+			if reached {
+				t.Error("preflight reached the routes")
+			}
+			if vary := w.Header().Get("Vary"); vary != "Origin" {
+				t.Errorf("Vary = %q, want Origin", vary)
+			}
+
+			allowOrigin := w.Header().Get("Access-Control-Allow-Origin")
+			if !tt.wantAllowed {
+				if allowOrigin != "" {
+					t.Errorf("Access-Control-Allow-Origin = %q, want none", allowOrigin)
+				}
+				return
+			}
+
+			want := map[string]string{
+				// The origin itself, as "*" is not allowed with credentials
+				"Access-Control-Allow-Origin":      tt.origin,
+				"Access-Control-Allow-Credentials": "true",
+				"Access-Control-Allow-Headers":     "Content-Type,Authorization",
+				"Access-Control-Max-Age":           "600",
+			}
+			for header, value := range want {
+				if got := w.Header().Get(header); got != value {
+					t.Errorf("%s = %q, want %q", header, got, value)
+				}
+			}
+		})
+	}
+}
+
+func TestPreflightRejectsUnlistedHeadersAndMethods(t *testing.T) {
+	policies := NewPolicies(map[string]Policy{DefaultGroup: testPolicy})
+
+	if w, _ := serve(policies, preflight("https://app.example.com", "POST", "X-Custom")); w.Code != http.StatusForbidden {
+		t.Errorf("preflight with an unlisted header = %d, want %d", w.Code, http.StatusForbidden)
+	}
+	if w, _ := serve(policies, preflight("https://app.example.com", "PATCH", "")); w.Code != http.StatusMethodNotAllowed {
+		t.Errorf("preflight with an unlisted method = %d, want %d", w.Code, http.StatusMethodNotAllowed)
+	}
+}
+
+func TestRequestExposesHeaders(t *testing.T) {
+	policies := NewPolicies(map[string]Policy{DefaultGroup: testPolicy})
+
+	r := httptest.NewRequest("POST", "/google-translate/translate", nil)
+	r.Header.Set("Origin", "https://app.example.com")
+	w, reached := serve(policies, r)
+
+	if !reached {
+		t.Error("request did not reach the routes")
+	}
+	if exposed := w.Header().Get("Access-Control-Expose-Headers"); http.CanonicalHeaderKey(exposed) != http.CanonicalHeaderKey("X-Request-ID") {
+		t.Errorf("Access-Control-Expose-Headers = %q, want X-Request-ID", exposed)
+	}
+	if allowOrigin := w.Header().Get("Access-Control-Allow-Origin"); allowOrigin != "https://app.example.com" {
+		t.Errorf("Access-Control-Allow-Origin = %q, want the origin", allowOrigin)
+	}
+}
+
+func TestAnyOriginPolicy(t *testing.T) {
+	policies := NewPolicies(map[string]Policy{DefaultGroup: {
+		AllowedOrigins: []string{"*"},
+		AllowedMethods: []string{"POST"},
+	}})
+
+	w, _ := serve(policies, preflight("https://anywhere.com", "POST", ""))
+	if allowOrigin := w.Header().Get("Access-Control-Allow-Origin"); allowOrigin != "*" {
+		t.Errorf("Access-Control-Allow-Origin = %q, want *", allowOrigin)
+	}
+	if vary := w.Header().Get("Vary"); vary != "" {
+		t.Errorf("Vary = %q, want none when every origin is allowed", vary)
+	}
+	if credentials := w.Header().Get("Access-Control-Allow-Credentials"); credentials != "" {
+		t.Errorf("Access-Control-Allow-Credentials = %q, want none", credentials)
+	}
+}
+
+func TestPoliciesByGroup(t *testing.T) {
+	policies := NewPolicies(map[string]Policy{
+		DefaultGroup: testPolicy,
+		AdminGroup:   {AllowedOrigins: []string{"https://admin.example.com"}, AllowedMethods: []string{"GET"}},
+	})
+	handler := policies.Handler(http.NotFoundHandler(), func(r *http.Request) string { return r.URL.Query().Get("group") })
+
+	tests := []struct {
+		group  string
+		origin string
+		want   string
+	}{
+		{AdminGroup, "https://admin.example.com", "https://admin.example.com"},
+		{AdminGroup, "https://app.example.com", ""},
+		{DefaultGroup, "https://admin.example.com", ""},
+		{"unknown", "https://app.example.com", "https://app.example.com"},
+	}
+
+	for _, tt := range tests {
+		r := preflight(tt.origin, "GET", "")
+		r.URL.RawQuery = "group=" + tt.group
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, r)
+
+		if allowOrigin := w.Header().Get("Access-Control-Allow-Origin"); allowOrigin != tt.want {
+			t.Errorf("group %s from %s: Access-Control-Allow-Origin = %q, want %q", tt.group, tt.origin, allowOrigin, tt.want)
+		}
+	}
+
+	// Reloads swap the policies in place
+	policies.Set(map[string]Policy{DefaultGroup: {AllowedOrigins: []string{"*"}, AllowedMethods: []string{"GET"}}})
+	r := preflight("https://app.example.com", "GET", "")
+	r.URL.RawQuery = "group=" + AdminGroup
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, r)
+	if allowOrigin := w.Header().Get("Access-Control-Allow-Origin"); allowOrigin != "*" {
+		t.Errorf("after Set: Access-Control-Allow-Origin = %q, want *", allowOrigin)
+	}
+}
+
+func TestPolicyValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		policy  Policy
+		wantErr bool
+	}{
+		{"listed origins with credentials", testPolicy, false},
+		{"any origin", Policy{AllowedOrigins: []string{"*"}}, false},
+		{"any origin with credentials", Policy{AllowedOrigins: []string{"*"}, AllowCredentials: true}, true},
+		{"origin without scheme", Policy{AllowedOrigins: []string{"app.example.com"}}, true},
+		{"origin with path", Policy{AllowedOrigins: []string{"https://app.example.com/path"}}, true},
+		{"wildcard inside host", Policy{AllowedOrigins: []string{"https://app.*.example.com"}}, true},
+		{"max age over 10 minutes", Policy{MaxAgeSeconds: 601}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.policy.Validate(); (err != nil) != tt.wantErr {
+				t.Errorf("Validate() error = %v, want error %v", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -5,13 +5,31 @@ import (
 	"errors"
 	"fmt"
 	"net/http"
+	"strings"
 
 	errorwrapper "github.com/pkg/errors"
 	"github.com/weiyuan-lane/google-translate-api/internal/types/httpresponses"
 	loggerutils "github.com/weiyuan-lane/google-translate-api/internal/utils/logger"
+	"github.com/weiyuan-lane/google-translate-api/internal/utils/requestinfo"
 )
 
+// errorObserver is told the error code of every error response
+var errorObserver = func(id string) {}
+
+// ObserveErrors sets observer to be called with the error code of every
+// error response, eg. to count them. It is meant to be called once at
+// startup.
+func ObserveErrors(observer func(id string)) {
+	errorObserver = observer
+}
+
 func HandleHTTPError(logger *loggerutils.Logger, stackErr error, w http.ResponseWriter) {
+	HandleHTTPErrorWithDetails(logger, stackErr, w, nil)
+}
+
+// HandleHTTPErrorWithDetails is HandleHTTPError for requests that can fail
+// partway, with details of what they got done rendered under "details"
+func HandleHTTPErrorWithDetails(logger *loggerutils.Logger, stackErr error, w http.ResponseWriter, details interface{}) {
 
 	var messageErr, baseErr error
 	errResponse := httpresponses.ErrorResponse{}
@@ -34,7 +52,7 @@ func HandleHTTPError(logger *loggerutils.Logger, stackErr error, w http.Response
 	if baseErr != nil {
 		id := baseErr.Error()
 		message := messageErr.Error()
-		stack := fmt.Sprintf("%+v", stackErr)
+		stack := scrubbedStack(logger, stackErr, id, strings.TrimSuffix(message, ": "+id))
 		errResponse = httpresponses.ErrorResponse{
 			ErrorCode: httpresponses.ErrorCode{
 				ID:          id,
@@ -42,8 +60,9 @@ func HandleHTTPError(logger *loggerutils.Logger, stackErr error, w http.Response
 			},
 		}
 
-		// Log error results
-		logger.Info(message,
+		// Log error results, without the user content that upstream errors
+		// may echo
+		logger.Info(logger.RedactUserContent(message),
 			map[string]string{
 				"error_code_id": id,
 				"error_stack":   stack,
@@ -53,21 +72,72 @@ func HandleHTTPError(logger *loggerutils.Logger, stackErr error, w http.Response
 		logger.Info("Error does not contain any message",
 			map[string]string{
 				"error_code_id": "",
-				"error_stack":   stackErr.Error(),
+				"error_stack":   logger.RedactUserContent(stackErr.Error()),
 			},
 		)
 	}
 
+	errorObserver(errResponse.ErrorCode.ID)
+
+	// Set by the access log handler, so clients can quote it
+	errResponse.RequestID = w.Header().Get(requestinfo.Header)
+	errResponse.Details = details
+
 	// Render response
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(statusCode)
 	json.NewEncoder(w).Encode(errResponse)
 }
 
+// scrubbedStack is the "%+v" format of a wrapped error, with the message
+// redacted but the stack frames kept
+func scrubbedStack(logger *loggerutils.Logger, stackErr error, id, message string) string {
+	stack := id + "\n" + logger.RedactUserContent(message)
+
+	var stackTracer interface {
+		StackTrace() errorwrapper.StackTrace
+	}
+	if errors.As(stackErr, &stackTracer) {
+		stack += fmt.Sprintf("%+v", stackTracer.StackTrace())
+	}
+
+	return stack
+}
+
 func Wrap(err error, msg string) error {
 	return errorwrapper.Wrap(err, msg)
 }
 
+// WrapUpstream is Wrap for errors returned by an upstream API, keeping the
+// upstream error around so it can be classified with UpstreamCause
+func WrapUpstream(err error, msg string, cause error) error {
+	return errorwrapper.Wrap(upstreamError{code: err, cause: cause}, msg)
+}
+
+func UpstreamCause(err error) error {
+	var upstreamErr upstreamError
+	if errors.As(err, &upstreamErr) {
+		return upstreamErr.cause
+	}
+
+	return nil
+}
+
+// upstreamError stands in for the error code, so the code is still what
+// gets reported
+type upstreamError struct {
+	code  error
+	cause error
+}
+
+func (e upstreamError) Error() string {
+	return e.code.Error()
+}
+
+func (e upstreamError) Unwrap() error {
+	return e.code
+}
+
 func errorIs(err error, errorEntities []error) bool {
 	result := false
 
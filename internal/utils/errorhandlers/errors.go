@@ -2,34 +2,81 @@ package errorhandlers
 
 import (
 	"fmt"
-	"os"
-
-	_ "github.com/joho/godotenv/autoload" // autoload env
 )
 
-var appName = os.Getenv("APP_NAME")
+// appName prefixes the error codes, eg. "google-translate-api.7"
+var appName = "google-translate-api"
+
+// SetAppName is meant to be called once at startup, before any error is
+// rendered
+func SetAppName(name string) {
+	appName = name
+}
+
+type errorCode int
+
+func (c errorCode) Error() string {
+	return fmt.Sprintf("%s.%d", appName, int(c))
+}
 
 // All declared errors
 var (
-	ErrGoogleTranslateV2EmptyTranslationResponse     = fmt.Errorf("%s.%d", appName, 1)
-	ErrGoogleTranslateV2TranslateErrResponse         = fmt.Errorf("%s.%d", appName, 2)
-	ErrGoogleTranslateV2DetectErrResponse            = fmt.Errorf("%s.%d", appName, 3)
-	ErrGoogleTranslateV2EmptyDetectionResponse       = fmt.Errorf("%s.%d", appName, 4)
-	ErrTranslateEndpointMissingTextBodyParam         = fmt.Errorf("%s.%d", appName, 5)
-	ErrTranslateEndpointMissingTargetLocaleBodyParam = fmt.Errorf("%s.%d", appName, 6)
-	ErrDecodeJSONBodyFromRequestFailed               = fmt.Errorf("%s.%d", appName, 7)
-	ErrEncodeJSONResponseFailed                      = fmt.Errorf("%s.%d", appName, 8)
-	ErrDetectEndpointMissingTextBodyParam            = fmt.Errorf("%s.%d", appName, 9)
-	ErrGoogleTranslateV3EmptyDetectionResponse       = fmt.Errorf("%s.%d", appName, 10)
-	ErrGoogleTranslateV3DetectErrResponse            = fmt.Errorf("%s.%d", appName, 11)
-	ErrGoogleTranslateV3EmptyTranslationResponse     = fmt.Errorf("%s.%d", appName, 12)
-	ErrGoogleTranslateV3TranslateErrResponse         = fmt.Errorf("%s.%d", appName, 13)
-	ErrGoogleTranslateV2ConvertLangTagErrResponse    = fmt.Errorf("%s.%d", appName, 14)
-	ErrGoogleTranslateV3CreateGlossaryErrResponse    = fmt.Errorf("%s.%d", appName, 15)
-	ErrGoogleTranslateV3ListGlossaryErrResponse      = fmt.Errorf("%s.%d", appName, 16)
-	ErrGoogleTranslateV3DeleteGlossaryErrResponse    = fmt.Errorf("%s.%d", appName, 17)
-	ErrGlossaryEndpointMissingIDBodyParam            = fmt.Errorf("%s.%d", appName, 18)
-	ErrGlossaryEndpointMissingGCSSourceBodyParam     = fmt.Errorf("%s.%d", appName, 19)
+	ErrGoogleTranslateV2EmptyTranslationResponse      = errorCode(1)
+	ErrGoogleTranslateV2TranslateErrResponse          = errorCode(2)
+	ErrGoogleTranslateV2DetectErrResponse             = errorCode(3)
+	ErrGoogleTranslateV2EmptyDetectionResponse        = errorCode(4)
+	ErrTranslateEndpointMissingTextBodyParam          = errorCode(5)
+	ErrTranslateEndpointMissingTargetLocaleBodyParam  = errorCode(6)
+	ErrDecodeJSONBodyFromRequestFailed                = errorCode(7)
+	ErrEncodeJSONResponseFailed                       = errorCode(8)
+	ErrDetectEndpointMissingTextBodyParam             = errorCode(9)
+	ErrGoogleTranslateV3EmptyDetectionResponse        = errorCode(10)
+	ErrGoogleTranslateV3DetectErrResponse             = errorCode(11)
+	ErrGoogleTranslateV3EmptyTranslationResponse      = errorCode(12)
+	ErrGoogleTranslateV3TranslateErrResponse          = errorCode(13)
+	ErrGoogleTranslateV2ConvertLangTagErrResponse     = errorCode(14)
+	ErrGoogleTranslateV3CreateGlossaryErrResponse     = errorCode(15)
+	ErrGoogleTranslateV3ListGlossaryErrResponse       = errorCode(16)
+	ErrGoogleTranslateV3DeleteGlossaryErrResponse     = errorCode(17)
+	ErrGlossaryEndpointMissingIDBodyParam             = errorCode(18)
+	ErrGlossaryEndpointMissingGCSSourceBodyParam      = errorCode(19)
+	ErrLocalGlossaryNotConfigured                     = errorCode(20)
+	ErrGlossarySyncNotConfigured                      = errorCode(21)
+	ErrGlossarySyncInvalidManifest                    = errorCode(22)
+	ErrGlossarySyncUploadErrResponse                  = errorCode(23)
+	ErrTranslateEndpointInvalidExplainParam           = errorCode(24)
+	ErrGoogleTranslateV2BackendDisabled               = errorCode(25)
+	ErrGoogleTranslateV3BackendDisabled               = errorCode(26)
+	ErrProviderNotConfigured                          = errorCode(27)
+	ErrProviderUnsupportedLanguage                    = errorCode(28)
+	ErrProviderDetectNotSupported                     = errorCode(29)
+	ErrProviderTranslateErrResponse                   = errorCode(30)
+	ErrProviderDetectErrResponse                      = errorCode(31)
+	ErrProviderEmptyResponse                          = errorCode(32)
+	ErrProviderAuthErrResponse                        = errorCode(33)
+	ErrProviderQuotaExceeded                          = errorCode(34)
+	ErrRoutingInvalidRules                            = errorCode(35)
+	ErrRoutingNotConfigured                           = errorCode(36)
+	ErrRoutingExplainMissingTargetParam               = errorCode(37)
+	ErrRateLimitExceeded                              = errorCode(38)
+	ErrConcurrencyLimitExceeded                       = errorCode(39)
+	ErrAuthMissingCredentials                         = errorCode(40)
+	ErrAuthInvalidCredentials                         = errorCode(41)
+	ErrAuthMissingScope                               = errorCode(42)
+	ErrAuthInvalidKeys                                = errorCode(43)
+	ErrTenantNotFound                                 = errorCode(44)
+	ErrTenantForbidden                                = errorCode(45)
+	ErrTenantLanguageNotAllowed                       = errorCode(46)
+	ErrTenantInvalidTenants                           = errorCode(47)
+	ErrTenantBackendInitFailed                        = errorCode(48)
+	ErrUsageBudgetExceeded                            = errorCode(49)
+	ErrUsageInvalidQuery                              = errorCode(50)
+	ErrUsageStoreFailed                               = errorCode(51)
+	ErrUsageInvalidPriceTable                         = errorCode(52)
+	ErrGoogleTranslateV2SupportedLanguagesErrResponse = errorCode(53)
+	ErrGoogleTranslateV3SupportedLanguagesErrResponse = errorCode(54)
+	ErrConfigReloadFailed                             = errorCode(55)
+	ErrAuthInvalidClientCerts                         = errorCode(56)
 )
 
 // Categorized to slices
@@ -46,9 +93,34 @@ var (
 		},
 	}
 
+	all401Errors = errorPackage{
+		HTTPStatusCode: 401,
+		Errors: []error{
+			ErrAuthMissingCredentials,
+			ErrAuthInvalidCredentials,
+		},
+	}
+
+	all402Errors = errorPackage{
+		HTTPStatusCode: 402,
+		Errors: []error{
+			ErrUsageBudgetExceeded,
+		},
+	}
+
+	all403Errors = errorPackage{
+		HTTPStatusCode: 403,
+		Errors: []error{
+			ErrAuthMissingScope,
+			ErrTenantForbidden,
+		},
+	}
+
 	all404Errors = errorPackage{
 		HTTPStatusCode: 404,
-		Errors:         []error{},
+		Errors: []error{
+			ErrTenantNotFound,
+		},
 	}
 
 	all422Errors = errorPackage{
@@ -59,6 +131,24 @@ var (
 			ErrDetectEndpointMissingTextBodyParam,
 			ErrGlossaryEndpointMissingIDBodyParam,
 			ErrGlossaryEndpointMissingGCSSourceBodyParam,
+			ErrLocalGlossaryNotConfigured,
+			ErrGlossarySyncNotConfigured,
+			ErrTranslateEndpointInvalidExplainParam,
+			ErrProviderNotConfigured,
+			ErrProviderUnsupportedLanguage,
+			ErrRoutingNotConfigured,
+			ErrRoutingExplainMissingTargetParam,
+			ErrTenantLanguageNotAllowed,
+			ErrUsageInvalidQuery,
+		},
+	}
+
+	all429Errors = errorPackage{
+		HTTPStatusCode: 429,
+		Errors: []error{
+			ErrProviderQuotaExceeded,
+			ErrRateLimitExceeded,
+			ErrConcurrencyLimitExceeded,
 		},
 	}
 
@@ -66,6 +156,24 @@ var (
 		HTTPStatusCode: 500,
 		Errors: []error{
 			ErrEncodeJSONResponseFailed,
+			ErrGlossarySyncInvalidManifest,
+			ErrRoutingInvalidRules,
+			ErrAuthInvalidKeys,
+			ErrTenantInvalidTenants,
+			ErrTenantBackendInitFailed,
+			ErrUsageStoreFailed,
+			ErrUsageInvalidPriceTable,
+			ErrConfigReloadFailed,
+			ErrAuthInvalidClientCerts,
+		},
+	}
+
+	all501Errors = errorPackage{
+		HTTPStatusCode: 501,
+		Errors: []error{
+			ErrGoogleTranslateV2BackendDisabled,
+			ErrGoogleTranslateV3BackendDisabled,
+			ErrProviderDetectNotSupported,
 		},
 	}
 
@@ -84,14 +192,26 @@ var (
 			ErrGoogleTranslateV3CreateGlossaryErrResponse,
 			ErrGoogleTranslateV3ListGlossaryErrResponse,
 			ErrGoogleTranslateV3DeleteGlossaryErrResponse,
+			ErrGlossarySyncUploadErrResponse,
+			ErrProviderTranslateErrResponse,
+			ErrProviderDetectErrResponse,
+			ErrProviderEmptyResponse,
+			ErrProviderAuthErrResponse,
+			ErrGoogleTranslateV2SupportedLanguagesErrResponse,
+			ErrGoogleTranslateV3SupportedLanguagesErrResponse,
 		},
 	}
 
 	allErrorPackages = []errorPackage{
 		all400Errors,
+		all401Errors,
+		all402Errors,
+		all403Errors,
 		all404Errors,
 		all422Errors,
+		all429Errors,
 		all500Errors,
+		all501Errors,
 		all502Errors,
 	}
 )
@@ -2,23 +2,61 @@ package googletranslate
 
 import (
 	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
 
+	"cloud.google.com/go/storage"
 	"cloud.google.com/go/translate"
 	translatev3 "cloud.google.com/go/translate/apiv3"
+	"google.golang.org/api/impersonate"
 	"google.golang.org/api/option"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+
+	"github.com/weiyuan-lane/google-translate-api/internal/utils/tracing"
 )
 
-func InitTranslateV2Client(apiKey string) *translate.Client {
+const cloudPlatformScope = "https://www.googleapis.com/auth/cloud-platform"
+
+// ClientConfig describes how to reach and authenticate against one Google
+// API. Without any credentials set, Application Default Credentials are used.
+type ClientConfig struct {
+	// Prefix of the env vars the settings came from, used in error messages
+	EnvPrefix                 string
+	APIKey                    string
+	CredentialsFile           string
+	CredentialsJSON           string
+	ImpersonateServiceAccount string
+	Endpoint                  string
+	// Plaintext and unauthenticated, for local emulators only
+	Insecure bool
+}
+
+type ClientConfigError struct {
+	Setting string
+	Problem string
+}
+
+func (e ClientConfigError) Error() string {
+	return fmt.Sprintf("invalid config %s: %s", e.Setting, e.Problem)
+}
+
+func InitTranslateV2Client(cfg ClientConfig) (*translate.Client, error) {
 	ctx := context.Background()
 
-	apiKeyOption := option.WithAPIKey(apiKey)
+	opts, err := cfg.clientOptions(ctx)
+	if err != nil {
+		return nil, err
+	}
 
-	client, err := translate.NewClient(ctx, apiKeyOption)
+	client, err := translate.NewClient(ctx, opts...)
 	if err != nil {
-		panic(err)
+		return nil, fmt.Errorf("create google translate v2 client: %w", err)
 	}
 
-	return client
+	return client, nil
 }
 
 func CloseV2Client(client *translate.Client) {
@@ -27,15 +65,51 @@ func CloseV2Client(client *translate.Client) {
 	}
 }
 
-func InitTranslateV3Client() *translatev3.TranslationClient {
+func InitTranslateV3Client(cfg ClientConfig) (*translatev3.TranslationClient, error) {
 	ctx := context.Background()
 
-	client, err := translatev3.NewTranslationClient(ctx)
+	opts, err := cfg.clientOptions(ctx)
 	if err != nil {
-		panic(err)
+		return nil, err
+	}
+
+	if cfg.Insecure {
+		opts = append(opts, option.WithGRPCDialOption(grpc.WithTransportCredentials(insecure.NewCredentials())))
+	}
+	// Passes the trace of each call on to Google
+	opts = append(opts, option.WithGRPCDialOption(grpc.WithChainUnaryInterceptor(tracing.UnaryClientInterceptor())))
+
+	client, err := translatev3.NewTranslationClient(ctx, opts...)
+	if err != nil {
+		return nil, fmt.Errorf("create google translate v3 client: %w", err)
 	}
 
-	return client
+	return client, nil
+}
+
+// InitTranslateV3RegionalClient returns a client for the API endpoint that
+// serves location, or nil if the endpoint of cfg already serves it
+func InitTranslateV3RegionalClient(cfg ClientConfig, location, regionalEndpoint string) (*translatev3.TranslationClient, error) {
+	if regionalEndpoint == "" && cfg.Endpoint == "" {
+		regionalEndpoint = v3RegionalEndpoint(location)
+	}
+
+	if regionalEndpoint == "" {
+		return nil, nil
+	}
+
+	cfg.Endpoint = regionalEndpoint
+	return InitTranslateV3Client(cfg)
+}
+
+// Data residency in the EU requires the EU endpoint, other locations are
+// served from the default endpoint
+func v3RegionalEndpoint(location string) string {
+	if strings.HasPrefix(location, "europe-") || location == "eu" {
+		return "translate-eu.googleapis.com:443"
+	}
+
+	return ""
 }
 
 func CloseV3Client(client *translatev3.TranslationClient) {
@@ -43,3 +117,105 @@ func CloseV3Client(client *translatev3.TranslationClient) {
 		panic(err)
 	}
 }
+
+// InitStorageClient is used to upload glossary files for the V3 API, with
+// the same credentials as the V3 client but the default storage endpoint
+func InitStorageClient(cfg ClientConfig) (*storage.Client, error) {
+	ctx := context.Background()
+
+	cfg.Endpoint = ""
+	cfg.Insecure = false
+	opts, err := cfg.clientOptions(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	client, err := storage.NewClient(ctx, opts...)
+	if err != nil {
+		return nil, fmt.Errorf("create google cloud storage client: %w", err)
+	}
+
+	return client, nil
+}
+
+func (c ClientConfig) clientOptions(ctx context.Context) ([]option.ClientOption, error) {
+	if err := c.validate(); err != nil {
+		return nil, err
+	}
+
+	opts := []option.ClientOption{}
+	if c.Endpoint != "" {
+		opts = append(opts, option.WithEndpoint(c.Endpoint))
+	}
+
+	if c.Insecure {
+		return append(opts, option.WithoutAuthentication()), nil
+	}
+
+	if c.APIKey != "" {
+		opts = append(opts, option.WithAPIKey(c.APIKey))
+	}
+
+	credentialOpts := []option.ClientOption{}
+	if c.CredentialsFile != "" {
+		credentialOpts = append(credentialOpts, option.WithCredentialsFile(c.CredentialsFile))
+	}
+	if c.CredentialsJSON != "" {
+		credentialOpts = append(credentialOpts, option.WithCredentialsJSON([]byte(c.CredentialsJSON)))
+	}
+
+	if c.ImpersonateServiceAccount == "" {
+		return append(opts, credentialOpts...), nil
+	}
+
+	tokenSource, err := impersonate.CredentialsTokenSource(
+		ctx,
+		impersonate.CredentialsConfig{
+			TargetPrincipal: c.ImpersonateServiceAccount,
+			Scopes:          []string{cloudPlatformScope},
+		},
+		credentialOpts...,
+	)
+	if err != nil {
+		return nil, ClientConfigError{
+			Setting: c.EnvPrefix + "_IMPERSONATE_SERVICE_ACCOUNT",
+			Problem: err.Error(),
+		}
+	}
+
+	return append(opts, option.WithTokenSource(tokenSource)), nil
+}
+
+func (c ClientConfig) validate() error {
+	if c.CredentialsFile != "" && c.CredentialsJSON != "" {
+		return ClientConfigError{
+			Setting: c.EnvPrefix + "_CREDENTIALS_FILE",
+			Problem: "cannot be set together with " + c.EnvPrefix + "_CREDENTIALS_JSON",
+		}
+	}
+
+	if c.CredentialsFile != "" {
+		if _, err := os.Stat(c.CredentialsFile); err != nil {
+			return ClientConfigError{
+				Setting: c.EnvPrefix + "_CREDENTIALS_FILE",
+				Problem: err.Error(),
+			}
+		}
+	}
+
+	if c.CredentialsJSON != "" && !json.Valid([]byte(c.CredentialsJSON)) {
+		return ClientConfigError{
+			Setting: c.EnvPrefix + "_CREDENTIALS_JSON",
+			Problem: "is not valid JSON",
+		}
+	}
+
+	if c.Insecure && c.Endpoint == "" {
+		return ClientConfigError{
+			Setting: c.EnvPrefix + "_INSECURE",
+			Problem: "needs " + c.EnvPrefix + "_ENDPOINT to point at a local emulator",
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,32 @@
+package http
+
+import (
+	"context"
+	"net/http"
+	"time"
+)
+
+// detachedContext keeps the values of its parent but not its deadline or
+// cancellation
+type detachedContext struct {
+	context.Context
+}
+
+func (detachedContext) Deadline() (time.Time, bool) {
+	return time.Time{}, false
+}
+
+func (detachedContext) Done() <-chan struct{} {
+	return nil
+}
+
+func (detachedContext) Err() error {
+	return nil
+}
+
+// DetachedContext returns a context with the values of the request context,
+// such as its span, request ID and principal, that is not cancelled when the
+// client goes away, so calls to Google are not cut short
+func DetachedContext(r *http.Request) context.Context {
+	return detachedContext{Context: r.Context()}
+}
@@ -5,10 +5,13 @@ import (
 	"net/http"
 
 	"github.com/weiyuan-lane/google-translate-api/internal/utils/errorhandlers"
+	"github.com/weiyuan-lane/google-translate-api/internal/utils/tracing"
 )
 
 func DecodeJSONBody(r *http.Request, ptr interface{}) error {
+	_, span := tracing.Start(r.Context(), "decode request body")
 	err := json.NewDecoder(r.Body).Decode(ptr)
+	tracing.End(span, err)
 	if err != nil {
 		wrappedErr := errorhandlers.Wrap(
 			errorhandlers.ErrDecodeJSONBodyFromRequestFailed,
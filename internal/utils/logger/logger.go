@@ -1,11 +1,17 @@
 package logger
 
 import (
+	"context"
+
+	"go.opentelemetry.io/otel/trace"
 	"go.uber.org/zap"
+
+	"github.com/weiyuan-lane/google-translate-api/internal/utils/requestinfo"
 )
 
 type Logger struct {
 	wrappedLogger *zap.Logger
+	redaction     RedactionPolicy
 }
 
 func New(appName string, isDevEnv bool) *Logger {
@@ -24,14 +30,68 @@ func New(appName string, isDevEnv bool) *Logger {
 
 	return &Logger{
 		wrappedLogger: zapLogger,
+		redaction:     DefaultRedactionPolicy(isDevEnv),
 	}
 }
 
+// WithRedaction returns a copy of the logger that writes fields in
+// UserContentFields as set by policy
+func (l *Logger) WithRedaction(policy RedactionPolicy) *Logger {
+	return &Logger{
+		wrappedLogger: l.wrappedLogger,
+		redaction:     policy,
+	}
+}
+
+// RedactUserContent applies the redaction policy to a message that may hold
+// user content
+func (l *Logger) RedactUserContent(value string) string {
+	return l.redaction.Redact(value)
+}
+
+// ForContext returns a logger that adds the request ID and the trace and
+// span IDs of ctx to each entry, or l when ctx has none of them
+func (l *Logger) ForContext(ctx context.Context) *Logger {
+	fields := []zap.Field{}
+
+	if requestID := requestinfo.IDFromContext(ctx); requestID != "" {
+		fields = append(fields, zap.String("request_id", requestID))
+	}
+
+	if spanContext := trace.SpanContextFromContext(ctx); spanContext.IsValid() {
+		fields = append(fields,
+			zap.String("trace_id", spanContext.TraceID().String()),
+			zap.String("span_id", spanContext.SpanID().String()),
+		)
+	}
+
+	if len(fields) == 0 {
+		return l
+	}
+
+	return &Logger{
+		wrappedLogger: l.wrappedLogger.With(fields...),
+		redaction:     l.redaction,
+	}
+}
+
+func (l *Logger) DebugContext(ctx context.Context, msg string, inputFields ...map[string]string) {
+	l.ForContext(ctx).Debug(msg, inputFields...)
+}
+
+func (l *Logger) ErrorContext(ctx context.Context, msg string, inputFields ...map[string]string) {
+	l.ForContext(ctx).Error(msg, inputFields...)
+}
+
+func (l *Logger) InfoContext(ctx context.Context, msg string, inputFields ...map[string]string) {
+	l.ForContext(ctx).Info(msg, inputFields...)
+}
+
 func (l *Logger) Debug(msg string, inputFields ...map[string]string) {
 	fields := []zap.Field{}
 
 	if len(inputFields) > 0 {
-		fields = transformStrMapToFields(inputFields[0])
+		fields = l.transformStrMapToFields(inputFields[0])
 	}
 
 	l.wrappedLogger.Debug(msg, fields...)
@@ -41,7 +101,7 @@ func (l *Logger) Error(msg string, inputFields ...map[string]string) {
 	fields := []zap.Field{}
 
 	if len(inputFields) > 0 {
-		fields = transformStrMapToFields(inputFields[0])
+		fields = l.transformStrMapToFields(inputFields[0])
 	}
 
 	l.wrappedLogger.Error(msg, fields...)
@@ -51,7 +111,7 @@ func (l *Logger) Fatal(msg string, inputFields ...map[string]string) {
 	fields := []zap.Field{}
 
 	if len(inputFields) > 0 {
-		fields = transformStrMapToFields(inputFields[0])
+		fields = l.transformStrMapToFields(inputFields[0])
 	}
 
 	l.wrappedLogger.Fatal(msg, fields...)
@@ -61,15 +121,28 @@ func (l *Logger) Info(msg string, inputFields ...map[string]string) {
 	fields := []zap.Field{}
 
 	if len(inputFields) > 0 {
-		fields = transformStrMapToFields(inputFields[0])
+		fields = l.transformStrMapToFields(inputFields[0])
 	}
 
 	l.wrappedLogger.Info(msg, fields...)
 }
 
-func transformStrMapToFields(strMap map[string]string) []zap.Field {
+func (l *Logger) Warn(msg string, inputFields ...map[string]string) {
+	fields := []zap.Field{}
+
+	if len(inputFields) > 0 {
+		fields = l.transformStrMapToFields(inputFields[0])
+	}
+
+	l.wrappedLogger.Warn(msg, fields...)
+}
+
+func (l *Logger) transformStrMapToFields(strMap map[string]string) []zap.Field {
 	fields := []zap.Field{}
 	for k, v := range strMap {
+		if UserContentFields[k] {
+			v = l.redaction.Redact(v)
+		}
 		fields = append(fields, zap.String(k, v))
 	}
 
@@ -0,0 +1,97 @@
+package logger
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+type RedactionMode string
+
+const (
+	RedactionNone     RedactionMode = "none"
+	RedactionHash     RedactionMode = "hash"
+	RedactionTruncate RedactionMode = "truncate"
+	RedactionDrop     RedactionMode = "drop"
+)
+
+const redactedValue = "[redacted]"
+
+// UserContentFields are the field keys that may hold text sent by users,
+// directly or echoed back in an upstream error, and are redacted by the
+// policy of the logger. Log user content under one of these keys, or pass
+// it through Logger.RedactUserContent. Errors of the service itself, eg. a
+// failed JWKS fetch, go under "error" and are kept for diagnosis.
+var UserContentFields = map[string]bool{
+	"text":           true,
+	"upstream_error": true,
+	"error_message":  true,
+}
+
+// RedactionPolicy is how user content is written to logs
+type RedactionPolicy struct {
+	Mode RedactionMode
+	// Runes kept by RedactionTruncate
+	TruncateLength int
+}
+
+// DefaultRedactionPolicy keeps user content out of production logs, and in
+// development logs
+func DefaultRedactionPolicy(isDevEnv bool) RedactionPolicy {
+	if isDevEnv {
+		return RedactionPolicy{Mode: RedactionNone}
+	}
+
+	return RedactionPolicy{Mode: RedactionDrop}
+}
+
+// ParseRedactionPolicy reads "none", "hash", "truncate:<runes>" or "drop"
+func ParseRedactionPolicy(policy string) (RedactionPolicy, error) {
+	mode, lengthStr, hasLength := strings.Cut(strings.TrimSpace(policy), ":")
+
+	switch RedactionMode(mode) {
+	case RedactionNone, RedactionHash, RedactionDrop:
+		if hasLength {
+			return RedactionPolicy{}, fmt.Errorf("%q does not take a length", mode)
+		}
+		return RedactionPolicy{Mode: RedactionMode(mode)}, nil
+	case RedactionTruncate:
+		length, err := strconv.Atoi(lengthStr)
+		if err != nil || length < 0 {
+			return RedactionPolicy{}, fmt.Errorf("%q needs a length, eg. \"truncate:32\"", mode)
+		}
+		return RedactionPolicy{Mode: RedactionTruncate, TruncateLength: length}, nil
+	default:
+		return RedactionPolicy{}, fmt.Errorf("unknown mode %q, expected one of none, hash, truncate:<runes>, drop", mode)
+	}
+}
+
+func (p RedactionPolicy) String() string {
+	if p.Mode == RedactionTruncate {
+		return fmt.Sprintf("%s:%d", p.Mode, p.TruncateLength)
+	}
+
+	return string(p.Mode)
+}
+
+// Redact applies the policy to value. Hashes let equal values be matched
+// across log entries without keeping them.
+func (p RedactionPolicy) Redact(value string) string {
+	switch p.Mode {
+	case RedactionNone:
+		return value
+	case RedactionHash:
+		hash := sha256.Sum256([]byte(value))
+		return "sha256:" + hex.EncodeToString(hash[:8])
+	case RedactionTruncate:
+		runes := []rune(value)
+		if len(runes) <= p.TruncateLength {
+			return value
+		}
+		return string(runes[:p.TruncateLength]) + "…"
+	default:
+		return redactedValue
+	}
+}
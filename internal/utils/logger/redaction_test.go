@@ -0,0 +1,35 @@
+package logger
+
+import (
+	"testing"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func TestFieldsKeepErrorsAndRedactUserContent(t *testing.T) {
+	core, logs := observer.New(zapcore.InfoLevel)
+	logger := &Logger{
+		wrappedLogger: zap.New(core),
+		redaction:     DefaultRedactionPolicy(false),
+	}
+
+	logger.Error("Failed to fetch JWKS", map[string]string{
+		"error":          "fetch JWKS: connection refused",
+		"upstream_error": "invalid text \"my secret\"",
+		"text":           "my secret",
+	})
+
+	want := map[string]string{
+		"error":          "fetch JWKS: connection refused",
+		"upstream_error": redactedValue,
+		"text":           redactedValue,
+	}
+	fields := logs.All()[0].ContextMap()
+	for key, value := range want {
+		if fields[key] != value {
+			t.Errorf("field %s = %q, want %q", key, fields[key], value)
+		}
+	}
+}
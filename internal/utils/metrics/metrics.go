@@ -0,0 +1,167 @@
+package metrics
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+const namespace = "google_translate_api"
+
+// Buckets of the latency histograms, in seconds. Empty buckets fall back to
+// the Prometheus defaults.
+type Buckets struct {
+	HTTPRequest  []float64
+	UpstreamCall []float64
+}
+
+// Validate checks that each set of buckets is in increasing order
+func (b Buckets) Validate() error {
+	for name, buckets := range map[string][]float64{
+		"HTTP request":  b.HTTPRequest,
+		"upstream call": b.UpstreamCall,
+	} {
+		for i := 1; i < len(buckets); i++ {
+			if buckets[i] <= buckets[i-1] {
+				return fmt.Errorf("%s buckets must be in increasing order, got %v", name, buckets)
+			}
+		}
+	}
+
+	return nil
+}
+
+// Metrics are served in the Prometheus exposition format. The methods of a
+// nil Metrics do nothing.
+type Metrics struct {
+	registry *prometheus.Registry
+
+	httpRequests         *prometheus.CounterVec
+	httpRequestDuration  *prometheus.HistogramVec
+	httpRequestsInFlight prometheus.Gauge
+	upstreamCallDuration *prometheus.HistogramVec
+	upstreamCallErrors   *prometheus.CounterVec
+	translatedCharacters *prometheus.CounterVec
+	errors               *prometheus.CounterVec
+}
+
+func New(buckets Buckets) *Metrics {
+	if len(buckets.HTTPRequest) == 0 {
+		buckets.HTTPRequest = prometheus.DefBuckets
+	}
+	if len(buckets.UpstreamCall) == 0 {
+		buckets.UpstreamCall = prometheus.DefBuckets
+	}
+
+	m := &Metrics{
+		registry: prometheus.NewRegistry(),
+		httpRequests: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "http_requests_total",
+			Help:      "HTTP requests served, by route, method and status.",
+		}, []string{"route", "method", "status"}),
+		httpRequestDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Name:      "http_request_duration_seconds",
+			Help:      "Latency of HTTP requests, by route, method and status.",
+			Buckets:   buckets.HTTPRequest,
+		}, []string{"route", "method", "status"}),
+		httpRequestsInFlight: prometheus.NewGauge(prometheus.GaugeOpts{
+			Namespace: namespace,
+			Name:      "http_requests_in_flight",
+			Help:      "HTTP requests being served.",
+		}),
+		upstreamCallDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Name:      "upstream_call_duration_seconds",
+			Help:      "Latency of calls to Google translate, by backend and method.",
+			Buckets:   buckets.UpstreamCall,
+		}, []string{"backend", "method"}),
+		upstreamCallErrors: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "upstream_call_errors_total",
+			Help:      "Failed calls to Google translate, by backend, method and error class.",
+		}, []string{"backend", "method", "class"}),
+		translatedCharacters: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "translated_characters_total",
+			Help:      "Characters translated by Google translate, by backend and language pair.",
+		}, []string{"backend", "source_locale", "target_locale"}),
+		errors: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "errors_total",
+			Help:      "Errors returned to clients, by error code.",
+		}, []string{"code"}),
+	}
+
+	m.registry.MustRegister(
+		m.httpRequests,
+		m.httpRequestDuration,
+		m.httpRequestsInFlight,
+		m.upstreamCallDuration,
+		m.upstreamCallErrors,
+		m.translatedCharacters,
+		m.errors,
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+	)
+
+	return m
+}
+
+func (m *Metrics) Handler() http.Handler {
+	return promhttp.HandlerFor(m.registry, promhttp.HandlerOpts{})
+}
+
+// StartRequest counts a request in flight until the returned func is called
+// with its route and status
+func (m *Metrics) StartRequest() func(route, method string, status int) {
+	if m == nil {
+		return func(string, string, int) {}
+	}
+
+	start := time.Now()
+	m.httpRequestsInFlight.Inc()
+
+	return func(route, method string, status int) {
+		m.httpRequestsInFlight.Dec()
+
+		statusLabel := strconv.Itoa(status)
+		m.httpRequests.WithLabelValues(route, method, statusLabel).Inc()
+		m.httpRequestDuration.WithLabelValues(route, method, statusLabel).Observe(time.Since(start).Seconds())
+	}
+}
+
+// ObserveUpstreamCall records a call to Google translate, errorClass being
+// empty for calls that succeeded
+func (m *Metrics) ObserveUpstreamCall(backend, method string, start time.Time, errorClass string) {
+	if m == nil {
+		return
+	}
+
+	m.upstreamCallDuration.WithLabelValues(backend, method).Observe(time.Since(start).Seconds())
+	if errorClass != "" {
+		m.upstreamCallErrors.WithLabelValues(backend, method, errorClass).Inc()
+	}
+}
+
+func (m *Metrics) AddTranslatedCharacters(backend, sourceLocale, targetLocale string, characters int64) {
+	if m == nil {
+		return
+	}
+
+	m.translatedCharacters.WithLabelValues(backend, sourceLocale, targetLocale).Add(float64(characters))
+}
+
+func (m *Metrics) CountError(code string) {
+	if m == nil {
+		return
+	}
+
+	m.errors.WithLabelValues(code).Inc()
+}
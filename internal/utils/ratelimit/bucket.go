@@ -0,0 +1,94 @@
+package ratelimit
+
+import (
+	"math"
+	"sync"
+	"time"
+)
+
+// bucket holds up to a minute worth of tokens, refilled continuously. Tokens
+// can go negative, which is how waiting callers queue up behind each other.
+type bucket struct {
+	mutex         sync.Mutex
+	capacity      float64
+	ratePerSecond float64
+	tokens        float64
+	updatedAt     time.Time
+}
+
+// newBucket returns nil, which never limits, for perMinute <= 0
+func newBucket(perMinute int) *bucket {
+	if perMinute <= 0 {
+		return nil
+	}
+
+	return &bucket{
+		capacity:      float64(perMinute),
+		ratePerSecond: float64(perMinute) / 60,
+		tokens:        float64(perMinute),
+		updatedAt:     time.Now(),
+	}
+}
+
+// reserve takes n tokens, returning how long until they are available and
+// how many were taken. Requests larger than the bucket take all of it.
+func (b *bucket) reserve(n float64, now time.Time) (time.Duration, float64) {
+	if b == nil {
+		return 0, 0
+	}
+
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	b.refill(now)
+	n = math.Min(n, b.capacity)
+	b.tokens -= n
+	if b.tokens >= 0 {
+		return 0, n
+	}
+
+	return time.Duration(-b.tokens / b.ratePerSecond * float64(time.Second)), n
+}
+
+// resized returns a bucket of perMinute tokens holding what is left in b,
+// so changing a limit does not refill it. b is kept when its rate is the
+// same.
+func (b *bucket) resized(perMinute int, now time.Time) *bucket {
+	if b == nil || perMinute <= 0 {
+		return newBucket(perMinute)
+	}
+	if float64(perMinute) == b.capacity {
+		return b
+	}
+
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	b.refill(now)
+	resized := newBucket(perMinute)
+	resized.tokens = math.Min(b.tokens, resized.capacity)
+	resized.updatedAt = now
+
+	return resized
+}
+
+func (b *bucket) refund(n float64) {
+	if b == nil || n == 0 {
+		return
+	}
+
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	b.tokens = math.Min(b.tokens+n, b.capacity)
+}
+
+func (b *bucket) refill(now time.Time) {
+	elapsed := now.Sub(b.updatedAt).Seconds()
+	if elapsed <= 0 {
+		return
+	}
+
+	b.tokens = math.Min(b.tokens+elapsed*b.ratePerSecond, b.capacity)
+	b.updatedAt = now
+}
@@ -0,0 +1,212 @@
+package ratelimit
+
+import (
+	"context"
+	"fmt"
+	"sync/atomic"
+	"time"
+	"unicode/utf8"
+
+	"github.com/weiyuan-lane/google-translate-api/internal/utils/errorhandlers"
+)
+
+// Limits of 0 are unlimited. Callers wait up to MaxWait for tokens and a
+// concurrency slot before being turned away.
+type Limits struct {
+	RequestsPerMinute   int
+	CharactersPerMinute int
+	MaxConcurrent       int
+	MaxWait             time.Duration
+}
+
+// Limiter is shared by every call to one upstream backend
+type Limiter struct {
+	name string
+	// Acquired after the limiter itself, eg. the limiter of the deployment
+	// for a tenant limiter
+	parent *Limiter
+	state  atomic.Pointer[limiterState]
+}
+
+// limiterState is swapped as a whole by SetLimits, calls already waiting
+// finish against the state they started with
+type limiterState struct {
+	limits     Limits
+	requests   *bucket
+	characters *bucket
+	slots      chan struct{}
+}
+
+func New(name string, limits Limits) *Limiter {
+	limiter := &Limiter{name: name}
+	limiter.SetLimits(limits)
+
+	return limiter
+}
+
+// NewWithin creates a limiter whose calls must also fit within the limits
+// of parent. A nil parent does not limit.
+func NewWithin(parent *Limiter, name string, limits Limits) *Limiter {
+	limiter := New(name, limits)
+	limiter.parent = parent
+
+	return limiter
+}
+
+// SetLimits replaces the limits, if they changed. Buckets keep the tokens
+// left, and calls in flight keep their slots while MaxConcurrent is the
+// same, so reloading a config lets no extra calls through. The first limits
+// start with full buckets.
+func (l *Limiter) SetLimits(limits Limits) {
+	previous := l.state.Load()
+	if previous == nil {
+		l.state.Store(&limiterState{
+			limits:     limits,
+			requests:   newBucket(limits.RequestsPerMinute),
+			characters: newBucket(limits.CharactersPerMinute),
+			slots:      newSlots(limits.MaxConcurrent),
+		})
+		return
+	}
+	if previous.limits == limits {
+		return
+	}
+
+	now := time.Now()
+	state := &limiterState{
+		limits:     limits,
+		requests:   previous.requests.resized(limits.RequestsPerMinute, now),
+		characters: previous.characters.resized(limits.CharactersPerMinute, now),
+		slots:      previous.slots,
+	}
+	if limits.MaxConcurrent != previous.limits.MaxConcurrent {
+		state.slots = newSlots(limits.MaxConcurrent)
+	}
+
+	l.state.Store(state)
+}
+
+// newSlots returns nil, which never limits, for maxConcurrent <= 0
+func newSlots(maxConcurrent int) chan struct{} {
+	if maxConcurrent <= 0 {
+		return nil
+	}
+
+	return make(chan struct{}, maxConcurrent)
+}
+
+func (l *Limiter) Limits() Limits {
+	return l.state.Load().limits
+}
+
+func (l Limits) IsLimited() bool {
+	return l.RequestsPerMinute > 0 || l.CharactersPerMinute > 0 || l.MaxConcurrent > 0
+}
+
+func (l Limits) String() string {
+	return fmt.Sprintf(
+		"%d requests/min, %d characters/min, %d concurrent, waiting up to %s",
+		l.RequestsPerMinute,
+		l.CharactersPerMinute,
+		l.MaxConcurrent,
+		l.MaxWait,
+	)
+}
+
+// Acquire waits for one request and the characters of text, then for a
+// concurrency slot, which the returned release gives back. Tokens are only
+// taken when the wait fits within MaxWait. The parent is acquired last, and
+// a call it turns away gives back what it took here. A nil Limiter does not
+// limit.
+func (l *Limiter) Acquire(ctx context.Context, text string) (func(), error) {
+	if l == nil {
+		return func() {}, nil
+	}
+
+	release, refund, err := l.acquire(ctx, text)
+	if err != nil {
+		return nil, err
+	}
+	if l.parent == nil {
+		return release, nil
+	}
+
+	releaseParent, err := l.parent.Acquire(ctx, text)
+	if err != nil {
+		release()
+		refund()
+		return nil, err
+	}
+
+	return func() {
+		releaseParent()
+		release()
+	}, nil
+}
+
+// acquire takes from this limiter only, also returning how to refund the
+// tokens taken
+func (l *Limiter) acquire(ctx context.Context, text string) (func(), func(), error) {
+	state := l.state.Load()
+
+	now := time.Now()
+	deadline := now.Add(state.limits.MaxWait)
+
+	requestsWait, requestsTaken := state.requests.reserve(1, now)
+	charactersWait, charactersTaken := state.characters.reserve(float64(utf8.RuneCountInString(text)), now)
+	refund := func() {
+		state.requests.refund(requestsTaken)
+		state.characters.refund(charactersTaken)
+	}
+
+	wait := requestsWait
+	if charactersWait > wait {
+		wait = charactersWait
+	}
+
+	if wait > state.limits.MaxWait {
+		refund()
+		return nil, nil, errorhandlers.Wrap(
+			errorhandlers.ErrRateLimitExceeded,
+			fmt.Sprintf("%s rate limit exceeded, retry in %s", l.name, wait.Round(time.Second)),
+		)
+	}
+
+	if wait > 0 {
+		timer := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			refund()
+			return nil, nil, errorhandlers.Wrap(
+				errorhandlers.ErrRateLimitExceeded,
+				fmt.Sprintf("%s rate limit wait cancelled: %s", l.name, ctx.Err().Error()),
+			)
+		case <-timer.C:
+		}
+	}
+
+	if state.slots == nil {
+		return func() {}, refund, nil
+	}
+
+	slotTimer := time.NewTimer(time.Until(deadline))
+	defer slotTimer.Stop()
+
+	select {
+	case state.slots <- struct{}{}:
+		return func() { <-state.slots }, refund, nil
+	case <-ctx.Done():
+		refund()
+		return nil, nil, errorhandlers.Wrap(
+			errorhandlers.ErrConcurrencyLimitExceeded,
+			fmt.Sprintf("%s concurrency limit wait cancelled: %s", l.name, ctx.Err().Error()),
+		)
+	case <-slotTimer.C:
+		refund()
+		return nil, nil, errorhandlers.Wrap(
+			errorhandlers.ErrConcurrencyLimitExceeded,
+			fmt.Sprintf("%s has %d requests in flight, waited %s", l.name, state.limits.MaxConcurrent, state.limits.MaxWait),
+		)
+	}
+}
@@ -0,0 +1,81 @@
+package ratelimit
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestLimiterWithinParent(t *testing.T) {
+	deployment := New("deployment", Limits{RequestsPerMinute: 1})
+	tenant := NewWithin(deployment, "tenant", Limits{RequestsPerMinute: 2, MaxConcurrent: 1, MaxWait: time.Second})
+
+	release, err := tenant.Acquire(context.Background(), "hello")
+	if err != nil {
+		t.Fatalf("first Acquire() error = %v", err)
+	}
+	release()
+
+	// The tenant has a request left, which the deployment turns away
+	if _, err := tenant.Acquire(context.Background(), "hello"); err == nil {
+		t.Fatal("Acquire() over the limits of the deployment error = nil, want an error")
+	}
+
+	// That request and its slot were given back to the tenant
+	deployment.SetLimits(Limits{})
+	if _, err := tenant.Acquire(context.Background(), "hello"); err != nil {
+		t.Errorf("Acquire() after the deployment turned a call away error = %v", err)
+	}
+}
+
+func TestLimiterWithinNilParent(t *testing.T) {
+	tenant := NewWithin(nil, "tenant", Limits{RequestsPerMinute: 1})
+
+	if _, err := tenant.Acquire(context.Background(), "hello"); err != nil {
+		t.Fatalf("Acquire() error = %v", err)
+	}
+	if _, err := tenant.Acquire(context.Background(), "hello"); err == nil {
+		t.Error("Acquire() over the limits of the tenant error = nil, want an error")
+	}
+}
+
+func TestSetLimitsKeepsTokensAndSlots(t *testing.T) {
+	limits := Limits{RequestsPerMinute: 1, MaxConcurrent: 1, MaxWait: 10 * time.Millisecond}
+	limiter := New("backend", limits)
+
+	if _, err := limiter.Acquire(context.Background(), "hello"); err != nil {
+		t.Fatalf("Acquire() error = %v", err)
+	}
+
+	// Reloading the same limits, or only a longer wait, refills nothing
+	limiter.SetLimits(limits)
+	if _, err := limiter.Acquire(context.Background(), "hello"); err == nil {
+		t.Error("Acquire() after reloading the same limits error = nil, want the bucket still empty")
+	}
+
+	limits.MaxWait = 20 * time.Millisecond
+	limiter.SetLimits(limits)
+	if _, err := limiter.Acquire(context.Background(), "hello"); err == nil {
+		t.Error("Acquire() after changing MaxWait error = nil, want the bucket still empty")
+	}
+
+	// The slot of the first call stays taken
+	limits.RequestsPerMinute = 0
+	limiter.SetLimits(limits)
+	if _, err := limiter.Acquire(context.Background(), "hello"); err == nil {
+		t.Error("Acquire() with the only slot in flight error = nil, want an error")
+	}
+}
+
+func TestSetLimitsKeepsTokensLeftWhenResized(t *testing.T) {
+	limiter := New("backend", Limits{CharactersPerMinute: 10})
+
+	if _, err := limiter.Acquire(context.Background(), "0123456789"); err != nil {
+		t.Fatalf("Acquire() error = %v", err)
+	}
+
+	limiter.SetLimits(Limits{CharactersPerMinute: 20})
+	if _, err := limiter.Acquire(context.Background(), "0123456789"); err == nil {
+		t.Error("Acquire() after raising the limit error = nil, want the characters already used counted")
+	}
+}
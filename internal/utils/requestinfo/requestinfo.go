@@ -0,0 +1,141 @@
+package requestinfo
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"strconv"
+	"sync"
+)
+
+// Header carries the request ID, from clients and back to them
+const Header = "X-Request-ID"
+
+// Longest client request ID accepted, longer ones are replaced
+const maxIDLength = 128
+
+type contextKey struct{}
+
+// Info is filled in while a request is served, for its access log
+type Info struct {
+	ID string
+
+	mutex    sync.Mutex
+	route    string
+	tenant   string
+	backend  string
+	cacheHit bool
+}
+
+func WithInfo(ctx context.Context, info *Info) context.Context {
+	return context.WithValue(ctx, contextKey{}, info)
+}
+
+// FromContext returns the info of the request of ctx, or nil outside of a
+// request. The setters of a nil Info do nothing.
+func FromContext(ctx context.Context) *Info {
+	info, _ := ctx.Value(contextKey{}).(*Info)
+	return info
+}
+
+// IDFromContext returns the request ID of ctx, or "" outside of a request
+func IDFromContext(ctx context.Context) string {
+	if info := FromContext(ctx); info != nil {
+		return info.ID
+	}
+
+	return ""
+}
+
+// NewID keeps a client's request ID if it is usable as a log field, and
+// generates one otherwise
+func NewID(clientID string) string {
+	if isValidID(clientID) {
+		return clientID
+	}
+
+	idBytes := make([]byte, 16)
+	if _, err := rand.Read(idBytes); err != nil {
+		panic(err)
+	}
+
+	return hex.EncodeToString(idBytes)
+}
+
+func (i *Info) SetRoute(route string) {
+	if i == nil {
+		return
+	}
+
+	i.mutex.Lock()
+	defer i.mutex.Unlock()
+	i.route = route
+}
+
+func (i *Info) SetTenant(tenant string) {
+	if i == nil {
+		return
+	}
+
+	i.mutex.Lock()
+	defer i.mutex.Unlock()
+	i.tenant = tenant
+}
+
+// SetBackend records the backend or provider that served the request. With
+// failover, the last one to succeed wins.
+func (i *Info) SetBackend(backend string) {
+	if i == nil {
+		return
+	}
+
+	i.mutex.Lock()
+	defer i.mutex.Unlock()
+	i.backend = backend
+}
+
+func (i *Info) SetCacheHit(cacheHit bool) {
+	if i == nil {
+		return
+	}
+
+	i.mutex.Lock()
+	defer i.mutex.Unlock()
+	i.cacheHit = cacheHit
+}
+
+// Route is the route template, or "" when no route matched
+func (i *Info) Route() string {
+	i.mutex.Lock()
+	defer i.mutex.Unlock()
+
+	return i.route
+}
+
+// Fields are added to the access log of the request
+func (i *Info) Fields() map[string]string {
+	i.mutex.Lock()
+	defer i.mutex.Unlock()
+
+	return map[string]string{
+		"request_id": i.ID,
+		"tenant":     i.tenant,
+		"backend":    i.backend,
+		"cache_hit":  strconv.FormatBool(i.cacheHit),
+	}
+}
+
+func isValidID(id string) bool {
+	if id == "" || len(id) > maxIDLength {
+		return false
+	}
+
+	for _, char := range id {
+		isAlphanumeric := (char >= 'a' && char <= 'z') || (char >= 'A' && char <= 'Z') || (char >= '0' && char <= '9')
+		if !isAlphanumeric && char != '-' && char != '_' && char != '.' && char != ':' {
+			return false
+		}
+	}
+
+	return true
+}
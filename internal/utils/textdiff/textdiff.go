@@ -0,0 +1,197 @@
+package textdiff
+
+import (
+	"unicode"
+	"unicode/utf8"
+)
+
+const (
+	OpEqual  = "equal"
+	OpDelete = "delete"
+	OpInsert = "insert"
+)
+
+// MaxTokens bounds the tokens of each text. Longer texts are diffed as a
+// whole replacement, as the diff takes time quadratic in the tokens.
+const MaxTokens = 2000
+
+type Chunk struct {
+	Op   string
+	Text string
+}
+
+// Tokens splits text into words, whitespace runs and punctuation. Scripts
+// written without spaces (eg. Chinese, Japanese, Thai) are split per rune.
+func Tokens(text string) []string {
+	tokens := []string{}
+	start := 0
+	previousClass := -1
+
+	for i, r := range text {
+		class := tokenClass(r)
+		if i > start && (class != previousClass || class == classSingle) {
+			tokens = append(tokens, text[start:i])
+			start = i
+		}
+		previousClass = class
+	}
+
+	if start < len(text) {
+		tokens = append(tokens, text[start:])
+	}
+
+	return tokens
+}
+
+// Diff compares the tokens of before and after, returning the chunks needed
+// to turn one into the other
+func Diff(before, after string) []Chunk {
+	beforeTokens := Tokens(before)
+	afterTokens := Tokens(after)
+
+	if len(beforeTokens) > MaxTokens || len(afterTokens) > MaxTokens {
+		return compact([]Chunk{
+			{Op: OpDelete, Text: before},
+			{Op: OpInsert, Text: after},
+		})
+	}
+
+	return compact(diffTokens(beforeTokens, afterTokens, []Chunk{}))
+}
+
+// diffTokens appends the chunks of a longest common subsequence of before
+// and after to chunks. It splits before in half, and after where the LCS of
+// both halves is longest (Hirschberg), so it only keeps one row of LCS
+// lengths at a time instead of the whole matrix.
+func diffTokens(before, after []string, chunks []Chunk) []Chunk {
+	// Common prefixes and suffixes are in every LCS
+	prefix := 0
+	for prefix < len(before) && prefix < len(after) && before[prefix] == after[prefix] {
+		chunks = append(chunks, Chunk{Op: OpEqual, Text: before[prefix]})
+		prefix++
+	}
+	before, after = before[prefix:], after[prefix:]
+
+	suffix := 0
+	for suffix < len(before) && suffix < len(after) && before[len(before)-1-suffix] == after[len(after)-1-suffix] {
+		suffix++
+	}
+	common := before[len(before)-suffix:]
+	before, after = before[:len(before)-suffix], after[:len(after)-suffix]
+
+	switch {
+	case len(before) == 0:
+		chunks = appendChunks(chunks, OpInsert, after)
+	case len(after) == 0:
+		chunks = appendChunks(chunks, OpDelete, before)
+	case len(before) == 1:
+		// The token is not in the trimmed prefix or suffix, so it can only
+		// match inside after
+		match := -1
+		for i, token := range after {
+			if token == before[0] {
+				match = i
+				break
+			}
+		}
+
+		if match < 0 {
+			chunks = append(chunks, Chunk{Op: OpDelete, Text: before[0]})
+			chunks = appendChunks(chunks, OpInsert, after)
+		} else {
+			chunks = appendChunks(chunks, OpInsert, after[:match])
+			chunks = append(chunks, Chunk{Op: OpEqual, Text: before[0]})
+			chunks = appendChunks(chunks, OpInsert, after[match+1:])
+		}
+	default:
+		middle := len(before) / 2
+		forward := lcsLengths(before[:middle], after, false)
+		backward := lcsLengths(before[middle:], after, true)
+
+		split, longest := 0, -1
+		for j := 0; j <= len(after); j++ {
+			if length := forward[j] + backward[len(after)-j]; length > longest {
+				split, longest = j, length
+			}
+		}
+
+		chunks = diffTokens(before[:middle], after[:split], chunks)
+		chunks = diffTokens(before[middle:], after[split:], chunks)
+	}
+
+	return appendChunks(chunks, OpEqual, common)
+}
+
+// lcsLengths returns, for each j, the LCS length of before and the first j
+// tokens of after, or of their last j tokens when reversed
+func lcsLengths(before, after []string, reversed bool) []int {
+	at := func(tokens []string, i int) string {
+		if reversed {
+			return tokens[len(tokens)-1-i]
+		}
+		return tokens[i]
+	}
+
+	row := make([]int, len(after)+1)
+	for i := range before {
+		diagonal := 0
+		for j := range after {
+			above := row[j+1]
+			if at(before, i) == at(after, j) {
+				row[j+1] = diagonal + 1
+			} else if row[j] > above {
+				row[j+1] = row[j]
+			}
+			diagonal = above
+		}
+	}
+
+	return row
+}
+
+func appendChunks(chunks []Chunk, op string, tokens []string) []Chunk {
+	for _, token := range tokens {
+		chunks = append(chunks, Chunk{Op: op, Text: token})
+	}
+
+	return chunks
+}
+
+func compact(chunks []Chunk) []Chunk {
+	results := []Chunk{}
+	for _, chunk := range chunks {
+		if chunk.Text == "" {
+			continue
+		}
+
+		if len(results) > 0 && results[len(results)-1].Op == chunk.Op {
+			results[len(results)-1].Text += chunk.Text
+			continue
+		}
+
+		results = append(results, chunk)
+	}
+
+	return results
+}
+
+const (
+	classWord = iota
+	classSpace
+	classSingle
+)
+
+func tokenClass(r rune) int {
+	switch {
+	case r == utf8.RuneError:
+		return classSingle
+	case unicode.IsSpace(r):
+		return classSpace
+	case unicode.In(r, unicode.Han, unicode.Hiragana, unicode.Katakana, unicode.Thai, unicode.Lao, unicode.Khmer, unicode.Myanmar):
+		return classSingle
+	case unicode.IsLetter(r) || unicode.IsDigit(r) || unicode.IsMark(r):
+		return classWord
+	default:
+		return classSingle
+	}
+}
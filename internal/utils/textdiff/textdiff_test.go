@@ -0,0 +1,136 @@
+package textdiff
+
+import (
+	"math/rand"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestDiff(t *testing.T) {
+	tests := []struct {
+		name   string
+		before string
+		after  string
+		want   []Chunk
+	}{
+		{
+			name:   "changed word",
+			before: "The cat sat down.",
+			after:  "The dog sat down.",
+			want: []Chunk{
+				{Op: OpEqual, Text: "The "},
+				{Op: OpDelete, Text: "cat"},
+				{Op: OpInsert, Text: "dog"},
+				{Op: OpEqual, Text: " sat down."},
+			},
+		},
+		{
+			name:   "inserted words",
+			before: "Open the file",
+			after:  "Open the shared file",
+			want: []Chunk{
+				{Op: OpEqual, Text: "Open the "},
+				{Op: OpInsert, Text: "shared "},
+				{Op: OpEqual, Text: "file"},
+			},
+		},
+		{
+			name:   "characters of scripts without spaces",
+			before: "我喜欢猫",
+			after:  "我喜欢狗",
+			want: []Chunk{
+				{Op: OpEqual, Text: "我喜欢"},
+				{Op: OpDelete, Text: "猫"},
+				{Op: OpInsert, Text: "狗"},
+			},
+		},
+		{
+			name:   "identical",
+			before: "Hello world",
+			after:  "Hello world",
+			want:   []Chunk{{Op: OpEqual, Text: "Hello world"}},
+		},
+		{
+			name:   "empty before",
+			before: "",
+			after:  "Hello",
+			want:   []Chunk{{Op: OpInsert, Text: "Hello"}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Diff(tt.before, tt.after); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Diff() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDiffReplacesTextsOverMaxTokens(t *testing.T) {
+	before := strings.Repeat("a ", MaxTokens)
+	after := before + "b"
+
+	want := []Chunk{{Op: OpDelete, Text: before}, {Op: OpInsert, Text: after}}
+	if got := Diff(before, after); !reflect.DeepEqual(got, want) {
+		t.Errorf("Diff() of %d tokens did not fall back to a replacement", len(Tokens(after)))
+	}
+}
+
+// referenceLCS is the LCS length from the full matrix
+func referenceLCS(before, after []string) int {
+	lcs := make([][]int, len(before)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(after)+1)
+	}
+	for i := len(before) - 1; i >= 0; i-- {
+		for j := len(after) - 1; j >= 0; j-- {
+			if before[i] == after[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else if lcs[i+1][j] >= lcs[i][j+1] {
+				lcs[i][j] = lcs[i+1][j]
+			} else {
+				lcs[i][j] = lcs[i][j+1]
+			}
+		}
+	}
+
+	return lcs[0][0]
+}
+
+func TestDiffTokensFindsALongestCommonSubsequence(t *testing.T) {
+	random := rand.New(rand.NewSource(1))
+	randomTokens := func() []string {
+		tokens := make([]string, random.Intn(30))
+		for i := range tokens {
+			tokens[i] = string(rune('a' + random.Intn(4)))
+		}
+		return tokens
+	}
+
+	for i := 0; i < 500; i++ {
+		before, after := randomTokens(), randomTokens()
+		chunks := diffTokens(before, after, []Chunk{})
+
+		gotBefore, gotAfter, equal := []string{}, []string{}, 0
+		for _, chunk := range chunks {
+			if chunk.Op != OpInsert {
+				gotBefore = append(gotBefore, chunk.Text)
+			}
+			if chunk.Op != OpDelete {
+				gotAfter = append(gotAfter, chunk.Text)
+			}
+			if chunk.Op == OpEqual {
+				equal++
+			}
+		}
+
+		if !reflect.DeepEqual(gotBefore, before) || !reflect.DeepEqual(gotAfter, after) {
+			t.Fatalf("diffTokens(%v, %v) = %+v, which does not rebuild both", before, after, chunks)
+		}
+		if want := referenceLCS(before, after); equal != want {
+			t.Fatalf("diffTokens(%v, %v) kept %d tokens, want the LCS of %d", before, after, equal, want)
+		}
+	}
+}
@@ -0,0 +1,201 @@
+package tlsconfig
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"os"
+	"sync/atomic"
+	"time"
+
+	loggerutils "github.com/weiyuan-lane/google-translate-api/internal/utils/logger"
+)
+
+// Config of a TLS listener. Client certificates are verified against
+// ClientCAFile when it is set, and only required with ClientCertRequired.
+type Config struct {
+	CertFile           string
+	KeyFile            string
+	ClientCAFile       string
+	ClientCertRequired bool
+	MinVersion         uint16
+	HTTP2              bool
+}
+
+func (c Config) Validate() error {
+	if c.CertFile == "" || c.KeyFile == "" {
+		return fmt.Errorf("both TLS_CERT_FILE and TLS_KEY_FILE must be set")
+	}
+
+	if c.ClientCertRequired && c.ClientCAFile == "" {
+		return fmt.Errorf("client certificates can only be required with TLS_CLIENT_CA_FILE")
+	}
+
+	return nil
+}
+
+// ParseMinVersion reads "1.2" or "1.3", older versions are not accepted
+func ParseMinVersion(version string) (uint16, error) {
+	switch version {
+	case "1.2":
+		return tls.VersionTLS12, nil
+	case "1.3":
+		return tls.VersionTLS13, nil
+	}
+
+	return 0, fmt.Errorf("TLS version %q must be 1.2 or 1.3", version)
+}
+
+// Reloader serves the certificate and client CAs from their files, and
+// swaps them in when the files change, so certificates can rotate without
+// a restart
+type Reloader struct {
+	config   Config
+	state    atomic.Pointer[state]
+	modTimes atomic.Pointer[map[string]time.Time]
+}
+
+type state struct {
+	certificate *tls.Certificate
+	clientCAs   *x509.CertPool
+}
+
+func NewReloader(config Config) (*Reloader, error) {
+	if err := config.Validate(); err != nil {
+		return nil, err
+	}
+
+	reloader := &Reloader{config: config}
+	if err := reloader.Reload(); err != nil {
+		return nil, err
+	}
+
+	return reloader, nil
+}
+
+// Reload keeps the current certificate when the files cannot be loaded
+func (r *Reloader) Reload() error {
+	modTimes := r.currentModTimes()
+
+	certificate, err := tls.LoadX509KeyPair(r.config.CertFile, r.config.KeyFile)
+	if err != nil {
+		return fmt.Errorf("load TLS certificate: %w", err)
+	}
+
+	var clientCAs *x509.CertPool
+	if r.config.ClientCAFile != "" {
+		caBytes, err := os.ReadFile(r.config.ClientCAFile)
+		if err != nil {
+			return fmt.Errorf("load client CAs: %w", err)
+		}
+
+		clientCAs = x509.NewCertPool()
+		if !clientCAs.AppendCertsFromPEM(caBytes) {
+			return fmt.Errorf("load client CAs: no PEM certificate found in %s", r.config.ClientCAFile)
+		}
+	}
+
+	r.state.Store(&state{certificate: &certificate, clientCAs: clientCAs})
+	r.modTimes.Store(&modTimes)
+
+	return nil
+}
+
+// Watch reloads whenever the modification time of a file changes, until
+// ctx is done
+func (r *Reloader) Watch(ctx context.Context, interval time.Duration, logger *loggerutils.Logger) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		modTimes := r.currentModTimes()
+		if equalModTimes(modTimes, *r.modTimes.Load()) {
+			continue
+		}
+
+		if err := r.Reload(); err != nil {
+			logger.Error(fmt.Sprintf("Keeping previous TLS certificate: %s", err.Error()))
+
+			// Only retry once a file changes again
+			r.modTimes.Store(&modTimes)
+			continue
+		}
+
+		logger.Info(fmt.Sprintf("Reloaded TLS certificate from %s", r.config.CertFile))
+	}
+}
+
+// TLSConfig is for the server, and takes the latest certificate and client
+// CAs on every handshake
+func (r *Reloader) TLSConfig() *tls.Config {
+	nextProtos := []string{"http/1.1"}
+	if r.config.HTTP2 {
+		nextProtos = []string{"h2", "http/1.1"}
+	}
+
+	clientAuth := tls.NoClientCert
+	if r.config.ClientCAFile != "" {
+		clientAuth = tls.VerifyClientCertIfGiven
+		if r.config.ClientCertRequired {
+			clientAuth = tls.RequireAndVerifyClientCert
+		}
+	}
+
+	base := &tls.Config{
+		MinVersion: r.config.MinVersion,
+		NextProtos: nextProtos,
+		ClientAuth: clientAuth,
+		GetCertificate: func(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+			return r.state.Load().certificate, nil
+		},
+	}
+
+	base.GetConfigForClient = func(*tls.ClientHelloInfo) (*tls.Config, error) {
+		current := r.state.Load()
+
+		config := base.Clone()
+		config.GetConfigForClient = nil
+		config.Certificates = []tls.Certificate{*current.certificate}
+		config.ClientCAs = current.clientCAs
+
+		return config, nil
+	}
+
+	return base
+}
+
+func (r *Reloader) currentModTimes() map[string]time.Time {
+	modTimes := map[string]time.Time{}
+	for _, path := range []string{r.config.CertFile, r.config.KeyFile, r.config.ClientCAFile} {
+		if path == "" {
+			continue
+		}
+
+		if fileInfo, err := os.Stat(path); err == nil {
+			modTimes[path] = fileInfo.ModTime()
+		}
+	}
+
+	return modTimes
+}
+
+func equalModTimes(a, b map[string]time.Time) bool {
+	if len(a) != len(b) {
+		return false
+	}
+
+	for path, modTime := range a {
+		if !modTime.Equal(b[path]) {
+			return false
+		}
+	}
+
+	return true
+}